@@ -6,6 +6,7 @@ import (
 	"math"
 	"net/http"
 	"sort"
+	"strings"
 
 	"github.com/Mtze/CI-Benchmarker/persister"
 	_ "github.com/Mtze/CI-Benchmarker/shared/response"
@@ -44,37 +45,29 @@ type MetricSummary struct {
 // @Param        from         query  string  false  "Start time (RFC3339)"
 // @Param        to           query  string  false  "End time (RFC3339)"
 // @Param        commit_hash  query  string  false  "Optional commit hash filter"
+// @Param        payload_hash query  string  false  "Optional payload content hash filter"
+// @Param        label.key    query  string  false  "Optional label filter, e.g. label.cluster=aks (repeatable with different keys)"
 // @Param		 executor     query  string  true  "executor filter"
 // @Success      200  {string}  binary  "PNG image"
 // @Failure 	 400  {object} 	response.ErrorMessage
 // @Failure      500  {object}  response.ServerErrorMessage
 // @Router       /benchmark/queue_latency/histogram [get]
-func GetQueueLatencyHistogram(c *gin.Context) {
-	from, to, ok := utils.ParseTimeParams(c)
-	if !ok {
-		return
-	}
-
-	var commitHash *string
-	if hash := c.Query("commit_hash"); hash != "" {
-		commitHash = &hash
-	}
-
-	var executor string
-	if executor = c.Query("executor"); executor == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Executor filter is required"})
-		return
-	}
-
-	p := persister.NewDBPersister()
-	latencies, err := p.GetQueueLatenciesInRange(from, to, commitHash, executor)
-	if err != nil {
-		log.Println("Error fetching queue latencies:", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch queue latencies"})
-		return
+func GetQueueLatencyHistogram(p persister.Database) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		filter, ok := parseFilter(c, false)
+		if !ok {
+			return
+		}
+
+		latencies, err := p.GetQueueLatenciesInRange(filter)
+		if err != nil {
+			log.Println("Error fetching queue latencies:", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch queue latencies"})
+			return
+		}
+
+		renderPlotAsPNG(c, "Queue Latency Distribution", "Queue Latency (s)", "Frequency", latencies, 20)
 	}
-
-	renderPlotAsPNG(c, "Queue Latency Distribution", "Queue Latency (s)", "Frequency", latencies, 20)
 }
 
 // GetBuildTimeHistogram godoc
@@ -86,37 +79,29 @@ func GetQueueLatencyHistogram(c *gin.Context) {
 // @Param        from         query  string  false  "Start time (RFC3339)"
 // @Param        to           query  string  false  "End time (RFC3339)"
 // @Param        commit_hash  query  string  false  "Optional commit hash filter"
+// @Param        payload_hash query  string  false  "Optional payload content hash filter"
+// @Param        label.key    query  string  false  "Optional label filter, e.g. label.cluster=aks (repeatable with different keys)"
 // @Param		 executor     query  string  true  "executor filter"
 // @Success      200  {string}  binary  "PNG image"
 // @Failure 	 400  {object} 	response.ErrorMessage
 // @Failure      500  {object}   response.ServerErrorMessage
 // @Router       /benchmark/build_time/histogram [get]
-func GetBuildTimeHistogram(c *gin.Context) {
-	from, to, ok := utils.ParseTimeParams(c)
-	if !ok {
-		return
-	}
-
-	var commitHash *string
-	if hash := c.Query("commit_hash"); hash != "" {
-		commitHash = &hash
+func GetBuildTimeHistogram(p persister.Database) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		filter, ok := parseFilter(c, false)
+		if !ok {
+			return
+		}
+
+		buildTimes, err := p.GetBuildTimesInRange(filter)
+		if err != nil {
+			log.Println("Error fetching build times:", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch build times"})
+			return
+		}
+
+		renderPlotAsPNG(c, "Build Time Distribution", "Build Time (s)", "Frequency", buildTimes, 20)
 	}
-
-	var executor string
-	if executor = c.Query("executor"); executor == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Executor filter is required"})
-		return
-	}
-
-	p := persister.NewDBPersister()
-	buildTimes, err := p.GetBuildTimesInRange(from, to, commitHash, executor)
-	if err != nil {
-		log.Println("Error fetching build times:", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch build times"})
-		return
-	}
-
-	renderPlotAsPNG(c, "Build Time Distribution", "Build Time (s)", "Frequency", buildTimes, 20)
 }
 
 // GetTotalLatencyHistogram godoc
@@ -128,37 +113,29 @@ func GetBuildTimeHistogram(c *gin.Context) {
 // @Param        from         query  string  false  "Start time (RFC3339)"
 // @Param        to           query  string  false  "End time (RFC3339)"
 // @Param        commit_hash  query  string  false  "Optional commit hash filter"
+// @Param        payload_hash query  string  false  "Optional payload content hash filter"
+// @Param        label.key    query  string  false  "Optional label filter, e.g. label.cluster=aks (repeatable with different keys)"
 // @Param		 executor     query  string  true  "executor filter"
 // @Success      200  {string}  binary  "PNG image"
 // @Failure 	 400  {object} 	response.ErrorMessage
 // @Failure      500  {object}   response.ServerErrorMessage
 // @Router       /benchmark/latency/histogram [get]
-func GetTotalLatencyHistogram(c *gin.Context) {
-	from, to, ok := utils.ParseTimeParams(c)
-	if !ok {
-		return
-	}
-
-	var commitHash *string
-	if hash := c.Query("commit_hash"); hash != "" {
-		commitHash = &hash
-	}
-
-	var executor string
-	if executor = c.Query("executor"); executor == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Executor filter is required"})
-		return
+func GetTotalLatencyHistogram(p persister.Database) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		filter, ok := parseFilter(c, false)
+		if !ok {
+			return
+		}
+
+		latencies, err := p.GetTotalLatenciesInRange(filter)
+		if err != nil {
+			log.Println("Error fetching total latencies:", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch total latencies"})
+			return
+		}
+
+		renderPlotAsPNG(c, "Total Latency Distribution", "Total Latency (s)", "Frequency", latencies, 20)
 	}
-
-	p := persister.NewDBPersister()
-	latencies, err := p.GetTotalLatenciesInRange(from, to, commitHash, executor)
-	if err != nil {
-		log.Println("Error fetching total latencies:", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch total latencies"})
-		return
-	}
-
-	renderPlotAsPNG(c, "Total Latency Distribution", "Total Latency (s)", "Frequency", latencies, 20)
 }
 
 //------------------------------------------------------------------------------
@@ -174,44 +151,52 @@ func GetTotalLatencyHistogram(c *gin.Context) {
 // @Param        from         query  string  false  "Start time (RFC3339)"
 // @Param        to           query  string  false  "End time (RFC3339)"
 // @Param        commit_hash  query  string  false  "Optional commit hash filter"
-// @Param		 executor     query  string  true  "executor filter"
-// @Success      200  {object}  MetricSummary
+// @Param        payload_hash query  string  false  "Optional payload content hash filter"
+// @Param        label.key    query  string  false  "Optional label filter, e.g. label.cluster=aks (repeatable with different keys)"
+// @Param		 executor     query  string  false  "executor filter, required unless group_by=executor"
+// @Param        group_by     query  string  false  "Optional grouping: executor, commit_hash, run, hour, day or label.<key>"
+// @Success      200  {object}  MetricSummary  "Summary, or a map of group to summary if group_by is set"
 // @Failure      400  {object}   response.ErrorMessage
 // @Failure      404  {object}   response.NotFoundMessage
 // @Failure      500  {object}   response.ServerErrorMessage
 // @Router       /benchmark/queue_latency/metrics [get]
-func GetQueueLatencyMetrics(c *gin.Context) {
-	from, to, ok := utils.ParseTimeParams(c)
-	if !ok {
-		return
-	}
-
-	var commitHash *string
-	if hash := c.Query("commit_hash"); hash != "" {
-		commitHash = &hash
-	}
-
-	var executor string
-	if executor = c.Query("executor"); executor == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Executor filter is required"})
-		return
-	}
-
-	p := persister.NewDBPersister()
-	latencies, err := p.GetQueueLatencySummaryInRange(from, to, commitHash, executor)
-	if err != nil {
-		log.Println("Error fetching queue latency summary:", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch queue latency summary"})
-		return
+func GetQueueLatencyMetrics(p persister.Database) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		filter, ok := parseFilter(c, true)
+		if !ok {
+			return
+		}
+
+		if groupBy := c.Query("group_by"); groupBy != "" {
+			grouping, ok := parseGroupBy(c, groupBy)
+			if !ok {
+				return
+			}
+			groups, err := p.GetQueueLatencySummaryByGroup(filter, grouping)
+			if err != nil {
+				log.Println("Error fetching grouped queue latency summary:", err)
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch queue latency summary"})
+				return
+			}
+			respondGroupedSummaries(c, groups, "Queue Latency Summary representing the time taken for jobs to be queued before execution with seconds as unit.")
+			return
+		}
+
+		latencies, err := p.GetQueueLatencySummaryInRange(filter)
+		if err != nil {
+			log.Println("Error fetching queue latency summary:", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch queue latency summary"})
+			return
+		}
+
+		if len(latencies) == 0 {
+			c.JSON(http.StatusNotFound, gin.H{"message": "No data found"})
+			return
+		}
+
+		summary := CalculateSummary(latencies, "Queue Latency Summary representing the time taken for jobs to be queued before execution with seconds as unit.")
+		c.JSON(http.StatusOK, summary)
 	}
-
-	if len(latencies) == 0 {
-		c.JSON(http.StatusNotFound, gin.H{"message": "No data found"})
-		return
-	}
-
-	summary := calculateSummary(latencies, "Queue Latency Summary representing the time taken for jobs to be queued before execution with seconds as unit.")
-	c.JSON(http.StatusOK, summary)
 }
 
 // GetBuildTimeMetrics godoc
@@ -223,44 +208,52 @@ func GetQueueLatencyMetrics(c *gin.Context) {
 // @Param        from         query  string  false  "Start time (RFC3339)"
 // @Param        to           query  string  false  "End time (RFC3339)"
 // @Param        commit_hash  query  string  false  "Optional commit hash filter"
-// @Param		 executor     query  string  true  "executor filter"
-// @Success      200  {object}  MetricSummary
+// @Param        payload_hash query  string  false  "Optional payload content hash filter"
+// @Param        label.key    query  string  false  "Optional label filter, e.g. label.cluster=aks (repeatable with different keys)"
+// @Param		 executor     query  string  false  "executor filter, required unless group_by=executor"
+// @Param        group_by     query  string  false  "Optional grouping: executor, commit_hash, run, hour, day or label.<key>"
+// @Success      200  {object}  MetricSummary  "Summary, or a map of group to summary if group_by is set"
 // @Failure      400  {object}   response.ErrorMessage
 // @Failure      404  {object}   response.NotFoundMessage
 // @Failure      500  {object}   response.ServerErrorMessage
 // @Router       /benchmark/build_time/metrics [get]
-func GetBuildTimeMetrics(c *gin.Context) {
-	from, to, ok := utils.ParseTimeParams(c)
-	if !ok {
-		return
-	}
-
-	var commitHash *string
-	if hash := c.Query("commit_hash"); hash != "" {
-		commitHash = &hash
-	}
-
-	var executor string
-	if executor = c.Query("executor"); executor == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Executor filter is required"})
-		return
-	}
-
-	p := persister.NewDBPersister()
-	buildTimes, err := p.GetBuildTimeSummaryInRange(from, to, commitHash, executor)
-	if err != nil {
-		log.Println("Error fetching build time summary:", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch build time summary"})
-		return
+func GetBuildTimeMetrics(p persister.Database) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		filter, ok := parseFilter(c, true)
+		if !ok {
+			return
+		}
+
+		if groupBy := c.Query("group_by"); groupBy != "" {
+			grouping, ok := parseGroupBy(c, groupBy)
+			if !ok {
+				return
+			}
+			groups, err := p.GetBuildTimeSummaryByGroup(filter, grouping)
+			if err != nil {
+				log.Println("Error fetching grouped build time summary:", err)
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch build time summary"})
+				return
+			}
+			respondGroupedSummaries(c, groups, "Build Time Summary representing the time taken for jobs to complete execution with seconds as unit.")
+			return
+		}
+
+		buildTimes, err := p.GetBuildTimeSummaryInRange(filter)
+		if err != nil {
+			log.Println("Error fetching build time summary:", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch build time summary"})
+			return
+		}
+
+		if len(buildTimes) == 0 {
+			c.JSON(http.StatusNotFound, gin.H{"message": "No data found"})
+			return
+		}
+
+		summary := CalculateSummary(buildTimes, "Build Time Summary representing the time taken for jobs to complete execution with seconds as unit.")
+		c.JSON(http.StatusOK, summary)
 	}
-
-	if len(buildTimes) == 0 {
-		c.JSON(http.StatusNotFound, gin.H{"message": "No data found"})
-		return
-	}
-
-	summary := calculateSummary(buildTimes, "Build Time Summary representing the time taken for jobs to complete execution with seconds as unit.")
-	c.JSON(http.StatusOK, summary)
 }
 
 // GetTotalLatencyMetrics godoc
@@ -272,50 +265,108 @@ func GetBuildTimeMetrics(c *gin.Context) {
 // @Param        from         query  string  false  "Start time (RFC3339)"
 // @Param        to           query  string  false  "End time (RFC3339)"
 // @Param        commit_hash  query  string  false  "Optional commit hash filter"
-// @Param		 executor     query  string  true  "executor filter"
-// @Success      200  {object}  MetricSummary
+// @Param        payload_hash query  string  false  "Optional payload content hash filter"
+// @Param        label.key    query  string  false  "Optional label filter, e.g. label.cluster=aks (repeatable with different keys)"
+// @Param		 executor     query  string  false  "executor filter, required unless group_by=executor"
+// @Param        group_by     query  string  false  "Optional grouping: executor, commit_hash, run, hour, day or label.<key>"
+// @Success      200  {object}  MetricSummary  "Summary, or a map of group to summary if group_by is set"
 // @Failure      400  {object}   response.ErrorMessage
 // @Failure      404  {object}   response.NotFoundMessage
 // @Failure      500  {object}   response.ServerErrorMessage
 // @Router       /benchmark/latency/metrics [get]
-func GetTotalLatencyMetrics(c *gin.Context) {
+func GetTotalLatencyMetrics(p persister.Database) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		filter, ok := parseFilter(c, true)
+		if !ok {
+			return
+		}
+
+		if groupBy := c.Query("group_by"); groupBy != "" {
+			grouping, ok := parseGroupBy(c, groupBy)
+			if !ok {
+				return
+			}
+			groups, err := p.GetTotalLatenciesSummaryByGroup(filter, grouping)
+			if err != nil {
+				log.Println("Error fetching grouped total latency summary:", err)
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch total latency summary"})
+				return
+			}
+			respondGroupedSummaries(c, groups, "Total Latency Summary representing the end-to-end time from job creation to job completion (seconds).")
+			return
+		}
+
+		latencies, err := p.GetTotalLatenciesSummaryInRange(filter)
+		if err != nil {
+			log.Println("Error fetching total latency summary:", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch total latency summary"})
+			return
+		}
+
+		if len(latencies) == 0 {
+			c.JSON(http.StatusNotFound, gin.H{"message": "No data found"})
+			return
+		}
+
+		summary := CalculateSummary(latencies, "Total Latency Summary representing the end-to-end time from job creation to job completion (seconds).")
+		c.JSON(http.StatusOK, summary)
+	}
+}
+
+//------------------------------------------------------------------------------
+// Helper functions (unchanged)
+//------------------------------------------------------------------------------
+
+// parseFilter reads the job filter shared by all metrics endpoints and answers with 400 if it is invalid.
+// The executor is required unless the endpoint supports grouping and the summaries are grouped by executor.
+func parseFilter(c *gin.Context, groupable bool) (persister.MetricsFilter, bool) {
 	from, to, ok := utils.ParseTimeParams(c)
 	if !ok {
-		return
+		return persister.MetricsFilter{}, false
 	}
 
-	var commitHash *string
+	filter := persister.MetricsFilter{From: from, To: to, Labels: utils.ParseLabelParams(c)}
 	if hash := c.Query("commit_hash"); hash != "" {
-		commitHash = &hash
+		filter.CommitHash = &hash
+	}
+	if hash := c.Query("payload_hash"); hash != "" {
+		filter.PayloadHash = &hash
 	}
 
-	var executor string
-	if executor = c.Query("executor"); executor == "" {
+	if filter.Executor = c.Query("executor"); filter.Executor == "" && (!groupable || c.Query("group_by") != persister.GroupByExecutor) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Executor filter is required"})
-		return
+		return filter, false
 	}
+	return filter, true
+}
 
-	p := persister.NewDBPersister()
-	latencies, err := p.GetTotalLatenciesSummaryInRange(from, to, commitHash, executor)
-	if err != nil {
-		log.Println("Error fetching total latency summary:", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch total latency summary"})
-		return
+// parseGroupBy returns the grouping of the summaries and answers with 400 if it is invalid
+func parseGroupBy(c *gin.Context, groupBy string) (persister.Grouping, bool) {
+	switch groupBy {
+	case persister.GroupByExecutor, persister.GroupByCommitHash, persister.GroupByRun, persister.GroupByHour, persister.GroupByDay:
+		return persister.Grouping{By: groupBy}, true
+	}
+	if label, ok := strings.CutPrefix(groupBy, utils.LabelPrefix); ok && label != "" {
+		return persister.Grouping{By: persister.GroupByLabel, Label: label}, true
 	}
+	c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid 'group_by' parameter, expected executor, commit_hash, run, hour, day or label.<key>"})
+	return persister.Grouping{}, false
+}
 
-	if len(latencies) == 0 {
+// respondGroupedSummaries answers with the summary of every group
+func respondGroupedSummaries(c *gin.Context, groups map[string][]int64, description string) {
+	if len(groups) == 0 {
 		c.JSON(http.StatusNotFound, gin.H{"message": "No data found"})
 		return
 	}
 
-	summary := calculateSummary(latencies, "Total Latency Summary representing the end-to-end time from job creation to job completion (seconds).")
-	c.JSON(http.StatusOK, summary)
+	summaries := make(map[string]MetricSummary, len(groups))
+	for group, values := range groups {
+		summaries[group] = CalculateSummary(values, description)
+	}
+	c.JSON(http.StatusOK, summaries)
 }
 
-//------------------------------------------------------------------------------
-// Helper functions (unchanged)
-//------------------------------------------------------------------------------
-
 func sum(data []int64) int64 {
 	total := int64(0)
 	for _, v := range data {
@@ -365,7 +416,8 @@ func renderPlotAsPNG(c *gin.Context, title, xLabel, yLabel string, data []int64,
 	c.Writer.Write(buffer.Bytes())
 }
 
-func calculateSummary(data []int64, description string) MetricSummary {
+// CalculateSummary computes the percentile statistics of data, which must not be empty
+func CalculateSummary(data []int64, description string) MetricSummary {
 	n := len(data)
 	sort.Slice(data, func(i, j int) bool { return data[i] < data[j] })
 
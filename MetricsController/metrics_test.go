@@ -0,0 +1,47 @@
+package MetricsController
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Mtze/CI-Benchmarker/persister"
+	"github.com/gin-gonic/gin"
+)
+
+func TestParseGroupBy(t *testing.T) {
+	tests := []struct {
+		groupBy  string
+		expected persister.Grouping
+		ok       bool
+	}{
+		{"executor", persister.Grouping{By: persister.GroupByExecutor}, true},
+		{"commit_hash", persister.Grouping{By: persister.GroupByCommitHash}, true},
+		{"run", persister.Grouping{By: persister.GroupByRun}, true},
+		{"hour", persister.Grouping{By: persister.GroupByHour}, true},
+		{"day", persister.Grouping{By: persister.GroupByDay}, true},
+		{"label.env", persister.Grouping{By: persister.GroupByLabel, Label: "env"}, true},
+		// Everything after the prefix is the key, including dots and quotes
+		{"label.team.name", persister.Grouping{By: persister.GroupByLabel, Label: "team.name"}, true},
+		{`label.a"b`, persister.Grouping{By: persister.GroupByLabel, Label: `a"b`}, true},
+		{"label.", persister.Grouping{}, false},
+		{"label", persister.Grouping{}, false},
+		{"week", persister.Grouping{}, false},
+		{"Executor", persister.Grouping{}, false},
+	}
+	gin.SetMode(gin.TestMode)
+	for _, test := range tests {
+		t.Run(test.groupBy, func(t *testing.T) {
+			recorder := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(recorder)
+
+			grouping, ok := parseGroupBy(c, test.groupBy)
+			if ok != test.ok || grouping != test.expected {
+				t.Fatalf("expected %+v, %t, got %+v, %t", test.expected, test.ok, grouping, ok)
+			}
+			if !ok && recorder.Code != http.StatusBadRequest {
+				t.Fatalf("expected status 400, got %d", recorder.Code)
+			}
+		})
+	}
+}
@@ -0,0 +1,116 @@
+package backup
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/Mtze/CI-Benchmarker/persister"
+)
+
+const (
+	filePrefix = "benchmark-"
+	fileSuffix = ".db"
+)
+
+// Scheduler writes snapshots of the SQLite database to a directory and keeps the latest of them
+type Scheduler struct {
+	persister persister.Database
+	interval  time.Duration
+	dir       string
+	keep      int
+}
+
+// NewScheduler creates a scheduler which backs up the database every interval to dir and keeps the
+// latest keep backups, keep <= 0 keeps all of them
+func NewScheduler(p persister.Database, interval time.Duration, dir string, keep int) *Scheduler {
+	return &Scheduler{
+		persister: p,
+		interval:  interval,
+		dir:       dir,
+		keep:      keep,
+	}
+}
+
+// Run backs up the database until the context is cancelled, it returns immediately if the interval is not set
+func (s *Scheduler) Run(ctx context.Context) {
+	if s.interval <= 0 {
+		slog.Debug("No backup interval set, skipping scheduled backups")
+		return
+	}
+	slog.Info("Starting backup Scheduler", slog.Duration("interval", s.interval), slog.String("dir", s.dir), slog.Int("keep", s.keep))
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			slog.Info("Stopping backup Scheduler")
+			return
+		case <-ticker.C:
+			if _, err := s.Create(ctx); err != nil {
+				slog.Error("Failed to back up database", slog.Any("error", err))
+			}
+		}
+	}
+}
+
+// Create backs up the database to a new file in the directory of the scheduler and removes the backups
+// exceeding the number of kept backups. It returns the path of the backup.
+func (s *Scheduler) Create(ctx context.Context) (string, error) {
+	if err := os.MkdirAll(s.dir, 0o755); err != nil {
+		return "", fmt.Errorf("failed to create backup directory: %w", err)
+	}
+
+	// The backup is written to a temporary file first, so a failed backup is never taken for a complete one
+	path := filepath.Join(s.dir, filePrefix+time.Now().UTC().Format("20060102T150405Z")+fileSuffix)
+	tmp := path + ".tmp"
+	if err := s.persister.Backup(ctx, tmp); err != nil {
+		os.Remove(tmp)
+		return "", err
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		os.Remove(tmp)
+		return "", fmt.Errorf("failed to move backup to %s: %w", path, err)
+	}
+	slog.Info("Backed up database", slog.String("path", path))
+
+	if err := s.rotate(); err != nil {
+		return path, fmt.Errorf("failed to remove old backups: %w", err)
+	}
+	return path, nil
+}
+
+// rotate removes all but the latest backups, their names sort by time
+func (s *Scheduler) rotate() error {
+	if s.keep <= 0 {
+		return nil
+	}
+	entries, err := os.ReadDir(s.dir)
+	if err != nil {
+		return err
+	}
+
+	var backups []string
+	for _, entry := range entries {
+		name := entry.Name()
+		if !entry.IsDir() && strings.HasPrefix(name, filePrefix) && strings.HasSuffix(name, fileSuffix) {
+			backups = append(backups, name)
+		}
+	}
+	sort.Strings(backups)
+
+	for len(backups) > s.keep {
+		if err := os.Remove(filepath.Join(s.dir, backups[0])); err != nil {
+			return err
+		}
+		slog.Debug("Removed old backup", slog.String("name", backups[0]))
+		backups = backups[1:]
+	}
+	return nil
+}
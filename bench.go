@@ -0,0 +1,223 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log/slog"
+	"net"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/Mtze/CI-Benchmarker/benchmarkController"
+	"github.com/Mtze/CI-Benchmarker/client"
+	"github.com/Mtze/CI-Benchmarker/report"
+	"github.com/Mtze/CI-Benchmarker/scenario"
+	"github.com/Mtze/CI-Benchmarker/shared/config"
+	"github.com/google/uuid"
+)
+
+// Exit codes of the bench mode, the same as the ones of the ci-benchmarker CLI
+const (
+	benchExitOK          = 0
+	benchExitError       = 1
+	benchExitSLOViolated = 2
+	benchExitRunFailed   = 3
+)
+
+// sloFlags collects the repeatable --slo flag
+type sloFlags []string
+
+func (s *sloFlags) String() string {
+	return strings.Join(*s, ",")
+}
+
+func (s *sloFlags) Set(value string) error {
+	*s = append(*s, value)
+	return nil
+}
+
+// bench starts the server, runs a scenario, waits until all of its jobs finished or the timeout elapsed,
+// writes a JSON and an HTML summary and shuts down again. The results stay in the database as usual.
+func bench(args []string) int {
+	fs := flag.NewFlagSet("bench", flag.ContinueOnError)
+	scenarioFile := fs.String("scenario", "", "Scenario file (YAML or JSON)")
+	timeout := fs.Duration("timeout", 2*time.Hour, "Maximum time to wait for the jobs, unfinished jobs are cancelled afterwards")
+	interval := fs.Duration("interval", 5*time.Second, "Poll interval while waiting")
+	out := fs.String("out", "results", "Directory the summary.json and summary.html are written to")
+	var slos sloFlags
+	fs.Var(&slos, "slo", "SLO the results have to meet, e.g. total_latency.p75<=120 (repeatable)")
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return benchExitOK
+		}
+		return benchExitError
+	}
+	if *scenarioFile == "" {
+		slog.Error("--scenario is required")
+		return benchExitError
+	}
+	parsedSLOs, err := report.ParseSLOs(slos)
+	if err != nil {
+		slog.Error("Invalid SLO", slog.Any("error", err))
+		return benchExitError
+	}
+	sc, err := scenario.Load(*scenarioFile)
+	if err != nil {
+		slog.Error("Failed to load scenario", slog.String("scenario", *scenarioFile), slog.Any("error", err))
+		return benchExitError
+	}
+
+	cfg := config.Load()
+
+	// An interrupt stops the runs like the timeout, the summary is written nevertheless
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	if err := setup(ctx, cfg); err != nil {
+		slog.Error("Setup failed", slog.Any("error", err))
+		return benchExitError
+	}
+	defer closePersister(stop)
+
+	// Requests do not use ctx, so the final state can still be fetched after the runs were stopped
+	srv, ln, err := listen(context.Background(), cfg)
+	if err != nil {
+		slog.Error("Failed to start server", slog.Any("error", err))
+		return benchExitError
+	}
+	go serve(srv, ln, stop)
+	defer shutdown(srv)
+
+	_, port, _ := net.SplitHostPort(ln.Addr().String())
+	c := client.New("http://localhost:" + port)
+
+	started, err := c.RunScenario(ctx, sc)
+	if err != nil {
+		slog.Error("Failed to start scenario", slog.Any("error", err))
+		return benchExitError
+	}
+
+	r, runErr, err := waitForScenario(ctx, c, started, *timeout, *interval, stop)
+	if err != nil {
+		slog.Error("Failed to collect results", slog.Any("error", err))
+		return benchExitError
+	}
+	r.Title = "Benchmark " + sc.Name
+	r.CheckSLOs(parsedSLOs)
+
+	if err := writeSummary(r, *out); err != nil {
+		slog.Error("Failed to write summary", slog.String("dir", *out), slog.Any("error", err))
+		return benchExitError
+	}
+	for _, result := range r.SLOs {
+		slog.Info("SLO", slog.String("result", report.FormatResult(result)))
+	}
+
+	switch {
+	case runErr != nil:
+		slog.Error("Benchmark did not succeed", slog.Any("error", runErr))
+		return benchExitRunFailed
+	case !r.Passed():
+		slog.Error("Benchmark violated SLOs", slog.Int("violations", len(report.Violations(r.SLOs))))
+		return benchExitSLOViolated
+	}
+	slog.Info("Benchmark finished", slog.String("summary", *out))
+	return benchExitOK
+}
+
+// waitForScenario waits for the run or experiment which was started and collects its results. If the
+// timeout elapses or ctx is cancelled first, the runs are stopped and their unfinished jobs cancelled.
+// runErr describes why the scenario did not succeed, err is set if the results could not be collected.
+func waitForScenario(ctx context.Context, c *client.Client, started benchmarkController.ScenarioStartedResponse, timeout time.Duration, interval time.Duration, stopRuns func()) (r report.Report, runErr error, err error) {
+	waitCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	// Fetching the final state must not be affected by the timeout
+	final := context.Background()
+	r.GeneratedAt = time.Now().UTC()
+
+	if started.ExperimentID != nil {
+		experiment, err := c.WaitForExperiment(waitCtx, *started.ExperimentID, interval)
+		if err != nil && waitCtx.Err() == nil {
+			return r, nil, err
+		}
+
+		if waitCtx.Err() != nil {
+			runErr = fmt.Errorf("experiment %s not finished after %s", started.ExperimentID, timeout)
+			stopRuns()
+			// Wait for the experiment to record its cancellation, afterwards no further run is started
+			if experiment, err = c.WaitForExperiment(final, *started.ExperimentID, time.Second); err != nil {
+				return r, nil, err
+			}
+			for _, run := range experiment.Runs {
+				cancelRun(final, c, run.RunID)
+			}
+			if experiment, err = c.GetExperiment(final, *started.ExperimentID); err != nil {
+				return r, nil, err
+			}
+		}
+		if runErr == nil && experiment.Status != benchmarkController.ExperimentStatusFinished {
+			runErr = fmt.Errorf("experiment %s %s", experiment.ID, experiment.Status)
+		}
+		r.Experiment = &experiment
+		return r, runErr, nil
+	}
+
+	run, err := c.WaitForRun(waitCtx, *started.RunID, interval)
+	if err != nil && waitCtx.Err() == nil {
+		return r, nil, err
+	}
+
+	if waitCtx.Err() != nil {
+		runErr = fmt.Errorf("run %s not finished after %s", started.RunID, timeout)
+		cancelRun(final, c, *started.RunID)
+		if run, err = c.GetRun(final, *started.RunID); err != nil {
+			return r, nil, err
+		}
+	}
+	if runErr == nil && benchmarkController.RunAborted(run.Status) {
+		runErr = fmt.Errorf("run %s %s", run.ID, run.Status)
+	}
+	r.Run = &run
+
+	if r.Metrics, err = c.RunMetrics(final, run); err != nil {
+		return r, nil, err
+	}
+	return r, runErr, nil
+}
+
+func cancelRun(ctx context.Context, c *client.Client, runID uuid.UUID) {
+	cancelled, err := c.CancelRun(ctx, runID)
+	if err != nil {
+		slog.Error("Failed to cancel run", slog.Any("runID", runID), slog.Any("error", err))
+		return
+	}
+	slog.Warn("Cancelled unfinished jobs", slog.Any("runID", runID), slog.Int64("jobs", cancelled.CancelledJobs))
+}
+
+// writeSummary writes summary.json and summary.html to dir
+func writeSummary(r report.Report, dir string) error {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+	for _, format := range []string{"json", "html"} {
+		f, err := os.Create(filepath.Join(dir, "summary."+format))
+		if err != nil {
+			return err
+		}
+		if err := r.Write(f, format); err != nil {
+			f.Close()
+			return err
+		}
+		if err := f.Close(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
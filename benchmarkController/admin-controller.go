@@ -0,0 +1,194 @@
+package benchmarkController
+
+import (
+	"bufio"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/Mtze/CI-Benchmarker/persister"
+	_ "github.com/Mtze/CI-Benchmarker/shared/response"
+	"github.com/gin-gonic/gin"
+)
+
+// GetBackup godoc
+//
+// @Summary      Download a database backup
+// @Description  Streams a consistent snapshot of the SQLite database, taken with the online backup API of SQLite. Requests are delayed while the snapshot is taken.
+// @Description  PostgreSQL databases are backed up with pg_dump instead.
+// @Tags         admin
+// @Produce      application/vnd.sqlite3
+// @Security     AdminToken
+// @Success      200  {file}    file
+// @Failure      401  {object}  response.ErrorMessage
+// @Failure      403  {object}  response.ErrorMessage
+// @Failure      500  {object}  response.ServerErrorMessage
+// @Failure      501  {object}  response.ErrorMessage
+// @Router       /admin/backup [get]
+func GetBackup(p persister.Database) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		file, err := os.CreateTemp("", "benchmark-backup-*.db")
+		if err != nil {
+			slog.Error("Failed to create backup file", slog.Any("error", err))
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to back up database"})
+			return
+		}
+		file.Close()
+		defer os.Remove(file.Name())
+
+		err = p.Backup(c.Request.Context(), file.Name())
+		if errors.Is(err, persister.ErrBackupUnsupported) {
+			c.JSON(http.StatusNotImplemented, gin.H{"error": err.Error()})
+			return
+		}
+		if err != nil {
+			slog.Error("Failed to back up database", slog.Any("error", err))
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to back up database"})
+			return
+		}
+
+		c.Header("Content-Type", "application/vnd.sqlite3")
+		c.FileAttachment(file.Name(), "benchmark-"+time.Now().UTC().Format("20060102T150405Z")+".db")
+	}
+}
+
+// ImportResponse counts the imported jobs and runs, replaced ones are counted as imported as well
+//
+// @Description Jobs and runs imported from another benchmarker instance.
+type ImportResponse struct {
+	Jobs         int `json:"jobs"          example:"1200"`
+	ReplacedJobs int `json:"replaced_jobs" example:"0"`
+	SkippedJobs  int `json:"skipped_jobs"  example:"3"`
+	Runs         int `json:"runs"          example:"4"`
+	ReplacedRuns int `json:"replaced_runs" example:"0"`
+	SkippedRuns  int `json:"skipped_runs"  example:"0"`
+}
+
+// ImportErrorResponse is returned for an invalid record, the records before it are imported
+//
+// @Description Invalid import with the jobs and runs imported before the invalid record.
+type ImportErrorResponse struct {
+	Error    string         `json:"error" example:"invalid import: record 17: expected either a job or a run"`
+	Imported ImportResponse `json:"imported"`
+}
+
+// jsonlResponse writes the records of an export as gzip compressed JSONL to the response
+type jsonlResponse struct {
+	writer  gin.ResponseWriter
+	gzip    *gzip.Writer
+	encoder *json.Encoder
+}
+
+func (r *jsonlResponse) Write(record persister.ArchiveRecord) error {
+	return r.encoder.Encode(record)
+}
+
+func (r *jsonlResponse) Flush() error {
+	if err := r.gzip.Flush(); err != nil {
+		return err
+	}
+	r.writer.Flush()
+	return nil
+}
+
+// ExportData godoc
+//
+// @Summary      Export all jobs and runs
+// @Description  Streams all jobs with their labels and times and all runs with their submission attempts as gzip compressed JSONL, one {"job": ...} or {"run": ...} object per line. Archives of pruned jobs have the same format.
+// @Tags         admin
+// @Produce      application/gzip
+// @Security     AdminToken
+// @Success      200  {file}    file
+// @Failure      401  {object}  response.ErrorMessage
+// @Failure      403  {object}  response.ErrorMessage
+// @Router       /admin/export [get]
+func ExportData(p persister.Database) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Header("Content-Type", "application/gzip")
+		c.Header("Content-Disposition", `attachment; filename="benchmark-export-`+time.Now().UTC().Format("20060102T150405Z")+`.jsonl.gz"`)
+		c.Status(http.StatusOK)
+
+		writer := gzip.NewWriter(c.Writer)
+		response := &jsonlResponse{writer: c.Writer, gzip: writer, encoder: json.NewEncoder(writer)}
+		if err := p.Export(c.Request.Context(), response); err != nil {
+			// The status is sent already, the truncated export is left without the gzip footer
+			slog.Error("Failed to export data", slog.Any("error", err))
+			c.Abort()
+			return
+		}
+		if err := writer.Close(); err != nil {
+			slog.Error("Failed to export data", slog.Any("error", err))
+		}
+	}
+}
+
+// ImportData godoc
+//
+// @Summary      Import jobs and runs
+// @Description  Imports the JSONL export of another benchmarker instance or an archive of pruned jobs, gzip compressed or not. The jobs and runs are labelled with origin, unless they are labelled with an origin already, so they can be told apart in the metrics, e.g. with group_by=label.origin.
+// @Description  Jobs and runs whose ID is stored already are skipped, or replaced with on_conflict=replace. The records are imported in batches, for an invalid record the batches before it are kept and importing again skips them.
+// @Tags         admin
+// @Accept       application/x-ndjson
+// @Accept       application/gzip
+// @Produce      json
+// @Param        origin       query  string  true   "Origin the imported jobs and runs are labelled with, e.g. the name of the instance"
+// @Param        on_conflict  query  string  false  "Whether stored jobs and runs are skipped or replaced, defaults to skip"  Enums(skip, replace)
+// @Param        data         body   string  true   "Exported jobs and runs (JSONL)"
+// @Security     AdminToken
+// @Success      200  {object}  ImportResponse
+// @Failure      400  {object}  ImportErrorResponse
+// @Failure      401  {object}  response.ErrorMessage
+// @Failure      403  {object}  response.ErrorMessage
+// @Failure      500  {object}  response.ServerErrorMessage
+// @Router       /admin/import [post]
+func ImportData(p persister.Database) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		origin := c.Query("origin")
+		if origin == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "origin is required"})
+			return
+		}
+		onConflict, err := persister.ParseConflictPolicy(c.DefaultQuery("on_conflict", string(persister.ConflictSkip)))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		body, err := decompressed(c.Request.Body)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to read gzip compressed data"})
+			return
+		}
+
+		result, err := p.Import(c.Request.Context(), body, persister.ImportOptions{Origin: origin, OnConflict: onConflict})
+		imported := ImportResponse(result)
+		if errors.Is(err, persister.ErrInvalidImport) {
+			c.JSON(http.StatusBadRequest, ImportErrorResponse{Error: err.Error(), Imported: imported})
+			return
+		}
+		if err != nil {
+			slog.Error("Failed to import data", slog.String("origin", origin), slog.Any("error", err))
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to import data"})
+			return
+		}
+
+		slog.Info("Imported data", slog.String("origin", origin), slog.Int("jobs", result.Jobs), slog.Int("runs", result.Runs),
+			slog.Int("skippedJobs", result.SkippedJobs), slog.Int("skippedRuns", result.SkippedRuns))
+		c.JSON(http.StatusOK, imported)
+	}
+}
+
+// decompressed returns the reader of gzip compressed data if r starts with the gzip magic number, otherwise r
+func decompressed(r io.Reader) (io.Reader, error) {
+	buffered := bufio.NewReader(r)
+	magic, err := buffered.Peek(2)
+	if err != nil || magic[0] != 0x1f || magic[1] != 0x8b {
+		return buffered, nil
+	}
+	return gzip.NewReader(buffered)
+}
@@ -1,8 +1,13 @@
 package benchmarkController
 
 import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"log/slog"
+	"maps"
 	"net/http"
 	"strconv"
 	"sync"
@@ -11,26 +16,118 @@ import (
 	"github.com/ls1intum/hades/shared/payload"
 
 	"github.com/Mtze/CI-Benchmarker/executor"
+	"github.com/Mtze/CI-Benchmarker/payloadtemplate"
 	"github.com/Mtze/CI-Benchmarker/persister"
+	"github.com/Mtze/CI-Benchmarker/reconciler"
+	"github.com/Mtze/CI-Benchmarker/retention"
+	"github.com/Mtze/CI-Benchmarker/shared/utils"
 	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
 	log "github.com/sirupsen/logrus"
 )
 
+// errStorage marks errors of the database, as opposed to errors of the request
+var errStorage = errors.New("failed to store benchmark state")
+
+// Services bundles the long-lived components which are shared by all benchmark handlers
+type Services struct {
+	// Persister is the persister of the database shared by all handlers
+	Persister  persister.Database
+	Reconciler *reconciler.Reconciler
+	// Pruner archives and deletes jobs on request
+	Pruner *retention.Pruner
+	Runs   *RunRegistry
+	// HTTPClient is used by the executors to talk to the CI systems
+	HTTPClient *http.Client
+	// RetryPolicy is applied to job submissions unless a request overrides it
+	RetryPolicy executor.RetryPolicy
+	// Concurrency limits the concurrent job submissions unless a request overrides it
+	Concurrency int
+	// ScenarioDir is the directory payload files of submitted scenarios are resolved against and confined to
+	ScenarioDir string
+	// PublicURL is the URL the CI systems reach the callback endpoints at
+	PublicURL string
+	// Reporters are injected into the payloads of runs which ask for it
+	Reporters payloadtemplate.Reporters
+}
+
 // Benchmark represents a benchmarking process that includes an executor to run the benchmarks,
 // a persister to save the results, and a job counter to keep track of the number of jobs executed.
+// If a Reconciler is set and the executor is able to report the status of its jobs, the scheduled
+// jobs are polled until they are finished. Runs are registered with Runs, so they can be cancelled.
+// Failed submissions are retried according to the RetryPolicy. If Reporters is set, the reporter
+// steps are added to the payload and if Templating is set, it is rendered for every job before
+// it is submitted.
 type Benchmark struct {
-	Executor   executor.Executor
-	Persister  persister.Persister
-	JobCounter int
+	Executor    executor.Executor
+	Persister   persister.Persister
+	Reconciler  *reconciler.Reconciler
+	Runs        *RunRegistry
+	RetryPolicy executor.RetryPolicy
+	// Concurrency limits the number of jobs which are submitted at the same time, 0 means unlimited
+	Concurrency int
+	JobCounter  int
+	// Interval spreads the submissions of the jobs, 0 submits them as fast as the concurrency allows
+	Interval time.Duration
+	// Trace replaces JobCounter and Interval if set, every job is due at its offset after the start of the run
+	Trace []TraceJob
+	// WarmupJobs are submitted before the measured jobs without being recorded, WarmupPause is waited afterwards
+	WarmupJobs  int
+	WarmupPause time.Duration
+	// Scenario and Labels are stored with the run to identify it later, the labels also with every job
+	Scenario string
+	Labels   map[string]string
+	// ExperimentID is set if the run is part of an experiment
+	ExperimentID *uuid.UUID
+	// Payloads is the payload library HandleFunc loads a payload_id from. LibraryPayload is set
+	// if the payload of the run is taken from it.
+	Payloads       PayloadLibrary
+	LibraryPayload *persister.JobPayload
+	// PublicURL is the URL the CI systems reach the callback endpoints at
+	PublicURL string
+	// Reporters are added to the payload of every job if set, DefaultReporters is used by HandleFunc
+	Reporters        *payloadtemplate.Reporters
+	DefaultReporters payloadtemplate.Reporters
+	Templating       *payloadtemplate.Renderer
+}
+
+// TraceJob is a job of a replayed trace, jobs without a payload use the payload of the run
+type TraceJob struct {
+	Offset  time.Duration
+	Payload *payload.RESTPayload
 }
 
 // HandleFunc is a handler function that receives a request to start a benchmark. It parses the
-// number of jobs to run from the request, runs the benchmark, and returns a response to the client.
-// The function assumes that the Executor and Persister fields of the Benchmark struct are already
-// initialized.
+// number of jobs to run from the request, starts the benchmark run and answers once all jobs are
+// submitted, with async=true it answers with the ID of the run right away. The function assumes that
+// the Executor, Persister and Runs fields of the Benchmark struct are already initialized.
 func (b Benchmark) HandleFunc(c *gin.Context) {
 	var restPayload payload.RESTPayload
-	if err := c.ShouldBind(&restPayload); err != nil {
+	if payloadID := c.Query("payload_id"); payloadID != "" {
+		// Use a payload of the payload library instead of the request body
+		id, err := uuid.Parse(payloadID)
+		if err != nil {
+			c.JSON(400, gin.H{"error": "Failed to parse payload_id"})
+			return
+		}
+		version, err := strconv.Atoi(c.DefaultQuery("payload_version", "0"))
+		if err != nil || version < 0 {
+			c.JSON(400, gin.H{"error": "Failed to parse payload_version"})
+			return
+		}
+		stored, libraryPayload, err := loadLibraryPayload(b.Payloads, id, version)
+		if errors.Is(err, sql.ErrNoRows) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Payload not found"})
+			return
+		}
+		if err != nil {
+			slog.Error("Failed to fetch payload", slog.Any("id", id), slog.Any("error", err))
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch payload"})
+			return
+		}
+		restPayload = libraryPayload
+		b.LibraryPayload = &persister.JobPayload{ID: &stored.ID, Version: int(stored.Version), Hash: stored.ContentHash}
+	} else if err := c.ShouldBind(&restPayload); err != nil {
 		log.WithError(err).Error("Failed to bind JSON")
 		c.String(http.StatusBadRequest, "Failed to bind JSON")
 		return
@@ -47,6 +144,80 @@ func (b Benchmark) HandleFunc(c *gin.Context) {
 		return
 	}
 
+	// Allow to override the number of submission attempts per job
+	if maxAttemptsStr := c.Query("max_attempts"); maxAttemptsStr != "" {
+		maxAttempts, err := strconv.Atoi(maxAttemptsStr)
+		if err != nil || maxAttempts < 1 {
+			slog.Error("Failed to parse max_attempts", slog.Any("error", err))
+			c.JSON(400, gin.H{"error": "Failed to parse max_attempts"})
+			return
+		}
+		b.RetryPolicy.MaxAttempts = maxAttempts
+	}
+
+	// Allow to override the number of concurrently submitted jobs
+	if concurrencyStr := c.Query("concurrency"); concurrencyStr != "" {
+		concurrency, err := strconv.Atoi(concurrencyStr)
+		if err != nil || concurrency < 0 {
+			slog.Error("Failed to parse concurrency", slog.Any("error", err))
+			c.JSON(400, gin.H{"error": "Failed to parse concurrency"})
+			return
+		}
+		b.Concurrency = concurrency
+	}
+
+	// Allow to render the payload per job
+	if templating := c.Query("template"); templating != "" {
+		enabled, err := strconv.ParseBool(templating)
+		if err != nil {
+			c.JSON(400, gin.H{"error": "Failed to parse template"})
+			return
+		}
+		if enabled {
+			if err := payloadtemplate.Check(restPayload); err != nil {
+				c.JSON(400, gin.H{"error": fmt.Sprintf("Invalid payload template: %v", err)})
+				return
+			}
+			b.Templating = &payloadtemplate.Renderer{BaseURL: b.PublicURL}
+		}
+	}
+
+	// Allow to add the steps reporting the start time and result of every job
+	if inject := c.Query("inject_reporters"); inject != "" {
+		enabled, err := strconv.ParseBool(inject)
+		if err != nil {
+			c.JSON(400, gin.H{"error": "Failed to parse inject_reporters"})
+			return
+		}
+		if enabled {
+			reporters := b.DefaultReporters
+			if ingestDir := c.Query("ingest_dir"); ingestDir != "" {
+				reporters.ResultMetadata = map[string]string{"INGEST_DIR": ingestDir}
+			}
+			b.Reporters = &reporters
+		}
+	}
+
+	// Allow to answer before the jobs are submitted, the progress is available at /v1/runs/{id}
+	async := false
+	if asyncStr := c.Query("async"); asyncStr != "" {
+		async, err = strconv.ParseBool(asyncStr)
+		if err != nil {
+			c.JSON(400, gin.H{"error": "Failed to parse async"})
+			return
+		}
+	}
+
+	// Labels given as label.<key>=<value> are stored with the run and every job
+	if labels := utils.ParseLabelParams(c); len(labels) > 0 {
+		merged := maps.Clone(b.Labels)
+		if merged == nil {
+			merged = make(map[string]string, len(labels))
+		}
+		maps.Copy(merged, labels)
+		b.Labels = merged
+	}
+
 	// Get the commit hash from the query parameters
 	var commitHash *string
 	hash := c.Query("commit_hash")
@@ -57,51 +228,302 @@ func (b Benchmark) HandleFunc(c *gin.Context) {
 	// Run the benchmark
 	slog.Debug("Running jobs", slog.Any("count", count))
 	b.JobCounter = count
-	if err := b.run(restPayload, commitHash); err != nil {
-		c.JSON(400, gin.H{"message": "Benchmark failed", "error": err.Error()})
-	} else {
-		c.JSON(200, gin.H{"message": "Benchmark started"})
+	runID, done, err := b.Start(restPayload, commitHash)
+	if err != nil {
+		slog.Error("Failed to start benchmark run", slog.Any("error", err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to store run"})
+		return
+	}
+
+	if async {
+		c.JSON(http.StatusAccepted, gin.H{"message": "Benchmark started", "run_id": runID})
+		return
+	}
+	if err := <-done; err != nil {
+		c.JSON(400, gin.H{"message": "Benchmark failed", "error": err.Error(), "run_id": runID})
+		return
+	}
+	c.JSON(200, gin.H{"message": "Benchmark started", "run_id": runID})
+}
+
+// Start records a new run and submits its jobs in the background. It returns the ID of the run
+// and a channel which receives the error of the submission, nil if all jobs were submitted, and is
+// closed afterwards. Nothing is submitted if the run cannot be stored.
+func (b Benchmark) Start(restPayload payload.RESTPayload, commitHash *string) (uuid.UUID, <-chan error, error) {
+	runID := uuid.New()
+	if err := b.Persister.StoreRun(persister.Run{
+		ID:           runID,
+		CreationTime: time.Now(),
+		Executor:     b.Executor.Name(),
+		JobCount:     b.JobCounter,
+		Concurrency:  b.Concurrency,
+		Scenario:     b.Scenario,
+		Labels:       b.Labels,
+		ExperimentID: b.ExperimentID,
+	}); err != nil {
+		return runID, nil, fmt.Errorf("%w: %w", errStorage, err)
+	}
+	ctx := b.Runs.Register(runID, b.Executor)
+
+	done := make(chan error, 1)
+	b.Runs.Go(func() {
+		defer close(done)
+		err := b.run(ctx, runID, restPayload, commitHash)
+		if err == nil && ctx.Err() != nil {
+			err = context.Cause(ctx)
+		}
+		switch {
+		case errors.Is(context.Cause(ctx), errRunCancelled):
+			// A cancelled run already got its final status from the cancellation
+		case ctx.Err() != nil:
+			b.interrupt(runID)
+		default:
+			status := RunStatusSubmitted
+			if err != nil {
+				slog.Error("Benchmark run failed", slog.Any("runID", runID), slog.Any("error", err))
+				status = RunStatusFailed
+			}
+			if err := b.Persister.UpdateRunStatus(runID, status, err); err != nil {
+				slog.Error("Failed to store run status", slog.Any("runID", runID), slog.Any("error", err))
+			}
+		}
+		b.Runs.Finish(runID)
+		done <- err
+	})
+
+	return runID, done, nil
+}
+
+// interrupt records that the server shut down while the run was submitting its jobs. The jobs which did
+// not finish yet are marked as cancelled, as their callbacks may never arrive.
+func (b Benchmark) interrupt(runID uuid.UUID) {
+	slog.Warn("Benchmark run interrupted by shutdown", slog.Any("runID", runID))
+	if _, err := b.Persister.CancelPendingJobsByRun(runID); err != nil {
+		slog.Error("Failed to mark jobs as cancelled", slog.Any("runID", runID), slog.Any("error", err))
+	}
+	if err := b.Persister.UpdateRunStatus(runID, RunStatusInterrupted, errors.New("server shut down")); err != nil {
+		slog.Error("Failed to store run status", slog.Any("runID", runID), slog.Any("error", err))
 	}
 }
 
 // run executes the benchmark jobs concurrently. It logs the start of job execution,
 // schedules each job, executes it using the provided executor, and stores the job
 // result using the persister. It waits for all jobs to complete before returning.
+// At most Concurrency jobs are submitted at the same time and if an Interval is set,
+// the jobs are due one Interval after another. The jobs of a Trace are due at their
+// offsets and may bring their own payload instead. The time a job waits after it is due,
+// e.g. for a free slot, is recorded as its submit delay. Warm-up jobs are submitted
+// first and are not recorded. Once ctx is cancelled no further jobs are scheduled,
+// and jobs which were submitted concurrently to the cancellation are cancelled right away.
 //
 // The function logs various stages of job execution, including the start of job
 // scheduling, any errors encountered during execution, and the successful storage
 // of job results.
-func (b Benchmark) run(payload payload.RESTPayload, commitHash *string) error {
-	slog.Info("Running jobs", slog.Any("number", b.JobCounter), slog.Any("executor", b.Executor))
+func (b Benchmark) run(ctx context.Context, runID uuid.UUID, payload payload.RESTPayload, commitHash *string) error {
+	workers := b.Concurrency
+	if workers <= 0 || workers > b.JobCounter {
+		workers = b.JobCounter
+	}
+	slog.Info("Running jobs", slog.Any("number", b.JobCounter), slog.Int("concurrency", workers), slog.Any("executor", b.Executor), slog.Any("runID", runID))
+
+	retrier := executor.NewRetryExecutor(b.Executor, b.RetryPolicy)
+	if b.WarmupJobs > 0 {
+		b.warmup(ctx, retrier, runID, payload)
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(b.WarmupPause):
+		}
+	}
+
 	var wg sync.WaitGroup
 	var runErr error
 	var mu sync.Mutex
+	jobs := make(chan dueJob)
+	runStart := time.Now()
 
-	for i := 0; i < b.JobCounter; i++ {
+	// Every job records the payload it was submitted with, before it was rendered
+	runPayload := persister.JobPayload{Hash: contentHash(payload)}
+	if b.LibraryPayload != nil {
+		runPayload = *b.LibraryPayload
+	}
+
+	for w := 0; w < workers; w++ {
 		wg.Add(1)
 
-		go func(p persister.Persister, jobIndex int) {
+		go func() {
 			defer wg.Done()
-			slog.Debug("Scheduling job", slog.Int("index", jobIndex))
-
-			uuid, err := b.Executor.Execute(payload)
-			if err != nil {
-				mu.Lock()
-				if runErr == nil {
-					runErr = fmt.Errorf("job %d: error while scheduling job: %w", jobIndex, err)
+			for job := range jobs {
+				jobPayload, source := payload, runPayload
+				if job.payload != nil {
+					jobPayload, source = *job.payload, persister.JobPayload{Hash: contentHash(*job.payload)}
+				}
+				if err := b.submitJob(ctx, retrier, runID, job.index, job.dueAt, jobPayload, source, commitHash); err != nil {
+					mu.Lock()
+					if runErr == nil {
+						runErr = err
+					}
+					mu.Unlock()
 				}
-				mu.Unlock()
-				return
 			}
+		}()
+	}
 
-			// Store the job
-			slog.Debug("Storing job", slog.Any("uuid", uuid))
-			p.StoreJob(uuid, time.Now(), b.Executor.Name(), commitHash)
-
-			slog.Debug("Job stored successfully", slog.Any("uuid", uuid))
-		}(b.Persister, i)
+dispatch:
+	for i := 0; i < b.JobCounter; i++ {
+		job := dueJob{index: i, dueAt: runStart.Add(time.Duration(i) * b.Interval)}
+		if i < len(b.Trace) {
+			job.dueAt = runStart.Add(b.Trace[i].Offset)
+			job.payload = b.Trace[i].Payload
+		}
+		if wait := time.Until(job.dueAt); wait > 0 {
+			select {
+			case <-time.After(wait):
+			case <-ctx.Done():
+				slog.Debug("Run cancelled, skipping remaining jobs", slog.Int("from", i))
+				break dispatch
+			}
+		}
+		select {
+		case jobs <- job:
+		case <-ctx.Done():
+			slog.Debug("Run cancelled, skipping remaining jobs", slog.Int("from", i))
+			break dispatch
+		}
 	}
+	close(jobs)
 
 	wg.Wait()
 	return runErr
 }
+
+// dueJob is a job of a run which is handed to a submitting worker once it is due.
+// Jobs of a trace may bring their own payload.
+type dueJob struct {
+	index   int
+	dueAt   time.Time
+	payload *payload.RESTPayload
+}
+
+// warmup submits the warm-up jobs with the concurrency of the run. Neither the jobs nor their
+// submission attempts are recorded, failures are only logged.
+func (b Benchmark) warmup(ctx context.Context, retrier *executor.RetryExecutor, runID uuid.UUID, payload payload.RESTPayload) {
+	slog.Info("Submitting warm-up jobs", slog.Int("number", b.WarmupJobs), slog.Any("executor", b.Executor))
+	workers := b.Concurrency
+	if workers <= 0 || workers > b.WarmupJobs {
+		workers = b.WarmupJobs
+	}
+
+	var wg sync.WaitGroup
+	jobs := make(chan int)
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				jobPayload, err := b.preparePayload(payload, runID, i, true)
+				if err != nil {
+					slog.Warn("Failed to render warm-up payload", slog.Int("index", i), slog.Any("error", err))
+					continue
+				}
+				if _, err := retrier.Execute(ctx, jobPayload); err != nil {
+					slog.Warn("Failed to submit warm-up job", slog.Int("index", i), slog.Any("error", err))
+				}
+			}
+		}()
+	}
+
+	for i := 0; i < b.WarmupJobs && ctx.Err() == nil; i++ {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+}
+
+// preparePayload adds the reporter steps to the payload of a job and renders its templates. The
+// reporter steps are added first, so templates in their metadata are rendered as well. Warm-up jobs
+// are not stored, so they get no reporter steps, their callbacks would end up as orphan callbacks.
+func (b Benchmark) preparePayload(p payload.RESTPayload, runID uuid.UUID, jobIndex int, warmup bool) (payload.RESTPayload, error) {
+	if b.Reporters != nil && !warmup {
+		p = b.Reporters.Inject(p)
+	}
+	if b.Templating != nil {
+		return b.Templating.Render(p, runID, jobIndex, warmup)
+	}
+	return p, nil
+}
+
+// jobMetadata returns the labels of the run encoded as metadata of its jobs, nil if it has none
+func (b Benchmark) jobMetadata() *string {
+	if len(b.Labels) == 0 {
+		return nil
+	}
+	encoded, err := json.Marshal(b.Labels)
+	if err != nil {
+		slog.Warn("Failed to encode job labels", slog.Any("error", err))
+		return nil
+	}
+	metadata := string(encoded)
+	return &metadata
+}
+
+// submitJob submits a single job of a run, records all submission attempts and stores the job.
+// The submit delay of the first attempt is the time the job waited since it was due.
+func (b Benchmark) submitJob(ctx context.Context, retrier *executor.RetryExecutor, runID uuid.UUID, jobIndex int, dueAt time.Time, payload payload.RESTPayload, source persister.JobPayload, commitHash *string) error {
+	if ctx.Err() != nil {
+		slog.Debug("Run cancelled, skipping job", slog.Int("index", jobIndex))
+		return nil
+	}
+	slog.Debug("Scheduling job", slog.Int("index", jobIndex))
+
+	payload, err := b.preparePayload(payload, runID, jobIndex, false)
+	if err != nil {
+		return fmt.Errorf("job %d: failed to render payload: %w", jobIndex, err)
+	}
+
+	uuid, attempts, err := retrier.ExecuteWithAttempts(ctx, payload)
+	readyAt := dueAt
+	for _, attempt := range attempts {
+		if err := b.Persister.StoreSubmissionAttempt(persister.SubmissionAttempt{
+			RunID:       runID,
+			JobIndex:    jobIndex,
+			Attempt:     attempt.Number,
+			SubmitDelay: attempt.StartTime.Sub(readyAt),
+			StartTime:   attempt.StartTime,
+			Duration:    attempt.Duration,
+			StatusCode:  attempt.StatusCode,
+			Err:         attempt.Err,
+			JobID:       attempt.JobID,
+		}); err != nil {
+			slog.Error("Failed to store submission attempt", slog.Any("runID", runID), slog.Any("error", err))
+		}
+		readyAt = attempt.StartTime.Add(attempt.Duration)
+	}
+	if err != nil {
+		return fmt.Errorf("job %d: error while scheduling job after %d attempt(s): %w", jobIndex, len(attempts), err)
+	}
+
+	// Store the job
+	slog.Debug("Storing job", slog.Any("uuid", uuid))
+	if err := b.Persister.StoreJobWithMetadata(uuid, time.Now(), b.Executor.Name(), b.jobMetadata(), commitHash, &runID, source); err != nil {
+		return fmt.Errorf("job %d: %w", jobIndex, err)
+	}
+
+	slog.Debug("Job stored successfully", slog.Any("uuid", uuid))
+
+	if ctx.Err() != nil {
+		// The run was cancelled while this job was submitted
+		if err := b.Persister.StoreJobStatus(uuid, string(executor.JobStateCancelled)); err != nil {
+			slog.Error("Failed to store job status", slog.Any("uuid", uuid), slog.Any("error", err))
+		}
+		if canceller, ok := b.Executor.(executor.Canceller); ok {
+			cancelJob(context.Background(), canceller, uuid)
+		}
+		return nil
+	}
+
+	if reporter, ok := b.Executor.(executor.StatusReporter); ok && b.Reconciler != nil {
+		b.Reconciler.Track(uuid, reporter)
+	}
+	return nil
+}
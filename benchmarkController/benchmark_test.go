@@ -0,0 +1,176 @@
+package benchmarkController
+
+import (
+	"context"
+	"net/http"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Mtze/CI-Benchmarker/executor"
+	"github.com/Mtze/CI-Benchmarker/persister"
+	"github.com/google/uuid"
+	"github.com/ls1intum/hades/shared/payload"
+)
+
+// fakeExecutor takes delay for every submission and fails the first failures of them with a 503
+type fakeExecutor struct {
+	delay    time.Duration
+	failures int
+
+	mu        sync.Mutex
+	calls     int
+	active    int
+	maxActive int
+}
+
+func (e *fakeExecutor) Execute(ctx context.Context, jobPayload payload.RESTPayload) (uuid.UUID, error) {
+	e.mu.Lock()
+	e.calls++
+	fail := e.calls <= e.failures
+	e.active++
+	e.maxActive = max(e.maxActive, e.active)
+	e.mu.Unlock()
+
+	time.Sleep(e.delay)
+
+	e.mu.Lock()
+	e.active--
+	e.mu.Unlock()
+	if fail {
+		return uuid.UUID{}, &executor.StatusError{Executor: e.Name(), StatusCode: http.StatusServiceUnavailable}
+	}
+	return uuid.New(), nil
+}
+
+func (e *fakeExecutor) Name() string {
+	return "FakeExecutor"
+}
+
+// fakePersister records the stored jobs and submission attempts of a run
+type fakePersister struct {
+	persister.Persister
+
+	mu       sync.Mutex
+	jobs     []uuid.UUID
+	attempts []persister.SubmissionAttempt
+}
+
+func (p *fakePersister) StoreJobWithMetadata(id uuid.UUID, creationTime time.Time, executor string, metaData *string, commitHash *string, runID *uuid.UUID, jobPayload persister.JobPayload) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.jobs = append(p.jobs, id)
+	return nil
+}
+
+func (p *fakePersister) StoreSubmissionAttempt(attempt persister.SubmissionAttempt) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.attempts = append(p.attempts, attempt)
+	return nil
+}
+
+// sortedAttempts returns the attempts ordered by job and attempt number
+func (p *fakePersister) sortedAttempts() []persister.SubmissionAttempt {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	attempts := append([]persister.SubmissionAttempt(nil), p.attempts...)
+	sort.Slice(attempts, func(i, j int) bool {
+		if attempts[i].JobIndex != attempts[j].JobIndex {
+			return attempts[i].JobIndex < attempts[j].JobIndex
+		}
+		return attempts[i].Attempt < attempts[j].Attempt
+	})
+	return attempts
+}
+
+func TestRunConcurrency(t *testing.T) {
+	exec := &fakeExecutor{delay: 20 * time.Millisecond}
+	p := &fakePersister{}
+	b := Benchmark{Executor: exec, Persister: p, Concurrency: 3, JobCounter: 12}
+
+	if err := b.run(context.Background(), uuid.New(), payload.RESTPayload{}, nil); err != nil {
+		t.Fatal(err)
+	}
+	if len(p.jobs) != 12 || len(p.attempts) != 12 {
+		t.Fatalf("expected 12 jobs and attempts, got %d jobs and %d attempts", len(p.jobs), len(p.attempts))
+	}
+	if exec.maxActive != 3 {
+		t.Fatalf("expected 3 concurrent submissions, got %d", exec.maxActive)
+	}
+}
+
+func TestRunSubmitDelay(t *testing.T) {
+	const delay = 30 * time.Millisecond
+	tolerance := 20 * time.Millisecond
+
+	t.Run("waiting for a slot", func(t *testing.T) {
+		// All jobs are due at the start of the run, every job waits for the submissions before it
+		p := &fakePersister{}
+		b := Benchmark{Executor: &fakeExecutor{delay: delay}, Persister: p, Concurrency: 1, JobCounter: 3}
+		if err := b.run(context.Background(), uuid.New(), payload.RESTPayload{}, nil); err != nil {
+			t.Fatal(err)
+		}
+		for i, attempt := range p.sortedAttempts() {
+			expected := time.Duration(i) * delay
+			if attempt.SubmitDelay < expected || attempt.SubmitDelay > expected+tolerance {
+				t.Errorf("job %d: expected a submit delay of about %s, got %s", i, expected, attempt.SubmitDelay)
+			}
+		}
+	})
+
+	t.Run("interval", func(t *testing.T) {
+		// The jobs are submitted when they are due, so they do not wait
+		p := &fakePersister{}
+		b := Benchmark{Executor: &fakeExecutor{}, Persister: p, JobCounter: 3, Interval: delay}
+		start := time.Now()
+		if err := b.run(context.Background(), uuid.New(), payload.RESTPayload{}, nil); err != nil {
+			t.Fatal(err)
+		}
+		for i, attempt := range p.sortedAttempts() {
+			if attempt.SubmitDelay > tolerance {
+				t.Errorf("job %d: expected no submit delay, got %s", i, attempt.SubmitDelay)
+			}
+			if due := start.Add(time.Duration(i) * delay); attempt.StartTime.Before(due) {
+				t.Errorf("job %d: submitted %s before it was due", i, due.Sub(attempt.StartTime))
+			}
+		}
+	})
+
+	t.Run("retry", func(t *testing.T) {
+		// The delay of a retry is the backoff after the failed attempt
+		p := &fakePersister{}
+		b := Benchmark{
+			Executor:    &fakeExecutor{failures: 1},
+			Persister:   p,
+			JobCounter:  1,
+			RetryPolicy: executor.RetryPolicy{MaxAttempts: 2, InitialBackoff: delay, RetryableStatusCodes: []int{http.StatusServiceUnavailable}},
+		}
+		if err := b.run(context.Background(), uuid.New(), payload.RESTPayload{}, nil); err != nil {
+			t.Fatal(err)
+		}
+		attempts := p.sortedAttempts()
+		if len(attempts) != 2 || attempts[0].StatusCode != http.StatusServiceUnavailable || attempts[1].JobID == nil {
+			t.Fatalf("expected a failed and a successful attempt, got %+v", attempts)
+		}
+		if retry := attempts[1].SubmitDelay; retry < delay || retry > delay+tolerance {
+			t.Fatalf("expected the retry to be delayed by the backoff of %s, got %s", delay, retry)
+		}
+	})
+}
+
+func TestRunCancelled(t *testing.T) {
+	p := &fakePersister{}
+	b := Benchmark{Executor: &fakeExecutor{}, Persister: p, JobCounter: 100, Interval: time.Hour}
+
+	// Only the first job is due before the run is cancelled
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if err := b.run(ctx, uuid.New(), payload.RESTPayload{}, nil); err != nil {
+		t.Fatal(err)
+	}
+	if len(p.jobs) != 1 {
+		t.Fatalf("expected 1 job before the cancellation, got %d", len(p.jobs))
+	}
+}
@@ -0,0 +1,406 @@
+package benchmarkController
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"maps"
+	"net/http"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/Mtze/CI-Benchmarker/MetricsController"
+	"github.com/Mtze/CI-Benchmarker/persister"
+	"github.com/Mtze/CI-Benchmarker/persister/model"
+	"github.com/Mtze/CI-Benchmarker/scenario"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// Status values of an experiment
+const (
+	ExperimentStatusRunning   = "running"
+	ExperimentStatusFinished  = "finished"
+	ExperimentStatusCancelled = "cancelled"
+	ExperimentStatusFailed    = "failed"
+	// ExperimentStatusInterrupted marks experiments which still had runs to execute when the server shut down
+	ExperimentStatusInterrupted = "interrupted"
+)
+
+// Labels which identify the cell of an experiment a run belongs to
+const (
+	LabelMatrixExecutor    = "matrix.executor"
+	LabelMatrixPayload     = "matrix.payload"
+	LabelMatrixConcurrency = "matrix.concurrency"
+	LabelMatrixRepetition  = "matrix.repetition"
+)
+
+// experimentPollInterval is the interval in which an experiment checks whether the jobs of its current run finished
+const experimentPollInterval = 5 * time.Second
+
+// ExperimentSummary describes an experiment, its runs and the comparison of its cells.
+//
+// @Description State of an experiment, its runs in execution order and the metrics per executor, payload and concurrency (seconds).
+type ExperimentSummary struct {
+	ID           uuid.UUID        `json:"id"            example:"8d0f6a52-44d5-4c6f-a7a3-1f0b9a1c7e21"`
+	Scenario     string           `json:"scenario"      example:"docker-vs-kubernetes"`
+	Status       string           `json:"status"        example:"finished"`
+	Error        string           `json:"error,omitempty"`
+	Order        string           `json:"order"         example:"randomized"`
+	Seed         uint64           `json:"seed,omitempty" example:"42"`
+	CreationTime time.Time        `json:"creation_time"`
+	FinishTime   *time.Time       `json:"finish_time,omitempty"`
+	RunCount     int64            `json:"run_count"     example:"12"`
+	Runs         []ExperimentRun  `json:"runs"`
+	Comparison   []CellComparison `json:"comparison"`
+}
+
+// ExperimentRun is a run of an experiment.
+//
+// @Description Run of an experiment and the cell of the matrix it belongs to.
+type ExperimentRun struct {
+	RunID       uuid.UUID `json:"run_id"      example:"3b0bce02-43c6-4e66-8810-b7a0ac5b3a08"`
+	Executor    string    `json:"executor"    example:"docker"`
+	Payload     string    `json:"payload"     example:"gradle"`
+	Concurrency string    `json:"concurrency" example:"10"`
+	Repetition  string    `json:"repetition"  example:"1"`
+	Status      string    `json:"status"      example:"submitted"`
+}
+
+// CellComparison summarizes the jobs of all repetitions of a cell of an experiment.
+//
+// @Description Metrics of all jobs of a cell of an experiment, missing if no job of the cell reported the metric.
+type CellComparison struct {
+	Executor     string                           `json:"executor"    example:"docker"`
+	Payload      string                           `json:"payload"     example:"gradle"`
+	Concurrency  string                           `json:"concurrency" example:"10"`
+	Runs         int                              `json:"runs"        example:"3"`
+	QueueLatency *MetricsController.MetricSummary `json:"queue_latency,omitempty"`
+	BuildTime    *MetricsController.MetricSummary `json:"build_time,omitempty"`
+	TotalLatency *MetricsController.MetricSummary `json:"total_latency,omitempty"`
+}
+
+// CancelExperimentResponse is returned after an experiment was cancelled.
+//
+// @Description Result of cancelling an experiment, its remaining runs are stopped in the background.
+type CancelExperimentResponse struct {
+	Message      string    `json:"message"       example:"Experiment cancelled"`
+	ExperimentID uuid.UUID `json:"experiment_id" example:"8d0f6a52-44d5-4c6f-a7a3-1f0b9a1c7e21"`
+}
+
+// StartExperiment records an experiment for a scenario with a matrix and executes its runs one after
+// another in the background. It returns the ID of the experiment and the number of its runs.
+func StartExperiment(s Services, sc *scenario.Scenario) (uuid.UUID, int, error) {
+	experimentID := uuid.New()
+	cells := sc.Cells()
+
+	// Create all benchmarks up front, so an invalid cell fails the request instead of the experiment
+	benchmarks := make([]Benchmark, 0, len(cells))
+	for _, cell := range cells {
+		cellScenario := *sc
+		cellScenario.Executor = cell.Executor
+		cellScenario.Load.Concurrency = cell.Concurrency
+
+		benchmark, err := NewScenarioBenchmark(s, &cellScenario)
+		if err != nil {
+			return uuid.UUID{}, 0, fmt.Errorf("executor %s: %w", cell.Executor.Name, err)
+		}
+		benchmark.ExperimentID = &experimentID
+		benchmark.Labels = maps.Clone(sc.Labels)
+		if benchmark.Labels == nil {
+			benchmark.Labels = make(map[string]string)
+		}
+		benchmark.Labels[LabelMatrixExecutor] = cell.Executor.Name
+		benchmark.Labels[LabelMatrixPayload] = cell.PayloadName
+		benchmark.Labels[LabelMatrixConcurrency] = strconv.Itoa(benchmark.Concurrency)
+		benchmark.Labels[LabelMatrixRepetition] = strconv.Itoa(cell.Repetition)
+		benchmarks = append(benchmarks, benchmark)
+	}
+
+	p := s.Persister
+	if err := p.StoreExperiment(persister.Experiment{
+		ID:           experimentID,
+		CreationTime: time.Now(),
+		Scenario:     sc.Name,
+		RunOrder:     sc.Matrix.Order,
+		Seed:         sc.Matrix.Seed,
+		RunCount:     len(cells),
+	}); err != nil {
+		return uuid.UUID{}, 0, fmt.Errorf("%w: %w", errStorage, err)
+	}
+
+	slog.Info("Starting experiment", slog.Any("experimentID", experimentID), slog.String("scenario", sc.Name), slog.Int("runs", len(cells)))
+	ctx := s.Runs.RegisterExperiment(experimentID)
+	s.Runs.Go(func() {
+		defer s.Runs.FinishExperiment(experimentID)
+		for i, benchmark := range benchmarks {
+			if ctx.Err() != nil {
+				break
+			}
+
+			runID, done, err := benchmark.Start(cells[i].Payload, scenarioCommitHash(sc))
+			if err != nil {
+				slog.Error("Failed to start experiment run", slog.Any("experimentID", experimentID), slog.Int("index", i), slog.Any("error", err))
+				updateExperimentStatus(p, experimentID, ExperimentStatusFailed, err)
+				return
+			}
+			slog.Info("Started experiment run", slog.Any("experimentID", experimentID), slog.Int("index", i), slog.Any("runID", runID))
+			select {
+			case <-done:
+			case <-ctx.Done():
+				// A run which is still submitting is cancelled with the experiment, on shutdown it is interrupted by itself
+				if errors.Is(context.Cause(ctx), errExperimentCancelled) {
+					if _, err := cancelRun(s, runID); err != nil {
+						slog.Error("Failed to cancel experiment run", slog.Any("experimentID", experimentID), slog.Any("runID", runID), slog.Any("error", err))
+					}
+				}
+				<-done
+			}
+			waitForRunJobs(ctx, p, runID, sc.Matrix.CellTimeout.Std())
+
+			if i < len(benchmarks)-1 {
+				select {
+				case <-ctx.Done():
+				case <-time.After(sc.Matrix.Pause.Std()):
+				}
+			}
+		}
+		switch {
+		case errors.Is(context.Cause(ctx), errExperimentCancelled):
+			updateExperimentStatus(p, experimentID, ExperimentStatusCancelled, nil)
+			slog.Info("Experiment cancelled", slog.Any("experimentID", experimentID))
+			return
+		case ctx.Err() != nil:
+			slog.Warn("Experiment interrupted by shutdown", slog.Any("experimentID", experimentID))
+			updateExperimentStatus(p, experimentID, ExperimentStatusInterrupted, errors.New("server shut down"))
+			return
+		}
+		updateExperimentStatus(p, experimentID, ExperimentStatusFinished, nil)
+		slog.Info("Experiment finished", slog.Any("experimentID", experimentID))
+	})
+
+	return experimentID, len(cells), nil
+}
+
+func updateExperimentStatus(p persister.Persister, experimentID uuid.UUID, status string, experimentErr error) {
+	if err := p.UpdateExperimentStatus(experimentID, status, experimentErr); err != nil {
+		slog.Error("Failed to store experiment status", slog.Any("experimentID", experimentID), slog.Any("error", err))
+	}
+}
+
+// waitForRunJobs blocks until all jobs of a run finished, the run was cancelled or failed, or the timeout elapsed
+func waitForRunJobs(ctx context.Context, p persister.Database, runID uuid.UUID, timeout time.Duration) {
+	deadline := time.After(timeout)
+	ticker := time.NewTicker(experimentPollInterval)
+	defer ticker.Stop()
+
+	for {
+		run, err := p.GetRun(runID)
+		if err == nil && RunAborted(run.Status) {
+			return
+		}
+		stats, err := p.GetRunJobStats(runID)
+		if err == nil && stats.FinishedJobs+stats.CancelledJobs >= stats.ScheduledJobs {
+			return
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-deadline:
+			slog.Warn("Jobs of experiment run did not finish in time, continuing", slog.Any("runID", runID))
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// GetExperiment godoc
+//
+// @Summary      Get an experiment
+// @Description  Returns the state of an experiment, its runs and the queue latency, build time and total latency of every combination of executor, payload and concurrency over all repetitions.
+// @Tags         experiments
+// @Produce      json
+// @Param        id   path  string  true  "Experiment ID"
+// @Success      200  {object}  ExperimentSummary
+// @Failure      400  {object}  response.ErrorMessage
+// @Failure      404  {object}  response.NotFoundMessage
+// @Failure      500  {object}  response.ServerErrorMessage
+// @Router       /experiments/{id} [get]
+func GetExperiment(p persister.Database) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		experimentID, err := uuid.Parse(c.Param("id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to parse experiment ID"})
+			return
+		}
+
+		experiment, err := p.GetExperiment(experimentID)
+		if errors.Is(err, sql.ErrNoRows) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Experiment not found"})
+			return
+		}
+		if err != nil {
+			slog.Error("Failed to fetch experiment", slog.Any("experimentID", experimentID), slog.Any("error", err))
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch experiment"})
+			return
+		}
+
+		runs, err := p.GetRunsByExperiment(experimentID)
+		if err != nil {
+			slog.Error("Failed to fetch experiment runs", slog.Any("experimentID", experimentID), slog.Any("error", err))
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch experiment"})
+			return
+		}
+
+		jobs, err := p.GetJobTimesByExperiment(experimentID)
+		if err != nil {
+			slog.Error("Failed to fetch experiment jobs", slog.Any("experimentID", experimentID), slog.Any("error", err))
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch experiment"})
+			return
+		}
+
+		summary := ExperimentSummary{
+			ID:           experiment.ID,
+			Scenario:     experiment.Scenario,
+			Status:       experiment.Status,
+			Error:        experiment.Error.String,
+			Order:        experiment.RunOrder,
+			Seed:         uint64(experiment.Seed),
+			CreationTime: experiment.CreationTime,
+			RunCount:     experiment.RunCount,
+			Runs:         make([]ExperimentRun, 0, len(runs)),
+			Comparison:   compareCells(runs, jobs),
+		}
+		if experiment.FinishTime.Valid {
+			summary.FinishTime = &experiment.FinishTime.Time
+		}
+		for _, run := range runs {
+			labels, _ := persister.RunLabels(run)
+			summary.Runs = append(summary.Runs, ExperimentRun{
+				RunID:       run.ID,
+				Executor:    labels[LabelMatrixExecutor],
+				Payload:     labels[LabelMatrixPayload],
+				Concurrency: labels[LabelMatrixConcurrency],
+				Repetition:  labels[LabelMatrixRepetition],
+				Status:      run.Status,
+			})
+		}
+
+		c.JSON(http.StatusOK, summary)
+	}
+}
+
+// CancelExperiment godoc
+//
+// @Summary      Cancel an experiment
+// @Description  Stops an experiment before its next run. A run of the experiment which is still submitting jobs is cancelled like with POST /runs/{id}/cancel, the runs which submitted all of their jobs are kept.
+// @Description  The experiment ends with the status cancelled.
+// @Tags         experiments
+// @Produce      json
+// @Param        id   path  string  true  "Experiment ID"
+// @Success      202  {object}  CancelExperimentResponse
+// @Failure      400  {object}  response.ErrorMessage
+// @Failure      404  {object}  response.NotFoundMessage
+// @Failure      409  {object}  response.ErrorMessage
+// @Failure      500  {object}  response.ServerErrorMessage
+// @Router       /experiments/{id}/cancel [post]
+func CancelExperiment(s Services) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		experimentID, err := uuid.Parse(c.Param("id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to parse experiment ID"})
+			return
+		}
+
+		if _, err := s.Persister.GetExperiment(experimentID); errors.Is(err, sql.ErrNoRows) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Experiment not found"})
+			return
+		} else if err != nil {
+			slog.Error("Failed to fetch experiment", slog.Any("experimentID", experimentID), slog.Any("error", err))
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch experiment"})
+			return
+		}
+
+		// Experiments which finished or were started before a restart are not running anymore
+		if !s.Runs.CancelExperiment(experimentID) {
+			c.JSON(http.StatusConflict, gin.H{"error": "Experiment is not running"})
+			return
+		}
+
+		slog.Info("Cancelling experiment", slog.Any("experimentID", experimentID))
+		c.JSON(http.StatusAccepted, CancelExperimentResponse{
+			Message:      "Experiment cancelled",
+			ExperimentID: experimentID,
+		})
+	}
+}
+
+// compareCells groups the jobs of an experiment by the cell of their run and summarizes every cell
+func compareCells(runs []model.BenchmarkRun, jobs []model.GetJobTimesByExperimentRow) []CellComparison {
+	type cellTimes struct {
+		comparison   CellComparison
+		queueLatency []int64
+		buildTime    []int64
+		totalLatency []int64
+	}
+
+	cellOf := make(map[uuid.UUID]string, len(runs))
+	cells := make(map[string]*cellTimes)
+	for _, run := range runs {
+		labels, _ := persister.RunLabels(run)
+		key := labels[LabelMatrixExecutor] + "\x00" + labels[LabelMatrixPayload] + "\x00" + labels[LabelMatrixConcurrency]
+		cellOf[run.ID] = key
+		if _, ok := cells[key]; !ok {
+			cells[key] = &cellTimes{comparison: CellComparison{
+				Executor:    labels[LabelMatrixExecutor],
+				Payload:     labels[LabelMatrixPayload],
+				Concurrency: labels[LabelMatrixConcurrency],
+			}}
+		}
+		cells[key].comparison.Runs++
+	}
+
+	for _, job := range jobs {
+		cell, ok := cells[cellOf[job.RunID.UUID]]
+		if !ok {
+			continue
+		}
+		if job.StartTime.Valid {
+			cell.queueLatency = append(cell.queueLatency, seconds(job.StartTime.Time.Sub(job.CreationTime)))
+		}
+		// Jobs which finished before they started are invalid, like in the build time metrics
+		if job.StartTime.Valid && job.EndTime.Valid && !job.EndTime.Time.Before(job.StartTime.Time) {
+			cell.buildTime = append(cell.buildTime, seconds(job.EndTime.Time.Sub(job.StartTime.Time)))
+		}
+		if job.EndTime.Valid {
+			cell.totalLatency = append(cell.totalLatency, seconds(job.EndTime.Time.Sub(job.CreationTime)))
+		}
+	}
+
+	keys := slices.Sorted(maps.Keys(cells))
+	comparison := make([]CellComparison, 0, len(keys))
+	for _, key := range keys {
+		cell := cells[key]
+		cell.comparison.QueueLatency = summarize(cell.queueLatency, "Queue latency of the cell (seconds).")
+		cell.comparison.BuildTime = summarize(cell.buildTime, "Build time of the cell (seconds).")
+		cell.comparison.TotalLatency = summarize(cell.totalLatency, "Total latency of the cell (seconds).")
+		comparison = append(comparison, cell.comparison)
+	}
+	return comparison
+}
+
+func summarize(data []int64, description string) *MetricsController.MetricSummary {
+	if len(data) == 0 {
+		return nil
+	}
+	summary := MetricsController.CalculateSummary(data, description)
+	return &summary
+}
+
+func seconds(d time.Duration) int64 {
+	return int64(d / time.Second)
+}
@@ -4,32 +4,45 @@ import (
 	"log/slog"
 
 	"github.com/Mtze/CI-Benchmarker/executor"
-	"github.com/Mtze/CI-Benchmarker/persister"
 	"github.com/gin-gonic/gin"
 )
 
-func NewHadesDockerBenchmark() gin.HandlerFunc {
+func NewHadesDockerBenchmark(s Services) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		slog.Debug("Creating new Hades (Docker) benchmark")
 
 		hadesHost := c.Query("host")
 		benchmark := Benchmark{
-			Executor:  executor.NewHadesExecutor(hadesHost, executor.Docker),
-			Persister: persister.NewDBPersister(),
+			Executor:         executor.NewHadesExecutor(hadesHost, executor.Docker, s.HTTPClient),
+			Persister:        s.Persister,
+			Payloads:         s.Persister,
+			Reconciler:       s.Reconciler,
+			Runs:             s.Runs,
+			RetryPolicy:      s.RetryPolicy,
+			Concurrency:      s.Concurrency,
+			PublicURL:        s.PublicURL,
+			DefaultReporters: s.Reporters,
 		}
 
 		benchmark.HandleFunc(c)
 	}
 }
 
-func NewHadesKubernetesBenchmark() gin.HandlerFunc {
+func NewHadesKubernetesBenchmark(s Services) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		slog.Debug("Creating new Hades (Kubernetes) benchmark")
 
 		hadesHost := c.Query("host")
 		benchmark := Benchmark{
-			Executor:  executor.NewHadesExecutor(hadesHost, executor.Kubernetes),
-			Persister: persister.NewDBPersister(),
+			Executor:         executor.NewHadesExecutor(hadesHost, executor.Kubernetes, s.HTTPClient),
+			Persister:        s.Persister,
+			Payloads:         s.Persister,
+			Reconciler:       s.Reconciler,
+			Runs:             s.Runs,
+			RetryPolicy:      s.RetryPolicy,
+			Concurrency:      s.Concurrency,
+			PublicURL:        s.PublicURL,
+			DefaultReporters: s.Reporters,
 		}
 
 		benchmark.HandleFunc(c)
@@ -4,11 +4,10 @@ import (
 	"log/slog"
 
 	"github.com/Mtze/CI-Benchmarker/executor"
-	"github.com/Mtze/CI-Benchmarker/persister"
 	"github.com/gin-gonic/gin"
 )
 
-func NewJenkinsBenchmark() gin.HandlerFunc {
+func NewJenkinsBenchmark(s Services) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		slog.Debug("Creating new Jenkins benchmark")
 
@@ -18,8 +17,15 @@ func NewJenkinsBenchmark() gin.HandlerFunc {
 		jenkinsJobPath := c.Query("job_path")
 		useParameters := c.DefaultQuery("use_parameters", "false") == "true"
 		benchmark := Benchmark{
-			Executor:  executor.NewJenkinsExecutor(jenkinsHost, jenkinsUser, jenkinsAPIToken, jenkinsJobPath, useParameters),
-			Persister: persister.NewDBPersister(),
+			Executor:         executor.NewJenkinsExecutor(jenkinsHost, jenkinsUser, jenkinsAPIToken, jenkinsJobPath, useParameters, s.HTTPClient),
+			Persister:        s.Persister,
+			Payloads:         s.Persister,
+			Reconciler:       s.Reconciler,
+			Runs:             s.Runs,
+			RetryPolicy:      s.RetryPolicy,
+			Concurrency:      s.Concurrency,
+			PublicURL:        s.PublicURL,
+			DefaultReporters: s.Reporters,
 		}
 
 		benchmark.HandleFunc(c)
@@ -0,0 +1,69 @@
+package benchmarkController
+
+import (
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/Mtze/CI-Benchmarker/persister"
+	_ "github.com/Mtze/CI-Benchmarker/shared/response"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// maxOrphanCallbacks limits the number of orphan callbacks returned at once
+const maxOrphanCallbacks = 1000
+
+// OrphanCallbackResponse describes a callback which was received for a job that is not scheduled,
+// e.g. from a stale reporter or a pipeline with a mistyped job ID.
+//
+// @Description Callback received for an unknown job.
+type OrphanCallbackResponse struct {
+	ID           int64     `json:"id"            example:"17"`
+	JobID        uuid.UUID `json:"job_id"        example:"3b0bce02-43c6-4e66-8810-b7a0ac5b3a08"`
+	Callback     string    `json:"callback"      example:"start_time" enums:"start_time,result"`
+	ReportedTime time.Time `json:"reported_time"`
+	ReceivedAt   time.Time `json:"received_at"`
+}
+
+// ListOrphanCallbacks godoc
+//
+// @Summary      List orphan callbacks
+// @Description  Returns the latest start time and result callbacks which were received for jobs that are not scheduled.
+// @Description  Their times are not part of any metric. Callbacks which arrive before their job is stored are applied once it is.
+// @Tags         callbacks
+// @Produce      json
+// @Param        limit  query  int  false  "Maximum number of callbacks, defaults to 100"
+// @Success      200  {array}   OrphanCallbackResponse
+// @Failure      400  {object}  response.ErrorMessage
+// @Failure      500  {object}  response.ServerErrorMessage
+// @Router       /orphan_callbacks [get]
+func ListOrphanCallbacks(p persister.Database) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		limit, err := strconv.Atoi(c.DefaultQuery("limit", "100"))
+		if err != nil || limit < 1 || limit > maxOrphanCallbacks {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "limit must be between 1 and " + strconv.Itoa(maxOrphanCallbacks)})
+			return
+		}
+
+		callbacks, err := p.ListOrphanCallbacks(limit)
+		if err != nil {
+			slog.Error("Failed to list orphan callbacks", slog.Any("error", err))
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to list orphan callbacks"})
+			return
+		}
+
+		response := make([]OrphanCallbackResponse, len(callbacks))
+		for i, callback := range callbacks {
+			response[i] = OrphanCallbackResponse{
+				ID:           callback.ID,
+				JobID:        callback.JobID,
+				Callback:     callback.Callback,
+				ReportedTime: callback.ReportedTime,
+				ReceivedAt:   callback.ReceivedAt,
+			}
+		}
+		c.JSON(http.StatusOK, response)
+	}
+}
@@ -0,0 +1,354 @@
+package benchmarkController
+
+import (
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/Mtze/CI-Benchmarker/persister"
+	"github.com/Mtze/CI-Benchmarker/persister/model"
+	_ "github.com/Mtze/CI-Benchmarker/shared/response"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/ls1intum/hades/shared/payload"
+)
+
+// PayloadRequest creates a payload of the payload library or a new version of it.
+//
+// @Description Name and Hades payload of a payload of the payload library.
+type PayloadRequest struct {
+	Name    string          `json:"name"    example:"gradle-java17" binding:"required"`
+	Payload json.RawMessage `json:"payload" swaggertype:"object" binding:"required"`
+}
+
+// PayloadVersionResponse describes a version of a payload of the payload library. The payload
+// itself is left out of listings.
+//
+// @Description Version of a payload of the payload library.
+type PayloadVersionResponse struct {
+	ID           uuid.UUID       `json:"id"           example:"5c1e7a4e-8f0b-4d2a-9a47-0d5f3c0e6b11"`
+	Version      int64           `json:"version"      example:"3"`
+	Name         string          `json:"name"         example:"gradle-java17"`
+	ContentHash  string          `json:"content_hash" example:"9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08"`
+	CreationTime time.Time       `json:"creation_time"`
+	Payload      json.RawMessage `json:"payload,omitempty" swaggertype:"object"`
+}
+
+// encodePayload returns the normalized JSON encoding of a payload and its content hash. Payloads
+// which only differ in formatting or key order have the same hash.
+func encodePayload(p payload.RESTPayload) ([]byte, string, error) {
+	content, err := json.Marshal(p)
+	if err != nil {
+		return nil, "", err
+	}
+	sum := sha256.Sum256(content)
+	return content, hex.EncodeToString(sum[:]), nil
+}
+
+// contentHash returns the content hash of a payload, or an empty string if it cannot be encoded
+func contentHash(p payload.RESTPayload) string {
+	_, hash, err := encodePayload(p)
+	if err != nil {
+		slog.Warn("Failed to hash payload", slog.Any("error", err))
+	}
+	return hash
+}
+
+// PayloadLibrary gives access to the stored versions of the payload library
+type PayloadLibrary interface {
+	GetLatestPayloadVersion(id uuid.UUID) (model.Payload, error)
+	GetPayloadVersion(id uuid.UUID, version int) (model.Payload, error)
+}
+
+// loadLibraryPayload returns a version of a payload of the payload library, the latest one if version is 0
+func loadLibraryPayload(p PayloadLibrary, id uuid.UUID, version int) (model.Payload, payload.RESTPayload, error) {
+	var stored model.Payload
+	var err error
+	if version == 0 {
+		stored, err = p.GetLatestPayloadVersion(id)
+	} else {
+		stored, err = p.GetPayloadVersion(id, version)
+	}
+	if err != nil {
+		return stored, payload.RESTPayload{}, err
+	}
+
+	var restPayload payload.RESTPayload
+	if err := json.Unmarshal(persister.PayloadContent(stored), &restPayload); err != nil {
+		return stored, restPayload, fmt.Errorf("failed to decode payload %s version %d: %w", id, stored.Version, err)
+	}
+	return stored, restPayload, nil
+}
+
+func payloadVersionResponse(stored model.Payload, withContent bool) PayloadVersionResponse {
+	response := PayloadVersionResponse{
+		ID:           stored.ID,
+		Version:      stored.Version,
+		Name:         stored.Name,
+		ContentHash:  stored.ContentHash,
+		CreationTime: stored.CreationTime,
+	}
+	if withContent {
+		response.Payload = persister.PayloadContent(stored)
+	}
+	return response
+}
+
+// bindPayloadRequest parses a payload request and answers with 400 if it is invalid
+func bindPayloadRequest(c *gin.Context) (PayloadRequest, []byte, string, bool) {
+	var request PayloadRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to bind JSON"})
+		return request, nil, "", false
+	}
+
+	var restPayload payload.RESTPayload
+	if err := json.Unmarshal(request.Payload, &restPayload); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Invalid payload: %v", err)})
+		return request, nil, "", false
+	}
+	content, hash, err := encodePayload(restPayload)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Invalid payload: %v", err)})
+		return request, nil, "", false
+	}
+	return request, content, hash, true
+}
+
+func parsePayloadID(c *gin.Context) (uuid.UUID, bool) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to parse payload ID"})
+		return id, false
+	}
+	return id, true
+}
+
+// CreatePayload godoc
+//
+// @Summary      Create a payload
+// @Description  Stores a payload in the payload library as version 1. Benchmark requests reference it with payload_id.
+// @Tags         payloads
+// @Accept       json
+// @Produce      json
+// @Param        payload  body  PayloadRequest  true  "Payload"
+// @Success      201  {object}  PayloadVersionResponse
+// @Failure      400  {object}  response.ErrorMessage
+// @Failure      500  {object}  response.ServerErrorMessage
+// @Router       /payloads [post]
+func CreatePayload(p persister.Database) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		request, content, hash, ok := bindPayloadRequest(c)
+		if !ok {
+			return
+		}
+
+		stored, err := p.StorePayloadVersion(persister.PayloadVersion{ID: uuid.New(), Name: request.Name, Content: content, Hash: hash})
+		if err != nil {
+			slog.Error("Failed to store payload", slog.String("name", request.Name), slog.Any("error", err))
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to store payload"})
+			return
+		}
+
+		slog.Info("Payload created", slog.Any("id", stored.ID), slog.String("name", stored.Name))
+		c.JSON(http.StatusCreated, payloadVersionResponse(stored, true))
+	}
+}
+
+// ListPayloads godoc
+//
+// @Summary      List payloads
+// @Description  Returns the latest version of every payload of the payload library without its content.
+// @Tags         payloads
+// @Produce      json
+// @Success      200  {array}   PayloadVersionResponse
+// @Failure      500  {object}  response.ServerErrorMessage
+// @Router       /payloads [get]
+func ListPayloads(p persister.Database) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		payloads, err := p.ListPayloads()
+		if err != nil {
+			slog.Error("Failed to list payloads", slog.Any("error", err))
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to list payloads"})
+			return
+		}
+
+		response := make([]PayloadVersionResponse, len(payloads))
+		for i, stored := range payloads {
+			response[i] = payloadVersionResponse(stored, false)
+		}
+		c.JSON(http.StatusOK, response)
+	}
+}
+
+// GetPayload godoc
+//
+// @Summary      Get a payload
+// @Description  Returns the latest or the given version of a payload of the payload library.
+// @Tags         payloads
+// @Produce      json
+// @Param        id       path   string  true   "Payload ID"
+// @Param        version  query  int     false  "Version, defaults to the latest one"
+// @Success      200  {object}  PayloadVersionResponse
+// @Failure      400  {object}  response.ErrorMessage
+// @Failure      404  {object}  response.NotFoundMessage
+// @Failure      500  {object}  response.ServerErrorMessage
+// @Router       /payloads/{id} [get]
+func GetPayload(p persister.Database) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, ok := parsePayloadID(c)
+		if !ok {
+			return
+		}
+		version, err := strconv.Atoi(c.DefaultQuery("version", "0"))
+		if err != nil || version < 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to parse version"})
+			return
+		}
+
+		stored, _, err := loadLibraryPayload(p, id, version)
+		if errors.Is(err, sql.ErrNoRows) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Payload not found"})
+			return
+		}
+		if err != nil {
+			slog.Error("Failed to fetch payload", slog.Any("id", id), slog.Any("error", err))
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch payload"})
+			return
+		}
+
+		c.JSON(http.StatusOK, payloadVersionResponse(stored, true))
+	}
+}
+
+// ListPayloadVersions godoc
+//
+// @Summary      List the versions of a payload
+// @Description  Returns all versions of a payload of the payload library without their content.
+// @Tags         payloads
+// @Produce      json
+// @Param        id   path  string  true  "Payload ID"
+// @Success      200  {array}   PayloadVersionResponse
+// @Failure      400  {object}  response.ErrorMessage
+// @Failure      404  {object}  response.NotFoundMessage
+// @Failure      500  {object}  response.ServerErrorMessage
+// @Router       /payloads/{id}/versions [get]
+func ListPayloadVersions(p persister.Database) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, ok := parsePayloadID(c)
+		if !ok {
+			return
+		}
+
+		versions, err := p.ListPayloadVersions(id)
+		if err != nil {
+			slog.Error("Failed to list payload versions", slog.Any("id", id), slog.Any("error", err))
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to list payload versions"})
+			return
+		}
+		if len(versions) == 0 {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Payload not found"})
+			return
+		}
+
+		response := make([]PayloadVersionResponse, len(versions))
+		for i, stored := range versions {
+			response[i] = payloadVersionResponse(stored, false)
+		}
+		c.JSON(http.StatusOK, response)
+	}
+}
+
+// UpdatePayload godoc
+//
+// @Summary      Update a payload
+// @Description  Stores a new version of a payload of the payload library. If neither the name nor the content changed, the latest version is returned and no version is added.
+// @Tags         payloads
+// @Accept       json
+// @Produce      json
+// @Param        id       path  string          true  "Payload ID"
+// @Param        payload  body  PayloadRequest  true  "Payload"
+// @Success      200  {object}  PayloadVersionResponse
+// @Failure      400  {object}  response.ErrorMessage
+// @Failure      404  {object}  response.NotFoundMessage
+// @Failure      500  {object}  response.ServerErrorMessage
+// @Router       /payloads/{id} [put]
+func UpdatePayload(p persister.Database) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, ok := parsePayloadID(c)
+		if !ok {
+			return
+		}
+		request, content, hash, ok := bindPayloadRequest(c)
+		if !ok {
+			return
+		}
+
+		latest, err := p.GetLatestPayloadVersion(id)
+		if errors.Is(err, sql.ErrNoRows) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Payload not found"})
+			return
+		}
+		if err != nil {
+			slog.Error("Failed to fetch payload", slog.Any("id", id), slog.Any("error", err))
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch payload"})
+			return
+		}
+		if latest.ContentHash == hash && latest.Name == request.Name {
+			c.JSON(http.StatusOK, payloadVersionResponse(latest, true))
+			return
+		}
+
+		stored, err := p.StorePayloadVersion(persister.PayloadVersion{ID: id, Name: request.Name, Content: content, Hash: hash})
+		if err != nil {
+			slog.Error("Failed to store payload", slog.Any("id", id), slog.Any("error", err))
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to store payload"})
+			return
+		}
+
+		slog.Info("Payload updated", slog.Any("id", id), slog.Int64("version", stored.Version))
+		c.JSON(http.StatusOK, payloadVersionResponse(stored, true))
+	}
+}
+
+// DeletePayload godoc
+//
+// @Summary      Delete a payload
+// @Description  Removes a payload from the payload library. Its versions are kept for the jobs which ran with them, but can no longer be referenced.
+// @Tags         payloads
+// @Produce      json
+// @Param        id   path  string  true  "Payload ID"
+// @Success      200  {object}  response.SimpleMessage
+// @Failure      400  {object}  response.ErrorMessage
+// @Failure      404  {object}  response.NotFoundMessage
+// @Failure      500  {object}  response.ServerErrorMessage
+// @Router       /payloads/{id} [delete]
+func DeletePayload(p persister.Database) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, ok := parsePayloadID(c)
+		if !ok {
+			return
+		}
+
+		deleted, err := p.DeletePayload(id)
+		if err != nil {
+			slog.Error("Failed to delete payload", slog.Any("id", id), slog.Any("error", err))
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete payload"})
+			return
+		}
+		if deleted == 0 {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Payload not found"})
+			return
+		}
+
+		slog.Info("Payload deleted", slog.Any("id", id))
+		c.JSON(http.StatusOK, gin.H{"message": "Payload deleted"})
+	}
+}
@@ -0,0 +1,117 @@
+package benchmarkController
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/Mtze/CI-Benchmarker/persister"
+	"github.com/gin-gonic/gin"
+	"github.com/ls1intum/hades/shared/payload"
+)
+
+func TestEncodePayload(t *testing.T) {
+	decode := func(text string) payload.RESTPayload {
+		t.Helper()
+		var p payload.RESTPayload
+		if err := json.Unmarshal([]byte(text), &p); err != nil {
+			t.Fatal(err)
+		}
+		return p
+	}
+	encode := func(p payload.RESTPayload) string {
+		t.Helper()
+		_, hash, err := encodePayload(p)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return hash
+	}
+
+	p := decode(`{"name":"job","metadata":{"A":"1","B":"2"},"steps":[{"id":1,"name":"Build","image":"gradle"}]}`)
+	hash := encode(p)
+	if len(hash) != 64 {
+		t.Fatalf("expected a hex encoded sha256 hash, got %q", hash)
+	}
+	for range 10 {
+		if again := encode(p); again != hash {
+			t.Fatalf("expected the hash to be stable, got %s and %s", hash, again)
+		}
+	}
+
+	// Formatting and the order of keys do not change the hash
+	reordered := decode(`{
+		"steps": [{"image": "gradle", "name": "Build", "id": 1}],
+		"metadata": {"B": "2", "A": "1"},
+		"name": "job"
+	}`)
+	if again := encode(reordered); again != hash {
+		t.Fatalf("expected the same hash for a reformatted payload, got %s and %s", hash, again)
+	}
+
+	p.Steps[0].Image = "maven"
+	if changed := encode(p); changed == hash {
+		t.Fatal("expected a different hash for a different payload")
+	}
+}
+
+func TestUpdatePayload(t *testing.T) {
+	p, err := persister.NewDBPersister(persister.InMemory)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer p.Close()
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.POST("/payloads", CreatePayload(p))
+	router.PUT("/payloads/:id", UpdatePayload(p))
+	send := func(method string, path string, body string) PayloadVersionResponse {
+		t.Helper()
+		recorder := httptest.NewRecorder()
+		request := httptest.NewRequest(method, path, strings.NewReader(body))
+		request.Header.Set("Content-Type", "application/json")
+		router.ServeHTTP(recorder, request)
+		if recorder.Code != http.StatusOK && recorder.Code != http.StatusCreated {
+			t.Fatalf("%s %s: unexpected status %d: %s", method, path, recorder.Code, recorder.Body)
+		}
+		var response PayloadVersionResponse
+		if err := json.Unmarshal(recorder.Body.Bytes(), &response); err != nil {
+			t.Fatal(err)
+		}
+		return response
+	}
+
+	created := send(http.MethodPost, "/payloads", `{"name":"gradle","payload":{"name":"job","metadata":{"A":"1","B":"2"}}}`)
+	if created.Version != 1 {
+		t.Fatalf("expected version 1, got %d", created.Version)
+	}
+	path := "/payloads/" + created.ID.String()
+
+	tests := []struct {
+		name    string
+		body    string
+		version int64
+	}{
+		{"unchanged", `{"name":"gradle","payload":{"name":"job","metadata":{"A":"1","B":"2"}}}`, 1},
+		{"reordered", `{"name":"gradle","payload":{"metadata":{"B":"2","A":"1"},"name":"job"}}`, 1},
+		{"renamed", `{"name":"gradle-java17","payload":{"name":"job","metadata":{"A":"1","B":"2"}}}`, 2},
+		{"changed content", `{"name":"gradle-java17","payload":{"name":"job","metadata":{"A":"3","B":"2"}}}`, 3},
+		{"unchanged again", `{"name":"gradle-java17","payload":{"name":"job","metadata":{"A":"3","B":"2"}}}`, 3},
+	}
+	for _, test := range tests {
+		if updated := send(http.MethodPut, path, test.body); updated.Version != test.version {
+			t.Fatalf("%s: expected version %d, got %d", test.name, test.version, updated.Version)
+		}
+	}
+
+	versions, err := p.ListPayloadVersions(created.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(versions) != 3 {
+		t.Fatalf("expected 3 stored versions, got %d", len(versions))
+	}
+}
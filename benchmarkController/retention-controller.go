@@ -0,0 +1,58 @@
+package benchmarkController
+
+import (
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/Mtze/CI-Benchmarker/persister"
+	_ "github.com/Mtze/CI-Benchmarker/shared/response"
+	"github.com/gin-gonic/gin"
+)
+
+// PruneResponse describes the jobs and runs which were deleted and the archive they were written to
+//
+// @Description Jobs and runs deleted from the database.
+type PruneResponse struct {
+	Jobs    int    `json:"jobs"              example:"1200"`
+	Runs    int    `json:"runs"              example:"3"`
+	Archive string `json:"archive,omitempty" example:"archive/pruned-20250204T110000Z-1234567.jsonl.gz"`
+}
+
+// DeleteJobs godoc
+//
+// @Summary      Delete jobs
+// @Description  Deletes the jobs created before the given time, optionally only those of an executor, with their times. Runs which are left without jobs and are not running any more are deleted as well.
+// @Description  The deleted jobs and runs are written to a gzip compressed JSONL archive in the archive directory of the server first.
+// @Tags         jobs
+// @Produce      json
+// @Param        before    query  string  true   "Delete the jobs created before this time (RFC 3339)"
+// @Param        executor  query  string  false  "Only delete the jobs of this executor"
+// @Security     AdminToken
+// @Success      200  {object}  PruneResponse
+// @Failure      400  {object}  response.ErrorMessage
+// @Failure      401  {object}  response.ErrorMessage
+// @Failure      403  {object}  response.ErrorMessage
+// @Failure      500  {object}  response.ServerErrorMessage
+// @Router       /jobs [delete]
+func DeleteJobs(s Services) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		before, err := time.Parse(time.RFC3339, c.Query("before"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "before must be an RFC 3339 time"})
+			return
+		}
+		filter := persister.PruneFilter{Before: &before}
+		if executor := c.Query("executor"); executor != "" {
+			filter.Executor = &executor
+		}
+
+		result, err := s.Pruner.Prune(c.Request.Context(), filter)
+		if err != nil {
+			slog.Error("Failed to delete jobs", slog.Any("error", err))
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete jobs"})
+			return
+		}
+		c.JSON(http.StatusOK, PruneResponse{Jobs: result.Jobs, Runs: result.Runs, Archive: result.Archive})
+	}
+}
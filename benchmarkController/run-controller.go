@@ -0,0 +1,398 @@
+package benchmarkController
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/Mtze/CI-Benchmarker/executor"
+	"github.com/Mtze/CI-Benchmarker/persister"
+	_ "github.com/Mtze/CI-Benchmarker/shared/response"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// Status values of a benchmark run
+const (
+	RunStatusRunning   = "running"
+	RunStatusSubmitted = "submitted"
+	RunStatusFailed    = "failed"
+	RunStatusCancelled = "cancelled"
+	// RunStatusInterrupted marks runs which were still submitting jobs when the server shut down
+	RunStatusInterrupted = "interrupted"
+)
+
+// errRunCancelled is the cause of the context of a run which was cancelled on request
+var errRunCancelled = errors.New("run cancelled")
+
+// errExperimentCancelled is the cause of the context of an experiment which was cancelled on request
+var errExperimentCancelled = errors.New("experiment cancelled")
+
+// RunAborted reports whether a run stopped before all of its jobs were submitted
+func RunAborted(status string) bool {
+	return status == RunStatusFailed || status == RunStatusCancelled || status == RunStatusInterrupted
+}
+
+// RunSummary describes a benchmark run and the progress of its jobs.
+//
+// @Description State of a benchmark run and the number of its jobs per state.
+type RunSummary struct {
+	ID            uuid.UUID         `json:"id"             example:"3b0bce02-43c6-4e66-8810-b7a0ac5b3a08"`
+	Executor      string            `json:"executor"       example:"HadesDockerExecutor"`
+	Scenario      string            `json:"scenario,omitempty" example:"hades-docker-gradle"`
+	Labels        map[string]string `json:"labels,omitempty"`
+	Status        string            `json:"status"         example:"submitted"`
+	Error         string            `json:"error,omitempty"`
+	CreationTime  time.Time         `json:"creation_time"`
+	FinishTime    *time.Time        `json:"finish_time,omitempty"`
+	JobCount      int64             `json:"job_count"      example:"100"`
+	Concurrency   int64             `json:"concurrency"    example:"20"`
+	ScheduledJobs int64             `json:"scheduled_jobs" example:"100"`
+	FinishedJobs  int64             `json:"finished_jobs"  example:"42"`
+	CancelledJobs int64             `json:"cancelled_jobs" example:"0"`
+	// InvalidJobs finished before they started according to their callbacks, their build time is left out
+	InvalidJobs int64 `json:"invalid_jobs" example:"0"`
+	// DuplicateCallbacks counts the callbacks which reported a time of a job again
+	DuplicateCallbacks int64             `json:"duplicate_callbacks" example:"3"`
+	Submission         SubmissionSummary `json:"submission"`
+}
+
+// SubmissionSummary describes how the jobs of a run were submitted to the CI system.
+//
+// @Description Submission attempts, submit latency and client-side submit delay of the jobs of a run (milliseconds).
+type SubmissionSummary struct {
+	Attempts           int64 `json:"attempts"              example:"104"`
+	RetriedJobs        int64 `json:"retried_jobs"          example:"4"`
+	FailedJobs         int64 `json:"failed_jobs"           example:"0"`
+	AvgSubmitLatencyMs int64 `json:"avg_submit_latency_ms" example:"120"`
+	MaxSubmitLatencyMs int64 `json:"max_submit_latency_ms" example:"1830"`
+	// Submit delay is the time a job waited on the client side before its first attempt
+	AvgSubmitDelayMs int64 `json:"avg_submit_delay_ms" example:"950"`
+	MaxSubmitDelayMs int64 `json:"max_submit_delay_ms" example:"2100"`
+}
+
+// CancelRunResponse is returned after a run was cancelled.
+//
+// @Description Result of cancelling a benchmark run, the CI system is asked to cancel the jobs in the background.
+type CancelRunResponse struct {
+	Message       string    `json:"message"        example:"Run cancelled"`
+	RunID         uuid.UUID `json:"run_id"         example:"3b0bce02-43c6-4e66-8810-b7a0ac5b3a08"`
+	CancelledJobs int64     `json:"cancelled_jobs" example:"58"`
+}
+
+// RunRegistry keeps track of the runs of this process, so they can be cancelled while they are
+// still submitting jobs and the executor can be asked to cancel the jobs it already submitted.
+// Experiments are tracked as well, so they can be cancelled before their next run. It also tracks the
+// goroutines of runs and experiments, so the server can wait for them on shutdown.
+type RunRegistry struct {
+	ctx     context.Context
+	workers sync.WaitGroup
+
+	mu          sync.Mutex
+	runs        map[uuid.UUID]*activeRun
+	experiments map[uuid.UUID]context.CancelCauseFunc
+}
+
+type activeRun struct {
+	executor executor.Executor
+	cancel   context.CancelCauseFunc
+}
+
+// NewRunRegistry creates a registry of the runs of this process. The executors of runs which finished
+// submitting their jobs are kept until the run is cancelled, so their jobs can be cancelled as long as
+// they run. All runs are cancelled once ctx is done, e.g. when the server shuts down.
+func NewRunRegistry(ctx context.Context) *RunRegistry {
+	return &RunRegistry{
+		ctx:         ctx,
+		runs:        make(map[uuid.UUID]*activeRun),
+		experiments: make(map[uuid.UUID]context.CancelCauseFunc),
+	}
+}
+
+// Context returns the context all runs are derived from, it is done once the server shuts down
+func (r *RunRegistry) Context() context.Context {
+	return r.ctx
+}
+
+// Go runs f in a goroutine which is awaited by Wait
+func (r *RunRegistry) Go(f func()) {
+	r.workers.Add(1)
+	go func() {
+		defer r.workers.Done()
+		f()
+	}()
+}
+
+// Wait blocks until all goroutines started with Go returned. They return soon after the context of the
+// registry is done.
+func (r *RunRegistry) Wait() {
+	r.workers.Wait()
+}
+
+// Register adds a run and returns a context which is cancelled once the run is cancelled or the server
+// shuts down. The cause of the context is errRunCancelled if the run was cancelled on request.
+func (r *RunRegistry) Register(runID uuid.UUID, exec executor.Executor) context.Context {
+	ctx, cancel := context.WithCancelCause(r.ctx)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.runs[runID] = &activeRun{executor: exec, cancel: cancel}
+	return ctx
+}
+
+// Finish marks a run as done submitting jobs and releases its context. Its executor is kept, it only
+// holds the address and credentials of the CI system.
+func (r *RunRegistry) Finish(runID uuid.UUID) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if run, ok := r.runs[runID]; ok {
+		run.cancel(nil)
+	}
+}
+
+// Cancel stops the submission of further jobs of a run and removes it from the registry. It returns
+// the executor of the run, or false if the run is not known to this registry, e.g. after a restart.
+func (r *RunRegistry) Cancel(runID uuid.UUID) (executor.Executor, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	run, ok := r.runs[runID]
+	if !ok {
+		return nil, false
+	}
+	run.cancel(errRunCancelled)
+	delete(r.runs, runID)
+	return run.executor, true
+}
+
+// RegisterExperiment adds an experiment and returns a context which is cancelled once the experiment is
+// cancelled or the server shuts down. The cause of the context is errExperimentCancelled if the
+// experiment was cancelled on request.
+func (r *RunRegistry) RegisterExperiment(experimentID uuid.UUID) context.Context {
+	ctx, cancel := context.WithCancelCause(r.ctx)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.experiments[experimentID] = cancel
+	return ctx
+}
+
+// FinishExperiment removes an experiment once it executed or stopped all of its runs
+func (r *RunRegistry) FinishExperiment(experimentID uuid.UUID) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if cancel, ok := r.experiments[experimentID]; ok {
+		cancel(nil)
+		delete(r.experiments, experimentID)
+	}
+}
+
+// CancelExperiment stops an experiment before its next run. It returns false if the experiment is not
+// running in this process, e.g. because it finished or the server restarted.
+func (r *RunRegistry) CancelExperiment(experimentID uuid.UUID) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	cancel, ok := r.experiments[experimentID]
+	if ok {
+		cancel(errExperimentCancelled)
+		delete(r.experiments, experimentID)
+	}
+	return ok
+}
+
+// GetRun godoc
+//
+// @Summary      Get a benchmark run
+// @Description  Returns the state of a benchmark run and the progress of its jobs.
+// @Tags         runs
+// @Produce      json
+// @Param        id   path  string  true  "Run ID"
+// @Success      200  {object}  RunSummary
+// @Failure      400  {object}  response.ErrorMessage
+// @Failure      404  {object}  response.NotFoundMessage
+// @Failure      500  {object}  response.ServerErrorMessage
+// @Router       /runs/{id} [get]
+func GetRun(p persister.Database) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		runID, err := uuid.Parse(c.Param("id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to parse run ID"})
+			return
+		}
+
+		run, err := p.GetRun(runID)
+		if errors.Is(err, sql.ErrNoRows) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Run not found"})
+			return
+		}
+		if err != nil {
+			slog.Error("Failed to fetch run", slog.Any("runID", runID), slog.Any("error", err))
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch run"})
+			return
+		}
+
+		stats, err := p.GetRunJobStats(runID)
+		if err != nil {
+			slog.Error("Failed to fetch run job stats", slog.Any("runID", runID), slog.Any("error", err))
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch run"})
+			return
+		}
+
+		labels, err := persister.RunLabels(run)
+		if err != nil {
+			slog.Warn("Failed to decode run labels", slog.Any("runID", runID), slog.Any("error", err))
+		}
+
+		submission, err := p.GetRunSubmissionStats(runID)
+		if err != nil {
+			slog.Error("Failed to fetch run submission stats", slog.Any("runID", runID), slog.Any("error", err))
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch run"})
+			return
+		}
+
+		summary := RunSummary{
+			ID:                 run.ID,
+			Executor:           run.Executor,
+			Scenario:           run.Scenario.String,
+			Labels:             labels,
+			Status:             run.Status,
+			Error:              run.Error.String,
+			CreationTime:       run.CreationTime,
+			JobCount:           run.JobCount,
+			Concurrency:        run.Concurrency,
+			ScheduledJobs:      stats.ScheduledJobs,
+			FinishedJobs:       stats.FinishedJobs,
+			CancelledJobs:      stats.CancelledJobs,
+			InvalidJobs:        stats.InvalidJobs,
+			DuplicateCallbacks: stats.DuplicateCallbacks,
+			Submission: SubmissionSummary{
+				Attempts:           submission.Attempts,
+				RetriedJobs:        submission.RetriedJobs,
+				FailedJobs:         submission.FailedJobs,
+				AvgSubmitLatencyMs: submission.AvgSubmitLatencyMs,
+				MaxSubmitLatencyMs: submission.MaxSubmitLatencyMs,
+				AvgSubmitDelayMs:   submission.AvgSubmitDelayMs,
+				MaxSubmitDelayMs:   submission.MaxSubmitDelayMs,
+			},
+		}
+		if run.FinishTime.Valid {
+			summary.FinishTime = &run.FinishTime.Time
+		}
+
+		c.JSON(http.StatusOK, summary)
+	}
+}
+
+// CancelRun godoc
+//
+// @Summary      Cancel a benchmark run
+// @Description  Stops scheduling further jobs of the run and marks all unfinished jobs as cancelled, which excludes them from all metrics.
+// @Description  The CI system is asked to cancel the jobs which are already submitted in the background.
+// @Tags         runs
+// @Produce      json
+// @Param        id   path  string  true  "Run ID"
+// @Success      202  {object}  CancelRunResponse
+// @Failure      400  {object}  response.ErrorMessage
+// @Failure      404  {object}  response.NotFoundMessage
+// @Failure      500  {object}  response.ServerErrorMessage
+// @Router       /runs/{id}/cancel [post]
+func CancelRun(s Services) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		runID, err := uuid.Parse(c.Param("id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to parse run ID"})
+			return
+		}
+
+		p := s.Persister
+		if _, err := p.GetRun(runID); errors.Is(err, sql.ErrNoRows) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Run not found"})
+			return
+		} else if err != nil {
+			slog.Error("Failed to fetch run", slog.Any("runID", runID), slog.Any("error", err))
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch run"})
+			return
+		}
+
+		slog.Info("Cancelling run", slog.Any("runID", runID))
+		cancelled, err := cancelRun(s, runID)
+		if err != nil {
+			slog.Error("Failed to cancel run", slog.Any("runID", runID), slog.Any("error", err))
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to cancel run"})
+			return
+		}
+
+		c.JSON(http.StatusAccepted, CancelRunResponse{
+			Message:       "Run cancelled",
+			RunID:         runID,
+			CancelledJobs: cancelled,
+		})
+	}
+}
+
+// cancelRun stops submitting further jobs of a run, marks its unfinished jobs and the run as cancelled
+// and asks the CI system to cancel the jobs which are already submitted in the background. It returns
+// the number of cancelled jobs.
+func cancelRun(s Services, runID uuid.UUID) (int64, error) {
+	p := s.Persister
+	exec, active := s.Runs.Cancel(runID)
+
+	pending, err := p.GetPendingJobIDsByRun(runID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to fetch pending jobs: %w", err)
+	}
+
+	if s.Reconciler != nil {
+		for _, jobID := range pending {
+			s.Reconciler.Untrack(jobID)
+		}
+	}
+
+	cancelled, err := p.CancelPendingJobsByRun(runID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to mark jobs as cancelled: %w", err)
+	}
+	if err := p.UpdateRunStatus(runID, RunStatusCancelled, nil); err != nil {
+		return cancelled, fmt.Errorf("failed to store run status: %w", err)
+	}
+
+	// Cancelling takes a request per job, the CI system is asked in the background
+	if canceller, ok := exec.(executor.Canceller); ok && active && len(pending) > 0 {
+		s.Runs.Go(func() { cancelJobs(s.Runs.Context(), canceller, pending) })
+	}
+	return cancelled, nil
+}
+
+// cancelConcurrency bounds the cancel requests sent to the CI system at the same time
+const cancelConcurrency = 10
+
+// cancelJobs asks the CI system to cancel the jobs, it returns once all of them were cancelled or ctx is done
+func cancelJobs(ctx context.Context, canceller executor.Canceller, jobIDs []uuid.UUID) {
+	var wg sync.WaitGroup
+	slots := make(chan struct{}, cancelConcurrency)
+	for _, jobID := range jobIDs {
+		if ctx.Err() != nil {
+			break
+		}
+		slots <- struct{}{}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer func() { <-slots }()
+			cancelJob(ctx, canceller, jobID)
+		}()
+	}
+	wg.Wait()
+}
+
+func cancelJob(ctx context.Context, canceller executor.Canceller, jobID uuid.UUID) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+	if err := canceller.Cancel(ctx, jobID); err != nil {
+		slog.Warn("Failed to cancel job", slog.Any("uuid", jobID), slog.Any("error", err))
+	}
+}
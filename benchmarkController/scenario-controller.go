@@ -0,0 +1,146 @@
+package benchmarkController
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"github.com/Mtze/CI-Benchmarker/payloadtemplate"
+	"github.com/Mtze/CI-Benchmarker/scenario"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// ScenarioStartedResponse is returned once a scenario was started, either as a single run
+// or as an experiment if the scenario declares a matrix.
+//
+// @Description Run or experiment started from a scenario.
+type ScenarioStartedResponse struct {
+	Message      string     `json:"message"                 example:"Scenario started"`
+	Scenario     string     `json:"scenario"                example:"hades-docker-gradle"`
+	RunID        *uuid.UUID `json:"run_id,omitempty"        example:"3b0bce02-43c6-4e66-8810-b7a0ac5b3a08"`
+	ExperimentID *uuid.UUID `json:"experiment_id,omitempty" example:"8d0f6a52-44d5-4c6f-a7a3-1f0b9a1c7e21"`
+	RunCount     int        `json:"run_count,omitempty"     example:"12"`
+}
+
+// NewScenarioBenchmark creates the benchmark described by a scenario. Settings which are not part of
+// the scenario, like the retry backoff, are taken from the services.
+func NewScenarioBenchmark(s Services, sc *scenario.Scenario) (Benchmark, error) {
+	exec, err := sc.Executor.NewExecutor(s.HTTPClient)
+	if err != nil {
+		return Benchmark{}, err
+	}
+
+	benchmark := Benchmark{
+		Executor:    exec,
+		Persister:   s.Persister,
+		Reconciler:  s.Reconciler,
+		Runs:        s.Runs,
+		RetryPolicy: s.RetryPolicy,
+		Concurrency: s.Concurrency,
+		JobCounter:  sc.JobCount(),
+		Interval:    sc.Load.Interval(),
+		WarmupJobs:  sc.Warmup.Count,
+		WarmupPause: sc.Warmup.Pause.Std(),
+		Scenario:    sc.Name,
+		Labels:      sc.Labels,
+		PublicURL:   s.PublicURL,
+	}
+	if sc.Reporters != nil && sc.Reporters.Inject {
+		reporters := s.Reporters
+		reporters.ResultMetadata = sc.Reporters.ResultMetadata
+		benchmark.Reporters = &reporters
+	}
+	if sc.Template != nil {
+		benchmark.Templating = &payloadtemplate.Renderer{BaseURL: s.PublicURL, Choices: sc.Template.Choices}
+	}
+	if sc.Trace != nil {
+		benchmark.Trace = make([]TraceJob, len(sc.Trace.Entries))
+		for i, entry := range sc.Trace.Entries {
+			benchmark.Trace[i] = TraceJob{Offset: sc.Trace.ScaledOffset(entry), Payload: entry.Payload}
+		}
+	}
+	if sc.Load.Concurrency != nil {
+		benchmark.Concurrency = *sc.Load.Concurrency
+	}
+	if sc.MaxAttempts > 0 {
+		benchmark.RetryPolicy.MaxAttempts = sc.MaxAttempts
+	}
+	return benchmark, nil
+}
+
+// RunScenario godoc
+//
+// @Summary      Run a benchmark scenario
+// @Description  Starts a benchmark run described by a YAML or JSON scenario, i.e. the targeted executor, the payload (inline or as payload_file relative to the scenario directory of the server), the load profile or a trace of recorded submissions to replay, labels and warm-up jobs. The run can be followed with GET /runs/{id}. A scenario with a matrix starts an experiment instead, which can be followed with GET /experiments/{id}.
+// @Tags         scenarios
+// @Accept       json
+// @Accept       application/yaml
+// @Produce      json
+// @Param        scenario  body  string  true  "Scenario (YAML or JSON)"
+// @Success      202  {object}  ScenarioStartedResponse
+// @Failure      400  {object}  response.ErrorMessage
+// @Router       /scenarios/run [post]
+func RunScenario(s Services) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		data, err := c.GetRawData()
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to read scenario"})
+			return
+		}
+
+		sc, err := scenario.Parse(data, s.ScenarioDir)
+		if err != nil {
+			slog.Error("Invalid scenario", slog.Any("error", err))
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		if sc.Matrix != nil {
+			experimentID, runCount, err := StartExperiment(s, sc)
+			if errors.Is(err, errStorage) {
+				slog.Error("Failed to start experiment", slog.String("scenario", sc.Name), slog.Any("error", err))
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to store experiment"})
+				return
+			}
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+				return
+			}
+			c.JSON(http.StatusAccepted, ScenarioStartedResponse{
+				Message:      "Experiment started",
+				Scenario:     sc.Name,
+				ExperimentID: &experimentID,
+				RunCount:     runCount,
+			})
+			return
+		}
+
+		benchmark, err := NewScenarioBenchmark(s, sc)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		slog.Info("Starting scenario", slog.String("scenario", sc.Name), slog.Int("count", sc.JobCount()))
+		runID, _, err := benchmark.Start(*sc.Payload, scenarioCommitHash(sc))
+		if err != nil {
+			slog.Error("Failed to start scenario", slog.String("scenario", sc.Name), slog.Any("error", err))
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to store run"})
+			return
+		}
+
+		c.JSON(http.StatusAccepted, ScenarioStartedResponse{
+			Message:  "Scenario started",
+			Scenario: sc.Name,
+			RunID:    &runID,
+		})
+	}
+}
+
+func scenarioCommitHash(sc *scenario.Scenario) *string {
+	if sc.CommitHash == "" {
+		return nil
+	}
+	return &sc.CommitHash
+}
@@ -0,0 +1,200 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/Mtze/CI-Benchmarker/MetricsController"
+	"github.com/Mtze/CI-Benchmarker/benchmarkController"
+	"github.com/Mtze/CI-Benchmarker/scenario"
+	"github.com/google/uuid"
+)
+
+// metricEndpoints maps the metric names to the path segments of their endpoints
+var metricEndpoints = map[string]string{
+	"queue_latency": "queue_latency",
+	"build_time":    "build_time",
+	"total_latency": "latency",
+}
+
+// ErrNoData is returned if no job matches the filter of a metrics request
+var ErrNoData = errors.New("no data found")
+
+// APIError is returned if the benchmarker answered with an error status
+type APIError struct {
+	StatusCode int
+	Message    string
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("benchmarker returned %d: %s", e.StatusCode, e.Message)
+}
+
+// Client talks to the REST API of a benchmarker
+type Client struct {
+	BaseURL string
+	HTTP    *http.Client
+}
+
+// New creates a client for the benchmarker at baseURL, e.g. http://localhost:8080
+func New(baseURL string) *Client {
+	return &Client{
+		BaseURL: strings.TrimSuffix(baseURL, "/"),
+		HTTP:    &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+// MetricsFilter selects the jobs a metric is computed for, nil fields are not filtered
+type MetricsFilter struct {
+	Executor   string
+	From       *time.Time
+	To         *time.Time
+	CommitHash string
+	// PayloadHash is the content hash of the payload the jobs were submitted with
+	PayloadHash string
+	// Labels selects the jobs carrying all of these labels
+	Labels map[string]string
+}
+
+func (f MetricsFilter) query() url.Values {
+	q := url.Values{}
+	q.Set("executor", f.Executor)
+	if f.From != nil {
+		q.Set("from", f.From.UTC().Format("2006-01-02T15:04:05"))
+	}
+	if f.To != nil {
+		q.Set("to", f.To.UTC().Format("2006-01-02T15:04:05"))
+	}
+	if f.CommitHash != "" {
+		q.Set("commit_hash", f.CommitHash)
+	}
+	if f.PayloadHash != "" {
+		q.Set("payload_hash", f.PayloadHash)
+	}
+	for key, value := range f.Labels {
+		q.Set("label."+key, value)
+	}
+	return q
+}
+
+// RunScenario starts a scenario. Payload files are inlined, so they do not need to exist on the server.
+func (c *Client) RunScenario(ctx context.Context, sc *scenario.Scenario) (benchmarkController.ScenarioStartedResponse, error) {
+	inlined := *sc
+	inlined.PayloadFile = ""
+	if sc.Matrix != nil {
+		matrix := *sc.Matrix
+		matrix.Payloads = make([]scenario.NamedPayload, len(sc.Matrix.Payloads))
+		for i, p := range sc.Matrix.Payloads {
+			p.PayloadFile = ""
+			matrix.Payloads[i] = p
+		}
+		inlined.Matrix = &matrix
+	}
+
+	body, err := json.Marshal(inlined)
+	if err != nil {
+		return benchmarkController.ScenarioStartedResponse{}, err
+	}
+
+	var started benchmarkController.ScenarioStartedResponse
+	err = c.do(ctx, http.MethodPost, "/v1/scenarios/run", nil, body, &started)
+	return started, err
+}
+
+// GetRun returns the state of a run
+func (c *Client) GetRun(ctx context.Context, runID uuid.UUID) (benchmarkController.RunSummary, error) {
+	var run benchmarkController.RunSummary
+	err := c.do(ctx, http.MethodGet, "/v1/runs/"+runID.String(), nil, nil, &run)
+	return run, err
+}
+
+// CancelRun stops submitting jobs of a run and marks its unfinished jobs as cancelled
+func (c *Client) CancelRun(ctx context.Context, runID uuid.UUID) (benchmarkController.CancelRunResponse, error) {
+	var cancelled benchmarkController.CancelRunResponse
+	err := c.do(ctx, http.MethodPost, "/v1/runs/"+runID.String()+"/cancel", nil, nil, &cancelled)
+	return cancelled, err
+}
+
+// GetExperiment returns the state and comparison of an experiment
+func (c *Client) GetExperiment(ctx context.Context, experimentID uuid.UUID) (benchmarkController.ExperimentSummary, error) {
+	var experiment benchmarkController.ExperimentSummary
+	err := c.do(ctx, http.MethodGet, "/v1/experiments/"+experimentID.String(), nil, nil, &experiment)
+	return experiment, err
+}
+
+// CancelExperiment stops an experiment before its next run and cancels its run which is still submitting jobs
+func (c *Client) CancelExperiment(ctx context.Context, experimentID uuid.UUID) (benchmarkController.CancelExperimentResponse, error) {
+	var cancelled benchmarkController.CancelExperimentResponse
+	err := c.do(ctx, http.MethodPost, "/v1/experiments/"+experimentID.String()+"/cancel", nil, nil, &cancelled)
+	return cancelled, err
+}
+
+// GetMetric returns the summary of a metric (queue_latency, build_time or total_latency),
+// ErrNoData if no job matches the filter
+func (c *Client) GetMetric(ctx context.Context, metric string, filter MetricsFilter) (MetricsController.MetricSummary, error) {
+	var summary MetricsController.MetricSummary
+	endpoint, ok := metricEndpoints[metric]
+	if !ok {
+		return summary, fmt.Errorf("unknown metric %q", metric)
+	}
+	err := c.do(ctx, http.MethodGet, "/v1/benchmark/"+endpoint+"/metrics", filter.query(), nil, &summary)
+	var apiErr *APIError
+	if errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusNotFound {
+		return summary, ErrNoData
+	}
+	return summary, err
+}
+
+func (c *Client) do(ctx context.Context, method string, path string, query url.Values, body []byte, out any) error {
+	u := c.BaseURL + path
+	if len(query) > 0 {
+		u += "?" + query.Encode()
+	}
+
+	var reader io.Reader
+	if body != nil {
+		reader = bytes.NewReader(body)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, u, reader)
+	if err != nil {
+		return err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := c.HTTP.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode >= 300 {
+		var msg struct {
+			Error   string `json:"error"`
+			Message string `json:"message"`
+		}
+		_ = json.Unmarshal(data, &msg)
+		text := msg.Error
+		if text == "" {
+			text = msg.Message
+		}
+		if text == "" {
+			text = strings.TrimSpace(string(data))
+		}
+		return &APIError{StatusCode: resp.StatusCode, Message: text}
+	}
+	return json.Unmarshal(data, out)
+}
@@ -0,0 +1,96 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/Mtze/CI-Benchmarker/MetricsController"
+	"github.com/Mtze/CI-Benchmarker/benchmarkController"
+	"github.com/google/uuid"
+)
+
+// RunDone reports whether a run failed, was cancelled or interrupted or submitted all jobs and all of them finished
+func RunDone(run benchmarkController.RunSummary) bool {
+	if benchmarkController.RunAborted(run.Status) {
+		return true
+	}
+	if run.Status == benchmarkController.RunStatusRunning {
+		return false
+	}
+	return run.FinishedJobs+run.CancelledJobs >= run.ScheduledJobs
+}
+
+// WaitForRun polls a run until RunDone. If ctx is done first, the last state and the error of ctx are returned.
+func (c *Client) WaitForRun(ctx context.Context, runID uuid.UUID, interval time.Duration) (benchmarkController.RunSummary, error) {
+	var run benchmarkController.RunSummary
+	err := poll(ctx, interval, func() (bool, error) {
+		current, err := c.GetRun(ctx, runID)
+		if err != nil {
+			return false, err
+		}
+		run = current
+		return RunDone(run), nil
+	})
+	return run, err
+}
+
+// WaitForExperiment polls an experiment until it is no longer running. If ctx is done first, the last state
+// and the error of ctx are returned.
+func (c *Client) WaitForExperiment(ctx context.Context, experimentID uuid.UUID, interval time.Duration) (benchmarkController.ExperimentSummary, error) {
+	var experiment benchmarkController.ExperimentSummary
+	err := poll(ctx, interval, func() (bool, error) {
+		current, err := c.GetExperiment(ctx, experimentID)
+		if err != nil {
+			return false, err
+		}
+		experiment = current
+		return experiment.Status != benchmarkController.ExperimentStatusRunning, nil
+	})
+	return experiment, err
+}
+
+// poll calls check every interval until it reports done, fails or ctx is done
+func poll(ctx context.Context, interval time.Duration, check func() (bool, error)) error {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		done, err := check()
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if err != nil || done {
+			return err
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// Metrics returns the summaries of all metrics keyed by metric name, metrics without data are left out
+func (c *Client) Metrics(ctx context.Context, filter MetricsFilter) (map[string]*MetricsController.MetricSummary, error) {
+	metrics := make(map[string]*MetricsController.MetricSummary)
+	for metric := range metricEndpoints {
+		summary, err := c.GetMetric(ctx, metric, filter)
+		if errors.Is(err, ErrNoData) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		metrics[metric] = &summary
+	}
+	return metrics, nil
+}
+
+// RunMetrics returns the metrics of the jobs of the executor of a run started since the run was created.
+// Jobs of runs started later on the same executor are included as well.
+func (c *Client) RunMetrics(ctx context.Context, run benchmarkController.RunSummary) (map[string]*MetricsController.MetricSummary, error) {
+	from := run.CreationTime
+	return c.Metrics(ctx, MetricsFilter{Executor: run.Executor, From: &from})
+}
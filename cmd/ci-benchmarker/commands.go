@@ -0,0 +1,508 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/Mtze/CI-Benchmarker/benchmarkController"
+	"github.com/Mtze/CI-Benchmarker/client"
+	"github.com/Mtze/CI-Benchmarker/report"
+	"github.com/Mtze/CI-Benchmarker/scenario"
+	"github.com/google/uuid"
+)
+
+// stringList is a flag which can be given multiple times
+type stringList []string
+
+func (l *stringList) String() string {
+	return strings.Join(*l, ",")
+}
+
+func (l *stringList) Set(value string) error {
+	*l = append(*l, value)
+	return nil
+}
+
+// labelFlags collects repeated key=value flags
+type labelFlags map[string]string
+
+func (l labelFlags) String() string {
+	pairs := make([]string, 0, len(l))
+	for key, value := range l {
+		pairs = append(pairs, key+"="+value)
+	}
+	return strings.Join(pairs, ",")
+}
+
+func (l labelFlags) Set(value string) error {
+	key, val, ok := strings.Cut(value, "=")
+	if !ok || key == "" {
+		return fmt.Errorf("invalid label %q, expected key=value", value)
+	}
+	l[key] = val
+	return nil
+}
+
+// timeFlag is an optional timestamp flag in RFC 3339 or 2006-01-02T15:04:05 (UTC) format
+type timeFlag struct {
+	t *time.Time
+}
+
+func (f *timeFlag) String() string {
+	if f.t == nil {
+		return ""
+	}
+	return f.t.Format(time.RFC3339)
+}
+
+func (f *timeFlag) Set(value string) error {
+	for _, layout := range []string{time.RFC3339, "2006-01-02T15:04:05"} {
+		if t, err := time.Parse(layout, value); err == nil {
+			f.t = &t
+			return nil
+		}
+	}
+	return fmt.Errorf("invalid time %q, expected RFC 3339", value)
+}
+
+// commonFlags are shared by all commands
+type commonFlags struct {
+	server string
+}
+
+func newFlagSet(name string, usage string) (*flag.FlagSet, *commonFlags) {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: ci-benchmarker %s %s\n\nFlags:\n", name, usage)
+		fs.PrintDefaults()
+	}
+
+	common := &commonFlags{}
+	server := os.Getenv("CI_BENCHMARKER_URL")
+	if server == "" {
+		server = "http://localhost:8080"
+	}
+	fs.StringVar(&common.server, "server", server, "URL of the benchmarker")
+	return fs, common
+}
+
+// target selects what a command operates on, exactly one of the fields is set
+type target struct {
+	run        string
+	experiment string
+	executor   string
+}
+
+func (t *target) register(fs *flag.FlagSet, withExecutor bool) {
+	fs.StringVar(&t.run, "run", "", "ID of a run")
+	fs.StringVar(&t.experiment, "experiment", "", "ID of an experiment")
+	if withExecutor {
+		fs.StringVar(&t.executor, "executor", "", "Name of an executor, selects all of its jobs")
+	}
+}
+
+func (t *target) validate(withExecutor bool) error {
+	set := 0
+	for _, v := range []string{t.run, t.experiment, t.executor} {
+		if v != "" {
+			set++
+		}
+	}
+	if set == 1 {
+		return nil
+	}
+	if withExecutor {
+		return errors.New("exactly one of --run, --experiment and --executor is required")
+	}
+	return errors.New("exactly one of --run and --experiment is required")
+}
+
+func parseID(kind string, value string) (uuid.UUID, error) {
+	id, err := uuid.Parse(value)
+	if err != nil {
+		return uuid.Nil, fmt.Errorf("invalid %s ID %q", kind, value)
+	}
+	return id, nil
+}
+
+// output opens the file given by -o, stdout if empty
+func output(path string) (io.WriteCloser, error) {
+	if path == "" {
+		return nopCloser{os.Stdout}, nil
+	}
+	return os.Create(path)
+}
+
+type nopCloser struct {
+	io.Writer
+}
+
+func (nopCloser) Close() error { return nil }
+
+// sloError turns violated SLOs into an error with exitSLOViolated
+func sloError(results []report.SLOResult) error {
+	violations := report.Violations(results)
+	if len(violations) == 0 {
+		return nil
+	}
+	return &exitErr{code: exitSLOViolated, err: fmt.Errorf("%d of %d SLOs violated", len(violations), len(results))}
+}
+
+func runCommand(args []string) error {
+	fs, common := newFlagSet("run", "--scenario <file> [--wait] [--slo <slo>]...")
+	scenarioFile := fs.String("scenario", "", "Scenario file (YAML or JSON)")
+	wait := fs.Bool("wait", false, "Wait until the run or experiment finished and check the SLOs")
+	timeout := fs.Duration("timeout", 2*time.Hour, "Maximum time to wait")
+	interval := fs.Duration("interval", 10*time.Second, "Poll interval while waiting")
+	var slos stringList
+	fs.Var(&slos, "slo", "SLO to check after waiting, e.g. total_latency.p75<=120 (repeatable)")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *scenarioFile == "" {
+		return errors.New("--scenario is required")
+	}
+	parsedSLOs, err := report.ParseSLOs(slos)
+	if err != nil {
+		return err
+	}
+	if len(parsedSLOs) > 0 && !*wait {
+		return errors.New("--slo requires --wait")
+	}
+
+	sc, err := scenario.Load(*scenarioFile)
+	if err != nil {
+		return err
+	}
+
+	c := client.New(common.server)
+	ctx := context.Background()
+	started, err := c.RunScenario(ctx, sc)
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case started.ExperimentID != nil:
+		fmt.Printf("Started experiment %s with %d runs\n", started.ExperimentID, started.RunCount)
+	case started.RunID != nil:
+		fmt.Printf("Started run %s\n", started.RunID)
+	}
+	if !*wait {
+		return nil
+	}
+
+	t := target{}
+	if started.ExperimentID != nil {
+		t.experiment = started.ExperimentID.String()
+	} else if started.RunID != nil {
+		t.run = started.RunID.String()
+	}
+	return waitFor(ctx, c, t, *timeout, *interval, parsedSLOs)
+}
+
+func statusCommand(args []string) error {
+	fs, common := newFlagSet("status", "--run <id> | --experiment <id>")
+	var t target
+	t.register(fs, false)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if err := t.validate(false); err != nil {
+		return err
+	}
+
+	c := client.New(common.server)
+	ctx := context.Background()
+	if t.run != "" {
+		id, err := parseID("run", t.run)
+		if err != nil {
+			return err
+		}
+		run, err := c.GetRun(ctx, id)
+		if err != nil {
+			return err
+		}
+		printRun(os.Stdout, run)
+		return nil
+	}
+
+	id, err := parseID("experiment", t.experiment)
+	if err != nil {
+		return err
+	}
+	experiment, err := c.GetExperiment(ctx, id)
+	if err != nil {
+		return err
+	}
+	printExperiment(os.Stdout, experiment)
+	return nil
+}
+
+func waitCommand(args []string) error {
+	fs, common := newFlagSet("wait", "--run <id> | --experiment <id> [--timeout <duration>] [--slo <slo>]...")
+	var t target
+	t.register(fs, false)
+	timeout := fs.Duration("timeout", 2*time.Hour, "Maximum time to wait")
+	interval := fs.Duration("interval", 10*time.Second, "Poll interval")
+	var slos stringList
+	fs.Var(&slos, "slo", "SLO to check once finished, e.g. total_latency.p75<=120 (repeatable)")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if err := t.validate(false); err != nil {
+		return err
+	}
+	parsedSLOs, err := report.ParseSLOs(slos)
+	if err != nil {
+		return err
+	}
+	return waitFor(context.Background(), client.New(common.server), t, *timeout, *interval, parsedSLOs)
+}
+
+// waitFor polls a run or experiment until it finished, prints its state and checks the SLOs
+func waitFor(ctx context.Context, c *client.Client, t target, timeout time.Duration, interval time.Duration, slos []report.SLO) error {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	var r report.Report
+	if t.run != "" {
+		id, err := parseID("run", t.run)
+		if err != nil {
+			return err
+		}
+		run, err := c.WaitForRun(ctx, id, interval)
+		if errors.Is(err, context.DeadlineExceeded) {
+			return &exitErr{code: exitRunFailed, err: fmt.Errorf("run %s not finished after %s", id, timeout)}
+		}
+		if err != nil {
+			return err
+		}
+		printRun(os.Stdout, run)
+		if benchmarkController.RunAborted(run.Status) {
+			return &exitErr{code: exitRunFailed, err: fmt.Errorf("run %s %s", run.ID, run.Status)}
+		}
+		r.Run = &run
+	} else {
+		id, err := parseID("experiment", t.experiment)
+		if err != nil {
+			return err
+		}
+		experiment, err := c.WaitForExperiment(ctx, id, interval)
+		if errors.Is(err, context.DeadlineExceeded) {
+			return &exitErr{code: exitRunFailed, err: fmt.Errorf("experiment %s not finished after %s", id, timeout)}
+		}
+		if err != nil {
+			return err
+		}
+		printExperiment(os.Stdout, experiment)
+		if experiment.Status != benchmarkController.ExperimentStatusFinished {
+			return &exitErr{code: exitRunFailed, err: fmt.Errorf("experiment %s %s", experiment.ID, experiment.Status)}
+		}
+		r.Experiment = &experiment
+	}
+
+	if len(slos) == 0 {
+		return nil
+	}
+	if r.Run != nil {
+		var err error
+		if r.Metrics, err = c.RunMetrics(ctx, *r.Run); err != nil {
+			return err
+		}
+	}
+	r.CheckSLOs(slos)
+	fmt.Println()
+	printSLOs(os.Stdout, r.SLOs)
+	return sloError(r.SLOs)
+}
+
+func metricsCommand(args []string) error {
+	fs, common := newFlagSet("metrics", "--executor <name> [--from <time>] [--to <time>] [--commit-hash <hash>] [--payload-hash <hash>] [--label <key=value>]... [--slo <slo>]...")
+	executor := fs.String("executor", "", "Name of the executor")
+	commitHash := fs.String("commit-hash", "", "Only jobs of this commit")
+	payloadHash := fs.String("payload-hash", "", "Only jobs submitted with the payload of this content hash")
+	labels := labelFlags{}
+	fs.Var(labels, "label", "Only jobs with this label, e.g. cluster=aks (repeatable)")
+	var from, to timeFlag
+	fs.Var(&from, "from", "Only jobs started at or after this time (RFC 3339)")
+	fs.Var(&to, "to", "Only jobs started at or before this time (RFC 3339)")
+	var slos stringList
+	fs.Var(&slos, "slo", "SLO to check, e.g. queue_latency.median<30 (repeatable)")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *executor == "" {
+		return errors.New("--executor is required")
+	}
+	parsedSLOs, err := report.ParseSLOs(slos)
+	if err != nil {
+		return err
+	}
+
+	filter := client.MetricsFilter{Executor: *executor, From: from.t, To: to.t, CommitHash: *commitHash, PayloadHash: *payloadHash, Labels: labels}
+	metrics, err := client.New(common.server).Metrics(context.Background(), filter)
+	if err != nil {
+		return err
+	}
+	printMetrics(os.Stdout, metrics)
+
+	if len(parsedSLOs) == 0 {
+		return nil
+	}
+	results := report.CheckAll(parsedSLOs, metrics, "")
+	fmt.Println()
+	printSLOs(os.Stdout, results)
+	return sloError(results)
+}
+
+func compareCommand(args []string) error {
+	fs, common := newFlagSet("compare", "--experiment <id> [--slo <slo>]...")
+	experimentID := fs.String("experiment", "", "ID of the experiment")
+	var slos stringList
+	fs.Var(&slos, "slo", "SLO every cell has to meet, e.g. build_time.median<=300 (repeatable)")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	id, err := parseID("experiment", *experimentID)
+	if err != nil {
+		return err
+	}
+	parsedSLOs, err := report.ParseSLOs(slos)
+	if err != nil {
+		return err
+	}
+
+	experiment, err := client.New(common.server).GetExperiment(context.Background(), id)
+	if err != nil {
+		return err
+	}
+	printComparison(os.Stdout, experiment.Comparison)
+
+	if len(parsedSLOs) == 0 {
+		return nil
+	}
+	r := report.Report{Experiment: &experiment}
+	r.CheckSLOs(parsedSLOs)
+	fmt.Println()
+	printSLOs(os.Stdout, r.SLOs)
+	return sloError(r.SLOs)
+}
+
+// buildReport fetches the state and metrics of the target
+func buildReport(ctx context.Context, c *client.Client, t target, title string) (report.Report, error) {
+	r := report.Report{Title: title, GeneratedAt: time.Now().UTC()}
+	switch {
+	case t.run != "":
+		id, err := parseID("run", t.run)
+		if err != nil {
+			return r, err
+		}
+		run, err := c.GetRun(ctx, id)
+		if err != nil {
+			return r, err
+		}
+		r.Run = &run
+		if r.Metrics, err = c.RunMetrics(ctx, run); err != nil {
+			return r, err
+		}
+		if r.Title == "" {
+			r.Title = "Benchmark run " + run.ID.String()
+		}
+	case t.experiment != "":
+		id, err := parseID("experiment", t.experiment)
+		if err != nil {
+			return r, err
+		}
+		experiment, err := c.GetExperiment(ctx, id)
+		if err != nil {
+			return r, err
+		}
+		r.Experiment = &experiment
+		if r.Title == "" {
+			r.Title = "Benchmark experiment " + experiment.Scenario
+		}
+	default:
+		metrics, err := c.Metrics(ctx, client.MetricsFilter{Executor: t.executor})
+		if err != nil {
+			return r, err
+		}
+		r.Metrics = metrics
+		if r.Title == "" {
+			r.Title = "Benchmark " + t.executor
+		}
+	}
+	return r, nil
+}
+
+func exportCommand(args []string) error {
+	fs, common := newFlagSet("export", "--run <id> | --experiment <id> | --executor <name> [--format json|csv] [-o <file>]")
+	var t target
+	t.register(fs, true)
+	format := fs.String("format", "json", "Output format, json or csv")
+	out := fs.String("o", "", "Output file, stdout if empty")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if err := t.validate(true); err != nil {
+		return err
+	}
+	if *format != "json" && *format != "csv" {
+		return fmt.Errorf("unknown format %q, expected json or csv", *format)
+	}
+
+	r, err := buildReport(context.Background(), client.New(common.server), t, "")
+	if err != nil {
+		return err
+	}
+	return writeReport(r, *format, *out)
+}
+
+func reportCommand(args []string) error {
+	fs, common := newFlagSet("report", "--run <id> | --experiment <id> | --executor <name> [--format markdown|html|json] [-o <file>] [--slo <slo>]...")
+	var t target
+	t.register(fs, true)
+	format := fs.String("format", "markdown", "Output format, markdown, html or json")
+	out := fs.String("o", "", "Output file, stdout if empty")
+	title := fs.String("title", "", "Title of the report")
+	var slos stringList
+	fs.Var(&slos, "slo", "SLO to check and include in the report (repeatable)")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if err := t.validate(true); err != nil {
+		return err
+	}
+	parsedSLOs, err := report.ParseSLOs(slos)
+	if err != nil {
+		return err
+	}
+
+	r, err := buildReport(context.Background(), client.New(common.server), t, *title)
+	if err != nil {
+		return err
+	}
+	r.CheckSLOs(parsedSLOs)
+	if err := writeReport(r, *format, *out); err != nil {
+		return err
+	}
+	return sloError(r.SLOs)
+}
+
+func writeReport(r report.Report, format string, path string) error {
+	w, err := output(path)
+	if err != nil {
+		return err
+	}
+	if err := r.Write(w, format); err != nil {
+		w.Close()
+		return err
+	}
+	return w.Close()
+}
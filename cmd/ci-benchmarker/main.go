@@ -0,0 +1,93 @@
+// Command ci-benchmarker drives a CI-Benchmarker server via its REST API, so benchmarks can be
+// scripted in pipelines. It exits with 2 if an SLO is violated and with 3 if a run failed or
+// did not finish in time.
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// Exit codes
+const (
+	exitOK          = 0
+	exitError       = 1
+	exitSLOViolated = 2
+	exitRunFailed   = 3
+)
+
+// exitErr carries the exit code of a failed command
+type exitErr struct {
+	code int
+	err  error
+}
+
+func (e *exitErr) Error() string {
+	return e.err.Error()
+}
+
+type command struct {
+	name    string
+	summary string
+	run     func(args []string) error
+}
+
+var commands = []command{
+	{"run", "Start a scenario, optionally wait for it and check SLOs", runCommand},
+	{"status", "Show the state of a run or an experiment", statusCommand},
+	{"wait", "Wait until a run or an experiment finished", waitCommand},
+	{"metrics", "Show the metrics of an executor", metricsCommand},
+	{"compare", "Compare the cells of an experiment", compareCommand},
+	{"export", "Export the metrics of a run, an experiment or an executor as JSON or CSV", exportCommand},
+	{"report", "Write a Markdown or HTML report of a run, an experiment or an executor", reportCommand},
+}
+
+func main() {
+	os.Exit(run(os.Args[1:]))
+}
+
+func run(args []string) int {
+	if len(args) == 0 || args[0] == "-h" || args[0] == "--help" || args[0] == "help" {
+		usage()
+		return exitOK
+	}
+
+	for _, cmd := range commands {
+		if cmd.name != args[0] {
+			continue
+		}
+		err := cmd.run(args[1:])
+		if err == nil {
+			return exitOK
+		}
+		if errors.Is(err, flag.ErrHelp) {
+			return exitOK
+		}
+		var e *exitErr
+		if errors.As(err, &e) {
+			fmt.Fprintln(os.Stderr, "Error:", e.err)
+			return e.code
+		}
+		fmt.Fprintln(os.Stderr, "Error:", err)
+		return exitError
+	}
+
+	fmt.Fprintf(os.Stderr, "Unknown command %q\n\n", args[0])
+	usage()
+	return exitError
+}
+
+func usage() {
+	var b strings.Builder
+	b.WriteString("Usage: ci-benchmarker <command> [flags]\n\nCommands:\n")
+	for _, cmd := range commands {
+		fmt.Fprintf(&b, "  %-8s %s\n", cmd.name, cmd.summary)
+	}
+	b.WriteString("\nRun 'ci-benchmarker <command> -h' for the flags of a command.\n")
+	b.WriteString("The server defaults to $CI_BENCHMARKER_URL or http://localhost:8080.\n")
+	b.WriteString("\nExit codes: 0 success, 1 error, 2 SLO violated, 3 run failed or timed out\n")
+	fmt.Fprint(os.Stderr, b.String())
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"text/tabwriter"
+	"time"
+
+	"github.com/Mtze/CI-Benchmarker/MetricsController"
+	"github.com/Mtze/CI-Benchmarker/benchmarkController"
+	"github.com/Mtze/CI-Benchmarker/report"
+)
+
+func newTable(w io.Writer) *tabwriter.Writer {
+	return tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+}
+
+func printRun(w io.Writer, run benchmarkController.RunSummary) {
+	tw := newTable(w)
+	fmt.Fprintf(tw, "Run\t%s\n", run.ID)
+	fmt.Fprintf(tw, "Executor\t%s\n", run.Executor)
+	if run.Scenario != "" {
+		fmt.Fprintf(tw, "Scenario\t%s\n", run.Scenario)
+	}
+	fmt.Fprintf(tw, "Status\t%s\n", run.Status)
+	if run.Error != "" {
+		fmt.Fprintf(tw, "Error\t%s\n", run.Error)
+	}
+	fmt.Fprintf(tw, "Created\t%s\n", run.CreationTime.UTC().Format(time.RFC3339))
+	fmt.Fprintf(tw, "Jobs\t%d scheduled of %d, %d finished, %d cancelled\n", run.ScheduledJobs, run.JobCount, run.FinishedJobs, run.CancelledJobs)
+	fmt.Fprintf(tw, "Submission\t%d attempts, %d retried, %d failed jobs\n", run.Submission.Attempts, run.Submission.RetriedJobs, run.Submission.FailedJobs)
+	tw.Flush()
+}
+
+func printExperiment(w io.Writer, experiment benchmarkController.ExperimentSummary) {
+	tw := newTable(w)
+	fmt.Fprintf(tw, "Experiment\t%s\n", experiment.ID)
+	fmt.Fprintf(tw, "Scenario\t%s\n", experiment.Scenario)
+	fmt.Fprintf(tw, "Status\t%s\n", experiment.Status)
+	if experiment.Error != "" {
+		fmt.Fprintf(tw, "Error\t%s\n", experiment.Error)
+	}
+	fmt.Fprintf(tw, "Order\t%s (seed %d)\n", experiment.Order, experiment.Seed)
+	fmt.Fprintf(tw, "Runs\t%d of %d started\n", len(experiment.Runs), experiment.RunCount)
+	tw.Flush()
+
+	if len(experiment.Runs) == 0 {
+		return
+	}
+	fmt.Fprintln(w)
+	tw = newTable(w)
+	fmt.Fprintln(tw, "RUN\tEXECUTOR\tPAYLOAD\tCONCURRENCY\tREPETITION\tSTATUS")
+	for _, run := range experiment.Runs {
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\n", run.RunID, run.Executor, run.Payload, run.Concurrency, run.Repetition, run.Status)
+	}
+	tw.Flush()
+}
+
+const metricsHeader = "JOBS\tAVG\tMEDIAN\tQ25\tQ75\tMIN\tMAX"
+
+func metricsColumns(s *MetricsController.MetricSummary) string {
+	if s == nil {
+		return "0\t-\t-\t-\t-\t-\t-"
+	}
+	return fmt.Sprintf("%d\t%d\t%d\t%d\t%d\t%d\t%d", s.TotalJobs, s.Average, s.Median, s.Q25, s.Q75, s.Min, s.Max)
+}
+
+func printMetrics(w io.Writer, metrics map[string]*MetricsController.MetricSummary) {
+	tw := newTable(w)
+	fmt.Fprintln(tw, "METRIC (s)\t"+metricsHeader)
+	for _, metric := range report.Metrics {
+		fmt.Fprintf(tw, "%s\t%s\n", metric, metricsColumns(metrics[metric]))
+	}
+	tw.Flush()
+}
+
+func printComparison(w io.Writer, cells []benchmarkController.CellComparison) {
+	tw := newTable(w)
+	fmt.Fprintln(tw, "EXECUTOR\tPAYLOAD\tCONCURRENCY\tRUNS\tMETRIC (s)\t"+metricsHeader)
+	for _, cell := range cells {
+		metrics := report.CellMetrics(cell)
+		for _, metric := range report.Metrics {
+			fmt.Fprintf(tw, "%s\t%s\t%s\t%d\t%s\t%s\n", cell.Executor, cell.Payload, cell.Concurrency, cell.Runs, metric, metricsColumns(metrics[metric]))
+		}
+	}
+	tw.Flush()
+}
+
+func printSLOs(w io.Writer, results []report.SLOResult) {
+	for _, r := range results {
+		fmt.Fprintln(w, report.FormatResult(r))
+	}
+}
@@ -24,6 +24,173 @@ const docTemplate = `{
     "host": "{{.Host}}",
     "basePath": "{{.BasePath}}",
     "paths": {
+        "/admin/backup": {
+            "get": {
+                "security": [
+                    {
+                        "AdminToken": []
+                    }
+                ],
+                "description": "Streams a consistent snapshot of the SQLite database, taken with the online backup API of SQLite. Requests are delayed while the snapshot is taken.\nPostgreSQL databases are backed up with pg_dump instead.",
+                "produces": [
+                    "application/vnd.sqlite3"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Download a database backup",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "file"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/response.ErrorMessage"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/response.ErrorMessage"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/response.ServerErrorMessage"
+                        }
+                    },
+                    "501": {
+                        "description": "Not Implemented",
+                        "schema": {
+                            "$ref": "#/definitions/response.ErrorMessage"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/export": {
+            "get": {
+                "security": [
+                    {
+                        "AdminToken": []
+                    }
+                ],
+                "description": "Streams all jobs with their labels and times and all runs with their submission attempts as gzip compressed JSONL, one {\"job\": ...} or {\"run\": ...} object per line. Archives of pruned jobs have the same format.",
+                "produces": [
+                    "application/gzip"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Export all jobs and runs",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "file"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/response.ErrorMessage"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/response.ErrorMessage"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/import": {
+            "post": {
+                "security": [
+                    {
+                        "AdminToken": []
+                    }
+                ],
+                "description": "Imports the JSONL export of another benchmarker instance or an archive of pruned jobs, gzip compressed or not. The jobs and runs are labelled with origin, unless they are labelled with an origin already, so they can be told apart in the metrics, e.g. with group_by=label.origin.\nJobs and runs whose ID is stored already are skipped, or replaced with on_conflict=replace. The records are imported in batches, for an invalid record the batches before it are kept and importing again skips them.",
+                "consumes": [
+                    "application/x-ndjson",
+                    "application/gzip"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Import jobs and runs",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Origin the imported jobs and runs are labelled with, e.g. the name of the instance",
+                        "name": "origin",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "enum": [
+                            "skip",
+                            "replace"
+                        ],
+                        "type": "string",
+                        "description": "Whether stored jobs and runs are skipped or replaced, defaults to skip",
+                        "name": "on_conflict",
+                        "in": "query"
+                    },
+                    {
+                        "description": "Exported jobs and runs (JSONL)",
+                        "name": "data",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/benchmarkController.ImportResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/benchmarkController.ImportErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/response.ErrorMessage"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/response.ErrorMessage"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/response.ServerErrorMessage"
+                        }
+                    }
+                }
+            }
+        },
         "/benchmark/build_time/histogram": {
             "get": {
                 "description": "Returns a PNG histogram showing distribution of build time (seconds).",
@@ -52,6 +219,25 @@ const docTemplate = `{
                         "description": "Optional commit hash filter",
                         "name": "commit_hash",
                         "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Optional payload content hash filter",
+                        "name": "payload_hash",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Optional label filter, e.g. label.cluster=aks (repeatable with different keys)",
+                        "name": "label.key",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "executor filter",
+                        "name": "executor",
+                        "in": "query",
+                        "required": true
                     }
                 ],
                 "responses": {
@@ -104,11 +290,35 @@ const docTemplate = `{
                         "description": "Optional commit hash filter",
                         "name": "commit_hash",
                         "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Optional payload content hash filter",
+                        "name": "payload_hash",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Optional label filter, e.g. label.cluster=aks (repeatable with different keys)",
+                        "name": "label.key",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "executor filter, required unless group_by=executor",
+                        "name": "executor",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Optional grouping: executor, commit_hash, run, hour, day or label.\u003ckey\u003e",
+                        "name": "group_by",
+                        "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "OK",
+                        "description": "Summary, or a map of group to summary if group_by is set",
                         "schema": {
                             "$ref": "#/definitions/MetricsController.MetricSummary"
                         }
@@ -162,6 +372,25 @@ const docTemplate = `{
                         "description": "Optional commit hash filter",
                         "name": "commit_hash",
                         "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Optional payload content hash filter",
+                        "name": "payload_hash",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Optional label filter, e.g. label.cluster=aks (repeatable with different keys)",
+                        "name": "label.key",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "executor filter",
+                        "name": "executor",
+                        "in": "query",
+                        "required": true
                     }
                 ],
                 "responses": {
@@ -214,11 +443,35 @@ const docTemplate = `{
                         "description": "Optional commit hash filter",
                         "name": "commit_hash",
                         "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Optional payload content hash filter",
+                        "name": "payload_hash",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Optional label filter, e.g. label.cluster=aks (repeatable with different keys)",
+                        "name": "label.key",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "executor filter, required unless group_by=executor",
+                        "name": "executor",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Optional grouping: executor, commit_hash, run, hour, day or label.\u003ckey\u003e",
+                        "name": "group_by",
+                        "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "OK",
+                        "description": "Summary, or a map of group to summary if group_by is set",
                         "schema": {
                             "$ref": "#/definitions/MetricsController.MetricSummary"
                         }
@@ -272,6 +525,25 @@ const docTemplate = `{
                         "description": "Optional commit hash filter",
                         "name": "commit_hash",
                         "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Optional payload content hash filter",
+                        "name": "payload_hash",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Optional label filter, e.g. label.cluster=aks (repeatable with different keys)",
+                        "name": "label.key",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "executor filter",
+                        "name": "executor",
+                        "in": "query",
+                        "required": true
                     }
                 ],
                 "responses": {
@@ -324,11 +596,35 @@ const docTemplate = `{
                         "description": "Optional commit hash filter",
                         "name": "commit_hash",
                         "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Optional payload content hash filter",
+                        "name": "payload_hash",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Optional label filter, e.g. label.cluster=aks (repeatable with different keys)",
+                        "name": "label.key",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "executor filter, required unless group_by=executor",
+                        "name": "executor",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Optional grouping: executor, commit_hash, run, hour, day or label.\u003ckey\u003e",
+                        "name": "group_by",
+                        "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "OK",
+                        "description": "Summary, or a map of group to summary if group_by is set",
                         "schema": {
                             "$ref": "#/definitions/MetricsController.MetricSummary"
                         }
@@ -354,35 +650,30 @@ const docTemplate = `{
                 }
             }
         },
-        "/result": {
-            "post": {
-                "description": "This endpoint handles the result of a job, The last container in the pipeline should send the result to this endpoint",
-                "consumes": [
-                    "application/json"
-                ],
+        "/experiments/{id}": {
+            "get": {
+                "description": "Returns the state of an experiment, its runs and the queue latency, build time and total latency of every combination of executor, payload and concurrency over all repetitions.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "result"
+                    "experiments"
                 ],
-                "summary": "Receive job result",
+                "summary": "Get an experiment",
                 "parameters": [
                     {
-                        "description": "Job Result Metadata",
-                        "name": "resultMetadata",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/main.ResultMetadata"
-                        }
+                        "type": "string",
+                        "description": "Experiment ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/response.SimpleMessage"
+                            "$ref": "#/definitions/benchmarkController.ExperimentSummary"
                         }
                     },
                     "400": {
@@ -390,39 +681,46 @@ const docTemplate = `{
                         "schema": {
                             "$ref": "#/definitions/response.ErrorMessage"
                         }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/response.NotFoundMessage"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/response.ServerErrorMessage"
+                        }
                     }
                 }
             }
         },
-        "/start_time": {
+        "/experiments/{id}/cancel": {
             "post": {
-                "description": "Submit job start time for benchmarking",
-                "consumes": [
-                    "application/json"
-                ],
+                "description": "Stops an experiment before its next run. A run of the experiment which is still submitting jobs is cancelled like with POST /runs/{id}/cancel, the runs which submitted all of their jobs are kept.\nThe experiment ends with the status cancelled.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "start_time"
+                    "experiments"
                 ],
-                "summary": "Receive build start time",
+                "summary": "Cancel an experiment",
                 "parameters": [
                     {
-                        "description": "Build Start Time",
-                        "name": "jobStartTime",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/main.JobStartTime"
-                        }
+                        "type": "string",
+                        "description": "Experiment ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
                     }
                 ],
                 "responses": {
-                    "200": {
-                        "description": "OK",
+                    "202": {
+                        "description": "Accepted",
                         "schema": {
-                            "$ref": "#/definitions/response.SimpleMessage"
+                            "$ref": "#/definitions/benchmarkController.CancelExperimentResponse"
                         }
                     },
                     "400": {
@@ -430,15 +728,669 @@ const docTemplate = `{
                         "schema": {
                             "$ref": "#/definitions/response.ErrorMessage"
                         }
-                    }
-                }
-            }
-        }
-    },
-    "definitions": {
-        "MetricsController.MetricSummary": {
-            "description": "Percentile and descriptive statistics for a metric (latency / build time).",
-            "type": "object",
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/response.NotFoundMessage"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/response.ErrorMessage"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/response.ServerErrorMessage"
+                        }
+                    }
+                }
+            }
+        },
+        "/jobs": {
+            "delete": {
+                "security": [
+                    {
+                        "AdminToken": []
+                    }
+                ],
+                "description": "Deletes the jobs created before the given time, optionally only those of an executor, with their times. Runs which are left without jobs and are not running any more are deleted as well.\nThe deleted jobs and runs are written to a gzip compressed JSONL archive in the archive directory of the server first.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "jobs"
+                ],
+                "summary": "Delete jobs",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Delete the jobs created before this time (RFC 3339)",
+                        "name": "before",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only delete the jobs of this executor",
+                        "name": "executor",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/benchmarkController.PruneResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/response.ErrorMessage"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/response.ErrorMessage"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/response.ErrorMessage"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/response.ServerErrorMessage"
+                        }
+                    }
+                }
+            }
+        },
+        "/orphan_callbacks": {
+            "get": {
+                "description": "Returns the latest start time and result callbacks which were received for jobs that are not scheduled.\nTheir times are not part of any metric. Callbacks which arrive before their job is stored are applied once it is.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "callbacks"
+                ],
+                "summary": "List orphan callbacks",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Maximum number of callbacks, defaults to 100",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/benchmarkController.OrphanCallbackResponse"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/response.ErrorMessage"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/response.ServerErrorMessage"
+                        }
+                    }
+                }
+            }
+        },
+        "/payloads": {
+            "get": {
+                "description": "Returns the latest version of every payload of the payload library without its content.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "payloads"
+                ],
+                "summary": "List payloads",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/benchmarkController.PayloadVersionResponse"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/response.ServerErrorMessage"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Stores a payload in the payload library as version 1. Benchmark requests reference it with payload_id.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "payloads"
+                ],
+                "summary": "Create a payload",
+                "parameters": [
+                    {
+                        "description": "Payload",
+                        "name": "payload",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/benchmarkController.PayloadRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/benchmarkController.PayloadVersionResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/response.ErrorMessage"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/response.ServerErrorMessage"
+                        }
+                    }
+                }
+            }
+        },
+        "/payloads/{id}": {
+            "get": {
+                "description": "Returns the latest or the given version of a payload of the payload library.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "payloads"
+                ],
+                "summary": "Get a payload",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Payload ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Version, defaults to the latest one",
+                        "name": "version",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/benchmarkController.PayloadVersionResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/response.ErrorMessage"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/response.NotFoundMessage"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/response.ServerErrorMessage"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "description": "Stores a new version of a payload of the payload library. If neither the name nor the content changed, the latest version is returned and no version is added.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "payloads"
+                ],
+                "summary": "Update a payload",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Payload ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Payload",
+                        "name": "payload",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/benchmarkController.PayloadRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/benchmarkController.PayloadVersionResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/response.ErrorMessage"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/response.NotFoundMessage"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/response.ServerErrorMessage"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "Removes a payload from the payload library. Its versions are kept for the jobs which ran with them, but can no longer be referenced.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "payloads"
+                ],
+                "summary": "Delete a payload",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Payload ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/response.SimpleMessage"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/response.ErrorMessage"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/response.NotFoundMessage"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/response.ServerErrorMessage"
+                        }
+                    }
+                }
+            }
+        },
+        "/payloads/{id}/versions": {
+            "get": {
+                "description": "Returns all versions of a payload of the payload library without their content.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "payloads"
+                ],
+                "summary": "List the versions of a payload",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Payload ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/benchmarkController.PayloadVersionResponse"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/response.ErrorMessage"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/response.NotFoundMessage"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/response.ServerErrorMessage"
+                        }
+                    }
+                }
+            }
+        },
+        "/result": {
+            "post": {
+                "description": "This endpoint handles the result of a job, The last container in the pipeline should send the result to this endpoint",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "result"
+                ],
+                "summary": "Receive job result",
+                "parameters": [
+                    {
+                        "description": "Job Result Metadata",
+                        "name": "resultMetadata",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/main.ResultMetadata"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/response.SimpleMessage"
+                        }
+                    },
+                    "202": {
+                        "description": "Unknown job, kept as orphan callback",
+                        "schema": {
+                            "$ref": "#/definitions/response.SimpleMessage"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/response.ErrorMessage"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/response.ServerErrorMessage"
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/response.ServerErrorMessage"
+                        }
+                    }
+                }
+            }
+        },
+        "/runs/{id}": {
+            "get": {
+                "description": "Returns the state of a benchmark run and the progress of its jobs.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "runs"
+                ],
+                "summary": "Get a benchmark run",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Run ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/benchmarkController.RunSummary"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/response.ErrorMessage"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/response.NotFoundMessage"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/response.ServerErrorMessage"
+                        }
+                    }
+                }
+            }
+        },
+        "/runs/{id}/cancel": {
+            "post": {
+                "description": "Stops scheduling further jobs of the run and marks all unfinished jobs as cancelled, which excludes them from all metrics.\nThe CI system is asked to cancel the jobs which are already submitted in the background.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "runs"
+                ],
+                "summary": "Cancel a benchmark run",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Run ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "202": {
+                        "description": "Accepted",
+                        "schema": {
+                            "$ref": "#/definitions/benchmarkController.CancelRunResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/response.ErrorMessage"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/response.NotFoundMessage"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/response.ServerErrorMessage"
+                        }
+                    }
+                }
+            }
+        },
+        "/scenarios/run": {
+            "post": {
+                "description": "Starts a benchmark run described by a YAML or JSON scenario, i.e. the targeted executor, the payload (inline or as payload_file relative to the scenario directory of the server), the load profile or a trace of recorded submissions to replay, labels and warm-up jobs. The run can be followed with GET /runs/{id}. A scenario with a matrix starts an experiment instead, which can be followed with GET /experiments/{id}.",
+                "consumes": [
+                    "application/json",
+                    "application/yaml"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "scenarios"
+                ],
+                "summary": "Run a benchmark scenario",
+                "parameters": [
+                    {
+                        "description": "Scenario (YAML or JSON)",
+                        "name": "scenario",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                ],
+                "responses": {
+                    "202": {
+                        "description": "Accepted",
+                        "schema": {
+                            "$ref": "#/definitions/benchmarkController.ScenarioStartedResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/response.ErrorMessage"
+                        }
+                    }
+                }
+            }
+        },
+        "/start_time": {
+            "post": {
+                "description": "Submit job start time for benchmarking",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "start_time"
+                ],
+                "summary": "Receive build start time",
+                "parameters": [
+                    {
+                        "description": "Build Start Time",
+                        "name": "jobStartTime",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/main.JobStartTime"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/response.SimpleMessage"
+                        }
+                    },
+                    "202": {
+                        "description": "Unknown job, kept as orphan callback",
+                        "schema": {
+                            "$ref": "#/definitions/response.SimpleMessage"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/response.ErrorMessage"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/response.ServerErrorMessage"
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/response.ServerErrorMessage"
+                        }
+                    }
+                }
+            }
+        }
+    },
+    "definitions": {
+        "MetricsController.MetricSummary": {
+            "description": "Percentile and descriptive statistics for a metric (latency / build time).",
+            "type": "object",
             "properties": {
                 "average": {
                     "type": "integer",
@@ -474,6 +1426,415 @@ const docTemplate = `{
                 }
             }
         },
+        "benchmarkController.CancelExperimentResponse": {
+            "description": "Result of cancelling an experiment, its remaining runs are stopped in the background.",
+            "type": "object",
+            "properties": {
+                "experiment_id": {
+                    "type": "string",
+                    "example": "8d0f6a52-44d5-4c6f-a7a3-1f0b9a1c7e21"
+                },
+                "message": {
+                    "type": "string",
+                    "example": "Experiment cancelled"
+                }
+            }
+        },
+        "benchmarkController.CancelRunResponse": {
+            "description": "Result of cancelling a benchmark run, the CI system is asked to cancel the jobs in the background.",
+            "type": "object",
+            "properties": {
+                "cancelled_jobs": {
+                    "type": "integer",
+                    "example": 58
+                },
+                "message": {
+                    "type": "string",
+                    "example": "Run cancelled"
+                },
+                "run_id": {
+                    "type": "string",
+                    "example": "3b0bce02-43c6-4e66-8810-b7a0ac5b3a08"
+                }
+            }
+        },
+        "benchmarkController.CellComparison": {
+            "description": "Metrics of all jobs of a cell of an experiment, missing if no job of the cell reported the metric.",
+            "type": "object",
+            "properties": {
+                "build_time": {
+                    "$ref": "#/definitions/MetricsController.MetricSummary"
+                },
+                "concurrency": {
+                    "type": "string",
+                    "example": "10"
+                },
+                "executor": {
+                    "type": "string",
+                    "example": "docker"
+                },
+                "payload": {
+                    "type": "string",
+                    "example": "gradle"
+                },
+                "queue_latency": {
+                    "$ref": "#/definitions/MetricsController.MetricSummary"
+                },
+                "runs": {
+                    "type": "integer",
+                    "example": 3
+                },
+                "total_latency": {
+                    "$ref": "#/definitions/MetricsController.MetricSummary"
+                }
+            }
+        },
+        "benchmarkController.ExperimentRun": {
+            "description": "Run of an experiment and the cell of the matrix it belongs to.",
+            "type": "object",
+            "properties": {
+                "concurrency": {
+                    "type": "string",
+                    "example": "10"
+                },
+                "executor": {
+                    "type": "string",
+                    "example": "docker"
+                },
+                "payload": {
+                    "type": "string",
+                    "example": "gradle"
+                },
+                "repetition": {
+                    "type": "string",
+                    "example": "1"
+                },
+                "run_id": {
+                    "type": "string",
+                    "example": "3b0bce02-43c6-4e66-8810-b7a0ac5b3a08"
+                },
+                "status": {
+                    "type": "string",
+                    "example": "submitted"
+                }
+            }
+        },
+        "benchmarkController.ExperimentSummary": {
+            "description": "State of an experiment, its runs in execution order and the metrics per executor, payload and concurrency (seconds).",
+            "type": "object",
+            "properties": {
+                "comparison": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/benchmarkController.CellComparison"
+                    }
+                },
+                "creation_time": {
+                    "type": "string"
+                },
+                "error": {
+                    "type": "string"
+                },
+                "finish_time": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string",
+                    "example": "8d0f6a52-44d5-4c6f-a7a3-1f0b9a1c7e21"
+                },
+                "order": {
+                    "type": "string",
+                    "example": "randomized"
+                },
+                "run_count": {
+                    "type": "integer",
+                    "example": 12
+                },
+                "runs": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/benchmarkController.ExperimentRun"
+                    }
+                },
+                "scenario": {
+                    "type": "string",
+                    "example": "docker-vs-kubernetes"
+                },
+                "seed": {
+                    "type": "integer",
+                    "example": 42
+                },
+                "status": {
+                    "type": "string",
+                    "example": "finished"
+                }
+            }
+        },
+        "benchmarkController.ImportErrorResponse": {
+            "description": "Invalid import with the jobs and runs imported before the invalid record.",
+            "type": "object",
+            "properties": {
+                "error": {
+                    "type": "string",
+                    "example": "invalid import: record 17: expected either a job or a run"
+                },
+                "imported": {
+                    "$ref": "#/definitions/benchmarkController.ImportResponse"
+                }
+            }
+        },
+        "benchmarkController.ImportResponse": {
+            "description": "Jobs and runs imported from another benchmarker instance.",
+            "type": "object",
+            "properties": {
+                "jobs": {
+                    "type": "integer",
+                    "example": 1200
+                },
+                "replaced_jobs": {
+                    "type": "integer",
+                    "example": 0
+                },
+                "replaced_runs": {
+                    "type": "integer",
+                    "example": 0
+                },
+                "runs": {
+                    "type": "integer",
+                    "example": 4
+                },
+                "skipped_jobs": {
+                    "type": "integer",
+                    "example": 3
+                },
+                "skipped_runs": {
+                    "type": "integer",
+                    "example": 0
+                }
+            }
+        },
+        "benchmarkController.OrphanCallbackResponse": {
+            "description": "Callback received for an unknown job.",
+            "type": "object",
+            "properties": {
+                "callback": {
+                    "type": "string",
+                    "enum": [
+                        "start_time",
+                        "result"
+                    ],
+                    "example": "start_time"
+                },
+                "id": {
+                    "type": "integer",
+                    "example": 17
+                },
+                "job_id": {
+                    "type": "string",
+                    "example": "3b0bce02-43c6-4e66-8810-b7a0ac5b3a08"
+                },
+                "received_at": {
+                    "type": "string"
+                },
+                "reported_time": {
+                    "type": "string"
+                }
+            }
+        },
+        "benchmarkController.PayloadRequest": {
+            "description": "Name and Hades payload of a payload of the payload library.",
+            "type": "object",
+            "required": [
+                "name",
+                "payload"
+            ],
+            "properties": {
+                "name": {
+                    "type": "string",
+                    "example": "gradle-java17"
+                },
+                "payload": {
+                    "type": "object"
+                }
+            }
+        },
+        "benchmarkController.PayloadVersionResponse": {
+            "description": "Version of a payload of the payload library.",
+            "type": "object",
+            "properties": {
+                "content_hash": {
+                    "type": "string",
+                    "example": "9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08"
+                },
+                "creation_time": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string",
+                    "example": "5c1e7a4e-8f0b-4d2a-9a47-0d5f3c0e6b11"
+                },
+                "name": {
+                    "type": "string",
+                    "example": "gradle-java17"
+                },
+                "payload": {
+                    "type": "object"
+                },
+                "version": {
+                    "type": "integer",
+                    "example": 3
+                }
+            }
+        },
+        "benchmarkController.PruneResponse": {
+            "description": "Jobs and runs deleted from the database.",
+            "type": "object",
+            "properties": {
+                "archive": {
+                    "type": "string",
+                    "example": "archive/pruned-20250204T110000Z-1234567.jsonl.gz"
+                },
+                "jobs": {
+                    "type": "integer",
+                    "example": 1200
+                },
+                "runs": {
+                    "type": "integer",
+                    "example": 3
+                }
+            }
+        },
+        "benchmarkController.RunSummary": {
+            "description": "State of a benchmark run and the number of its jobs per state.",
+            "type": "object",
+            "properties": {
+                "cancelled_jobs": {
+                    "type": "integer",
+                    "example": 0
+                },
+                "concurrency": {
+                    "type": "integer",
+                    "example": 20
+                },
+                "creation_time": {
+                    "type": "string"
+                },
+                "duplicate_callbacks": {
+                    "description": "DuplicateCallbacks counts the callbacks which reported a time of a job again",
+                    "type": "integer",
+                    "example": 3
+                },
+                "error": {
+                    "type": "string"
+                },
+                "executor": {
+                    "type": "string",
+                    "example": "HadesDockerExecutor"
+                },
+                "finish_time": {
+                    "type": "string"
+                },
+                "finished_jobs": {
+                    "type": "integer",
+                    "example": 42
+                },
+                "id": {
+                    "type": "string",
+                    "example": "3b0bce02-43c6-4e66-8810-b7a0ac5b3a08"
+                },
+                "invalid_jobs": {
+                    "description": "InvalidJobs finished before they started according to their callbacks, their build time is left out",
+                    "type": "integer",
+                    "example": 0
+                },
+                "job_count": {
+                    "type": "integer",
+                    "example": 100
+                },
+                "labels": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "string"
+                    }
+                },
+                "scenario": {
+                    "type": "string",
+                    "example": "hades-docker-gradle"
+                },
+                "scheduled_jobs": {
+                    "type": "integer",
+                    "example": 100
+                },
+                "status": {
+                    "type": "string",
+                    "example": "submitted"
+                },
+                "submission": {
+                    "$ref": "#/definitions/benchmarkController.SubmissionSummary"
+                }
+            }
+        },
+        "benchmarkController.ScenarioStartedResponse": {
+            "description": "Run or experiment started from a scenario.",
+            "type": "object",
+            "properties": {
+                "experiment_id": {
+                    "type": "string",
+                    "example": "8d0f6a52-44d5-4c6f-a7a3-1f0b9a1c7e21"
+                },
+                "message": {
+                    "type": "string",
+                    "example": "Scenario started"
+                },
+                "run_count": {
+                    "type": "integer",
+                    "example": 12
+                },
+                "run_id": {
+                    "type": "string",
+                    "example": "3b0bce02-43c6-4e66-8810-b7a0ac5b3a08"
+                },
+                "scenario": {
+                    "type": "string",
+                    "example": "hades-docker-gradle"
+                }
+            }
+        },
+        "benchmarkController.SubmissionSummary": {
+            "description": "Submission attempts, submit latency and client-side submit delay of the jobs of a run (milliseconds).",
+            "type": "object",
+            "properties": {
+                "attempts": {
+                    "type": "integer",
+                    "example": 104
+                },
+                "avg_submit_delay_ms": {
+                    "description": "Submit delay is the time a job waited on the client side before its first attempt",
+                    "type": "integer",
+                    "example": 950
+                },
+                "avg_submit_latency_ms": {
+                    "type": "integer",
+                    "example": 120
+                },
+                "failed_jobs": {
+                    "type": "integer",
+                    "example": 0
+                },
+                "max_submit_delay_ms": {
+                    "type": "integer",
+                    "example": 2100
+                },
+                "max_submit_latency_ms": {
+                    "type": "integer",
+                    "example": 1830
+                },
+                "retried_jobs": {
+                    "type": "integer",
+                    "example": 4
+                }
+            }
+        },
         "main.JobStartTime": {
             "type": "object",
             "properties": {
@@ -547,6 +1908,14 @@ const docTemplate = `{
                 }
             }
         }
+    },
+    "securityDefinitions": {
+        "AdminToken": {
+            "description": "Admin endpoints require \"Bearer \u003cADMIN_TOKEN\u003e\", they are disabled if ADMIN_TOKEN is not set",
+            "type": "apiKey",
+            "name": "Authorization",
+            "in": "header"
+        }
     }
 }`
 
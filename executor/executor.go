@@ -1,6 +1,11 @@
 package executor
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
 	"github.com/google/uuid"
 	"github.com/ls1intum/hades/shared/payload"
 )
@@ -9,8 +14,58 @@ import (
 // We assume that each executor returns a UUID which can be used to track the job
 type Executor interface {
 	// For now we only assume a single method to execute the job - This may be expanded in the future to actually pass in the job to execute
-	Execute(payload.RESTPayload) (uuid.UUID, error)
+	// The context bounds the submission, a cancelled context aborts the request to the CI system
+	Execute(ctx context.Context, jobPayload payload.RESTPayload) (uuid.UUID, error)
 
 	// Add a method to get the name of the executor
 	Name() string
 }
+
+// StatusError is returned by an executor if the CI system answered with an unexpected status code
+type StatusError struct {
+	Executor   string
+	StatusCode int
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("%s returned unexpected status code: %d", e.Executor, e.StatusCode)
+}
+
+// ErrJobNotFound is returned by StatusReporter and Canceller if the CI system does not know the job,
+// e.g. because the job was deleted in the meantime
+var ErrJobNotFound = errors.New("job not found by the CI system")
+
+// JobState describes the lifecycle state of a job as reported by the CI system
+type JobState string
+
+const (
+	JobStateQueued    JobState = "queued"
+	JobStateRunning   JobState = "running"
+	JobStateSucceeded JobState = "succeeded"
+	JobStateFailed    JobState = "failed"
+	JobStateCancelled JobState = "cancelled"
+	JobStateUnknown   JobState = "unknown"
+)
+
+// IsTerminal reports whether the job will not change its state anymore
+func (s JobState) IsTerminal() bool {
+	return s == JobStateSucceeded || s == JobStateFailed || s == JobStateCancelled
+}
+
+// JobTimestamps holds the start and end time of a job as far as they are known to the CI system
+type JobTimestamps struct {
+	StartTime *time.Time
+	EndTime   *time.Time
+}
+
+// StatusReporter is an optional interface for executors that can query the state of a job they scheduled.
+// It allows to benchmark pipelines that do not report their start and result times themselves.
+type StatusReporter interface {
+	Status(ctx context.Context, jobID uuid.UUID) (JobState, JobTimestamps, error)
+}
+
+// Canceller is an optional interface for executors that can abort a job they scheduled,
+// regardless of whether it is still queued or already running.
+type Canceller interface {
+	Cancel(ctx context.Context, jobID uuid.UUID) error
+}
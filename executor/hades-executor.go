@@ -2,6 +2,7 @@ package executor
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"log/slog"
@@ -22,13 +23,16 @@ const (
 	Kubernetes ExecutorType = "Kubernetes"
 )
 
-// HadesExecutor is the executor for Hades
+// HadesExecutor is the executor for Hades. Hades only provides an endpoint to schedule jobs, so it implements
+// neither StatusReporter nor Canceller. The times of its jobs are reported by the reporter steps of the payload
+// and cancelling a run stops the submission of further jobs, the submitted ones keep running.
 type HadesExecutor struct {
 	executorType ExecutorType
 	HadesURL     string
+	Client       *http.Client
 }
 
-func (e *HadesExecutor) Execute(jobPayload payload.RESTPayload) (uuid.UUID, error) {
+func (e *HadesExecutor) Execute(ctx context.Context, jobPayload payload.RESTPayload) (uuid.UUID, error) {
 	slog.Debug("Executing HadesExecutor")
 
 	jobPayloadBytes, err := json.Marshal(jobPayload)
@@ -38,16 +42,23 @@ func (e *HadesExecutor) Execute(jobPayload payload.RESTPayload) (uuid.UUID, erro
 	}
 
 	// schedule job - send the http post request to hades
-	resp, err := http.Post(e.HadesURL, "application/json", bytes.NewBufferString(string(jobPayloadBytes)))
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, e.HadesURL, bytes.NewReader(jobPayloadBytes))
+	if err != nil {
+		slog.Debug("Error while creating POST request to Hades")
+		return uuid.UUID{}, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := e.Client.Do(req)
 	if err != nil {
 		slog.Debug("Error while sending POST request to Hades")
 		return uuid.UUID{}, err
 	}
+	defer resp.Body.Close()
 	if resp.StatusCode != http.StatusOK {
 		slog.Debug(fmt.Sprintf("HadesExecutor returned status code %d", resp.StatusCode))
-		return uuid.UUID{}, fmt.Errorf("HadesExecutor returned non-200 status code: %d", resp.StatusCode)
+		return uuid.UUID{}, &StatusError{Executor: e.Name(), StatusCode: resp.StatusCode}
 	}
-	defer resp.Body.Close()
 	slog.Debug("HadesExecutor response", slog.Any("response", resp))
 
 	// Read the response body
@@ -73,15 +84,21 @@ func (e *HadesExecutor) Execute(jobPayload payload.RESTPayload) (uuid.UUID, erro
 	return jobID, nil
 }
 
-func NewHadesExecutor(hadesURL string, executorType ExecutorType) *HadesExecutor {
+// NewHadesExecutor creates an executor submitting jobs to the Hades build endpoint at hadesURL.
+// If client is nil, a client with the default timeouts is used.
+func NewHadesExecutor(hadesURL string, executorType ExecutorType, client *http.Client) *HadesExecutor {
 	slog.Info("Creating new HadesExecutor")
 	if executorType != Docker && executorType != Kubernetes {
 		slog.Warn("Invalid executor type, defaulting to Docker", slog.String("executorType", string(executorType)))
 		executorType = Docker
 	}
+	if client == nil {
+		client = NewHTTPClient(DefaultConnectTimeout, DefaultResponseTimeout)
+	}
 	return &HadesExecutor{
 		executorType: executorType,
 		HadesURL:     hadesURL,
+		Client:       client,
 	}
 }
 
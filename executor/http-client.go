@@ -0,0 +1,28 @@
+package executor
+
+import (
+	"net"
+	"net/http"
+	"time"
+)
+
+const (
+	DefaultConnectTimeout  = 10 * time.Second
+	DefaultResponseTimeout = 30 * time.Second
+)
+
+// NewHTTPClient creates the client used by the executors to talk to the CI systems.
+// connectTimeout bounds establishing the connection including the TLS handshake, responseTimeout
+// bounds waiting for the response headers after the request was sent. Neither bounds the overall
+// request, which is left to the context of the request.
+func NewHTTPClient(connectTimeout time.Duration, responseTimeout time.Duration) *http.Client {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.DialContext = (&net.Dialer{
+		Timeout:   connectTimeout,
+		KeepAlive: 30 * time.Second,
+	}).DialContext
+	transport.TLSHandshakeTimeout = connectTimeout
+	transport.ResponseHeaderTimeout = responseTimeout
+
+	return &http.Client{Transport: transport}
+}
@@ -1,12 +1,16 @@
 package executor
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"log/slog"
 	"net/http"
 	"net/url"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/ls1intum/hades/shared/payload"
@@ -14,6 +18,8 @@ import (
 
 // Compile-time check to ensure JenkinsExecutor implements the Executor interface
 var _ Executor = (*JenkinsExecutor)(nil)
+var _ StatusReporter = (*JenkinsExecutor)(nil)
+var _ Canceller = (*JenkinsExecutor)(nil)
 
 type JenkinsExecutor struct {
 	JenkinsURL    string
@@ -21,6 +27,18 @@ type JenkinsExecutor struct {
 	APIToken      string
 	JobPath       string
 	UseParameters bool
+	Client        *http.Client
+
+	// jobs maps the job IDs handed out by Execute to the Jenkins queue item and build
+	mu   sync.Mutex
+	jobs map[uuid.UUID]*jenkinsJob
+}
+
+// jenkinsJob keeps track of where a scheduled job lives in Jenkins. The build URL is only known
+// once the queue item left the queue.
+type jenkinsJob struct {
+	queueURL string
+	buildURL string
 }
 
 type crumbResp struct {
@@ -28,14 +46,20 @@ type crumbResp struct {
 	CrumbRequestField string `json:"crumbRequestField"`
 }
 
-func NewJenkinsExecutor(jenkinsURL string, user string, APIToken string, path string, useParameters bool) *JenkinsExecutor {
+// NewJenkinsExecutor creates an executor triggering the Jenkins job at path.
+// If client is nil, a client with the default timeouts is used.
+func NewJenkinsExecutor(jenkinsURL string, user string, APIToken string, path string, useParameters bool, client *http.Client) *JenkinsExecutor {
 	slog.Info("Creating new JenkinsExecutor")
+	if client == nil {
+		client = NewHTTPClient(DefaultConnectTimeout, DefaultResponseTimeout)
+	}
 	return &JenkinsExecutor{
 		JenkinsURL:    strings.TrimRight(jenkinsURL, "/"),
 		User:          user,
 		APIToken:      APIToken,
 		JobPath:       path,
 		UseParameters: useParameters,
+		Client:        client,
 	}
 }
 
@@ -43,7 +67,7 @@ func (e *JenkinsExecutor) Name() string {
 	return "JenkinsExecutor"
 }
 
-func (e *JenkinsExecutor) Execute(jobPayload payload.RESTPayload) (uuid.UUID, error) {
+func (e *JenkinsExecutor) Execute(ctx context.Context, jobPayload payload.RESTPayload) (uuid.UUID, error) {
 	slog.Debug("Executing JenkinsExecutor")
 
 	if e.JenkinsURL == "" || e.User == "" || e.APIToken == "" || e.JobPath == "" {
@@ -51,7 +75,7 @@ func (e *JenkinsExecutor) Execute(jobPayload payload.RESTPayload) (uuid.UUID, er
 		return uuid.UUID{}, errors.New("JenkinsExecutor not configured: need JenkinsURL, User, APIToken, JobPath")
 	}
 
-	crumbField, crumbValue, err := e.getCrumb()
+	crumbField, crumbValue, err := e.getCrumb(ctx)
 	if err != nil {
 		slog.Debug("Error while getting Jenkins crumb")
 		return uuid.UUID{}, err
@@ -68,7 +92,7 @@ func (e *JenkinsExecutor) Execute(jobPayload payload.RESTPayload) (uuid.UUID, er
 		}
 		endpoint = e.JenkinsURL + "/" + strings.TrimLeft(e.JobPath, "/") + "/buildWithParameters"
 
-		req, err = http.NewRequest(http.MethodPost, endpoint, strings.NewReader(params.Encode()))
+		req, err = http.NewRequestWithContext(ctx, http.MethodPost, endpoint, strings.NewReader(params.Encode()))
 		if err != nil {
 			slog.Debug("Error while creating POST request to Jenkins (parameters)")
 			return uuid.UUID{}, err
@@ -78,7 +102,7 @@ func (e *JenkinsExecutor) Execute(jobPayload payload.RESTPayload) (uuid.UUID, er
 		endpoint = e.JenkinsURL + "/" + strings.TrimLeft(e.JobPath, "/") + "/build"
 
 		var err error
-		req, err = http.NewRequest(http.MethodPost, endpoint, nil)
+		req, err = http.NewRequestWithContext(ctx, http.MethodPost, endpoint, nil)
 		if err != nil {
 			slog.Debug("Error while creating POST request to Jenkins (no parameters)")
 			return uuid.UUID{}, err
@@ -90,7 +114,7 @@ func (e *JenkinsExecutor) Execute(jobPayload payload.RESTPayload) (uuid.UUID, er
 		req.Header.Set(crumbField, crumbValue)
 	}
 
-	resp, err := http.DefaultClient.Do(req)
+	resp, err := e.Client.Do(req)
 	if err != nil {
 		slog.Debug("Error while sending POST request to Jenkins")
 		return uuid.UUID{}, err
@@ -99,7 +123,7 @@ func (e *JenkinsExecutor) Execute(jobPayload payload.RESTPayload) (uuid.UUID, er
 
 	if resp.StatusCode != http.StatusCreated && resp.StatusCode != http.StatusAccepted {
 		slog.Debug("JenkinsExecutor returned non-201/202 status code", slog.Int("status", resp.StatusCode))
-		return uuid.UUID{}, errors.New("JenkinsExecutor returned non-201/202 status code")
+		return uuid.UUID{}, &StatusError{Executor: e.Name(), StatusCode: resp.StatusCode}
 	}
 
 	loc := strings.TrimSpace(resp.Header.Get("Location"))
@@ -109,19 +133,20 @@ func (e *JenkinsExecutor) Execute(jobPayload payload.RESTPayload) (uuid.UUID, er
 	}
 
 	id := uuid.NewSHA1(uuid.NameSpaceURL, []byte(strings.TrimRight(loc, "/")))
+	e.trackJob(id, loc)
 	slog.Info("JenkinsExecutor queued successfully", slog.String("queue_url", loc), slog.Any("jobID", id))
 
 	return id, nil
 }
 
-func (e *JenkinsExecutor) getCrumb() (field string, value string, err error) {
-	req, err := http.NewRequest(http.MethodGet, e.JenkinsURL+"/crumbIssuer/api/json", nil)
+func (e *JenkinsExecutor) getCrumb(ctx context.Context) (field string, value string, err error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, e.JenkinsURL+"/crumbIssuer/api/json", nil)
 	if err != nil {
 		return "", "", err
 	}
 	req.SetBasicAuth(e.User, e.APIToken)
 
-	resp, err := http.DefaultClient.Do(req)
+	resp, err := e.Client.Do(req)
 	if err != nil {
 		return "", "", err
 	}
@@ -150,3 +175,157 @@ func (e *JenkinsExecutor) payloadToParams(p payload.RESTPayload) (url.Values, er
 	values.Set("HADES_PAYLOAD_JSON", string(b))
 	return values, nil
 }
+
+func (e *JenkinsExecutor) trackJob(id uuid.UUID, queueURL string) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.jobs == nil {
+		e.jobs = make(map[uuid.UUID]*jenkinsJob)
+	}
+	e.jobs[id] = &jenkinsJob{queueURL: strings.TrimRight(queueURL, "/") + "/"}
+}
+
+// Status resolves the queue item of a job to its build and reports the state of that build.
+// Only jobs scheduled by this executor instance can be resolved.
+func (e *JenkinsExecutor) Status(ctx context.Context, jobID uuid.UUID) (JobState, JobTimestamps, error) {
+	e.mu.Lock()
+	job, ok := e.jobs[jobID]
+	var queueURL, buildURL string
+	if ok {
+		queueURL, buildURL = job.queueURL, job.buildURL
+	}
+	e.mu.Unlock()
+	if !ok {
+		return JobStateUnknown, JobTimestamps{}, fmt.Errorf("job %s was not scheduled by this JenkinsExecutor", jobID)
+	}
+
+	if buildURL == "" {
+		var item struct {
+			Cancelled  bool `json:"cancelled"`
+			Executable *struct {
+				URL string `json:"url"`
+			} `json:"executable"`
+		}
+		if err := e.getJSON(ctx, queueURL+"api/json", &item); err != nil {
+			return JobStateUnknown, JobTimestamps{}, err
+		}
+		if item.Cancelled {
+			return JobStateCancelled, JobTimestamps{}, nil
+		}
+		if item.Executable == nil || item.Executable.URL == "" {
+			return JobStateQueued, JobTimestamps{}, nil
+		}
+
+		buildURL = strings.TrimRight(item.Executable.URL, "/") + "/"
+		e.mu.Lock()
+		job.buildURL = buildURL
+		e.mu.Unlock()
+	}
+
+	var build struct {
+		Building  bool   `json:"building"`
+		Result    string `json:"result"`
+		Timestamp int64  `json:"timestamp"`
+		Duration  int64  `json:"duration"`
+	}
+	if err := e.getJSON(ctx, buildURL+"api/json", &build); err != nil {
+		return JobStateUnknown, JobTimestamps{}, err
+	}
+
+	var timestamps JobTimestamps
+	if build.Timestamp > 0 {
+		start := time.UnixMilli(build.Timestamp)
+		timestamps.StartTime = &start
+	}
+	if build.Building || build.Result == "" {
+		return JobStateRunning, timestamps, nil
+	}
+	if timestamps.StartTime != nil {
+		end := timestamps.StartTime.Add(time.Duration(build.Duration) * time.Millisecond)
+		timestamps.EndTime = &end
+	}
+
+	switch build.Result {
+	case "SUCCESS":
+		return JobStateSucceeded, timestamps, nil
+	case "FAILURE", "UNSTABLE":
+		return JobStateFailed, timestamps, nil
+	case "ABORTED", "NOT_BUILT":
+		return JobStateCancelled, timestamps, nil
+	default:
+		return JobStateUnknown, timestamps, nil
+	}
+}
+
+// Cancel removes a job from the Jenkins queue or stops its build if it already left the queue
+func (e *JenkinsExecutor) Cancel(ctx context.Context, jobID uuid.UUID) error {
+	// Resolve the build first, a queue item can not be cancelled anymore once it is executing
+	state, _, err := e.Status(ctx, jobID)
+	if err != nil {
+		return err
+	}
+	if state.IsTerminal() {
+		return nil
+	}
+
+	e.mu.Lock()
+	queueURL, buildURL := e.jobs[jobID].queueURL, e.jobs[jobID].buildURL
+	e.mu.Unlock()
+
+	var endpoint string
+	if buildURL != "" {
+		endpoint = buildURL + "stop"
+	} else {
+		// queue item urls look like <JenkinsURL>/queue/item/<id>/
+		parts := strings.Split(strings.TrimRight(queueURL, "/"), "/")
+		endpoint = e.JenkinsURL + "/queue/cancelItem?id=" + url.QueryEscape(parts[len(parts)-1])
+	}
+
+	crumbField, crumbValue, err := e.getCrumb(ctx)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, nil)
+	if err != nil {
+		return err
+	}
+	req.SetBasicAuth(e.User, e.APIToken)
+	if crumbField != "" && crumbValue != "" {
+		req.Header.Set(crumbField, crumbValue)
+	}
+
+	resp, err := e.Client.Do(req)
+	if err != nil {
+		slog.Debug("Error while sending cancel request to Jenkins")
+		return err
+	}
+	defer resp.Body.Close()
+
+	// Jenkins answers both endpoints with a redirect on success
+	if resp.StatusCode >= 400 {
+		return fmt.Errorf("jenkins returned status code %d while cancelling %s", resp.StatusCode, jobID)
+	}
+
+	slog.Info("JenkinsExecutor cancelled job", slog.Any("jobID", jobID), slog.String("endpoint", endpoint))
+	return nil
+}
+
+func (e *JenkinsExecutor) getJSON(ctx context.Context, endpoint string, v any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return err
+	}
+	req.SetBasicAuth(e.User, e.APIToken)
+
+	resp, err := e.Client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("jenkins returned status code %d for %s", resp.StatusCode, endpoint)
+	}
+	return json.NewDecoder(resp.Body).Decode(v)
+}
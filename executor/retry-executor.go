@@ -0,0 +1,151 @@
+package executor
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"math"
+	"math/rand/v2"
+	"net"
+	"slices"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/ls1intum/hades/shared/payload"
+)
+
+// Compile-time check to ensure RetryExecutor implements the Executor interface
+var _ Executor = (*RetryExecutor)(nil)
+
+// RetryPolicy defines how often and when a failed job submission is retried
+type RetryPolicy struct {
+	// MaxAttempts is the number of submissions per job including the first one, values below 1 count as 1
+	MaxAttempts int
+	// InitialBackoff is the delay before the first retry, it doubles with every further retry
+	InitialBackoff time.Duration
+	// MaxBackoff caps the delay between two attempts, 0 means no cap
+	MaxBackoff time.Duration
+	// Jitter is the fraction of the delay which is randomized, between 0 and 1
+	Jitter float64
+	// RetryableStatusCodes are the status codes of the CI system which are considered transient
+	RetryableStatusCodes []int
+}
+
+// DefaultRetryPolicy retries transient proxy and overload errors twice
+func DefaultRetryPolicy() RetryPolicy {
+	return RetryPolicy{
+		MaxAttempts:          3,
+		InitialBackoff:       500 * time.Millisecond,
+		MaxBackoff:           10 * time.Second,
+		Jitter:               0.5,
+		RetryableStatusCodes: []int{429, 502, 503, 504},
+	}
+}
+
+// Attempt describes a single submission of a job to the CI system
+type Attempt struct {
+	Number    int
+	StartTime time.Time
+	Duration  time.Duration
+	// StatusCode is the unexpected status code returned by the CI system, 0 if the attempt succeeded
+	// or did not get a response
+	StatusCode int
+	Err        error
+	JobID      *uuid.UUID
+}
+
+// RetryExecutor wraps an executor and retries failed submissions according to its policy.
+// Every attempt is reported, so retries do not hide in the measured submit latency.
+type RetryExecutor struct {
+	Executor Executor
+	Policy   RetryPolicy
+}
+
+func NewRetryExecutor(e Executor, policy RetryPolicy) *RetryExecutor {
+	return &RetryExecutor{
+		Executor: e,
+		Policy:   policy,
+	}
+}
+
+// Name returns the name of the wrapped executor, retries do not change what is benchmarked
+func (r *RetryExecutor) Name() string {
+	return r.Executor.Name()
+}
+
+func (r *RetryExecutor) Execute(ctx context.Context, jobPayload payload.RESTPayload) (uuid.UUID, error) {
+	jobID, _, err := r.ExecuteWithAttempts(ctx, jobPayload)
+	return jobID, err
+}
+
+// ExecuteWithAttempts submits the job until it succeeds, a non-retryable error occurs or the attempts
+// are exhausted. It returns all attempts made, the last one carries the final outcome.
+func (r *RetryExecutor) ExecuteWithAttempts(ctx context.Context, jobPayload payload.RESTPayload) (uuid.UUID, []Attempt, error) {
+	maxAttempts := max(r.Policy.MaxAttempts, 1)
+	attempts := make([]Attempt, 0, 1)
+
+	for n := 1; ; n++ {
+		start := time.Now()
+		jobID, err := r.Executor.Execute(ctx, jobPayload)
+		attempt := Attempt{
+			Number:    n,
+			StartTime: start,
+			Duration:  time.Since(start),
+			Err:       err,
+		}
+		var statusErr *StatusError
+		if errors.As(err, &statusErr) {
+			attempt.StatusCode = statusErr.StatusCode
+		}
+		if err == nil {
+			attempt.JobID = &jobID
+		}
+		attempts = append(attempts, attempt)
+
+		if err == nil {
+			return jobID, attempts, nil
+		}
+		if n >= maxAttempts || !r.retryable(ctx, err) {
+			return uuid.UUID{}, attempts, err
+		}
+
+		delay := r.backoff(n)
+		slog.Debug("Retrying job submission", slog.Int("attempt", n), slog.Duration("delay", delay), slog.Any("error", err))
+		select {
+		case <-ctx.Done():
+			return uuid.UUID{}, attempts, err
+		case <-time.After(delay):
+		}
+	}
+}
+
+// retryable reports whether err is transient, i.e. a retryable status code or a failed connection attempt.
+// Other errors are not retried, as the job might have been created despite the error.
+func (r *RetryExecutor) retryable(ctx context.Context, err error) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+
+	var statusErr *StatusError
+	if errors.As(err, &statusErr) {
+		return slices.Contains(r.Policy.RetryableStatusCodes, statusErr.StatusCode)
+	}
+
+	var opErr *net.OpError
+	return errors.As(err, &opErr) && opErr.Op == "dial"
+}
+
+// backoff returns the delay after the given attempt, growing exponentially and randomized by the jitter
+func (r *RetryExecutor) backoff(attempt int) time.Duration {
+	maxBackoff := r.Policy.MaxBackoff
+	if maxBackoff <= 0 {
+		maxBackoff = math.MaxInt64
+	}
+	delay := r.Policy.InitialBackoff << (attempt - 1)
+	if delay > maxBackoff || delay <= 0 || delay>>(attempt-1) != r.Policy.InitialBackoff {
+		delay = maxBackoff
+	}
+
+	jitter := min(max(r.Policy.Jitter, 0), 1)
+	return delay - time.Duration(float64(delay)*jitter*rand.Float64())
+}
@@ -0,0 +1,137 @@
+package executor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/ls1intum/hades/shared/payload"
+)
+
+// failingHades answers the first failures requests with status and accepts the following ones
+func failingHades(t *testing.T, failures int, status int) (*HadesExecutor, *atomic.Int32, uuid.UUID) {
+	t.Helper()
+	jobID := uuid.New()
+	var requests atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if int(requests.Add(1)) <= failures {
+			w.WriteHeader(status)
+			return
+		}
+		fmt.Fprintf(w, `{"message": "ok", "job_id": %q}`, jobID)
+	}))
+	t.Cleanup(srv.Close)
+	return NewHadesExecutor(srv.URL, Docker, srv.Client()), &requests, jobID
+}
+
+func TestRetryExecutor(t *testing.T) {
+	policy := RetryPolicy{
+		MaxAttempts:          3,
+		InitialBackoff:       time.Millisecond,
+		MaxBackoff:           5 * time.Millisecond,
+		RetryableStatusCodes: []int{502, 503},
+	}
+	tests := []struct {
+		name     string
+		failures int
+		status   int
+		attempts int
+		success  bool
+	}{
+		{"first attempt succeeds", 0, 0, 1, true},
+		{"retryable status is retried", 2, http.StatusServiceUnavailable, 3, true},
+		{"attempts are exhausted", 3, http.StatusBadGateway, 3, false},
+		{"other status is not retried", 3, http.StatusInternalServerError, 1, false},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			hades, requests, jobID := failingHades(t, test.failures, test.status)
+			retrier := NewRetryExecutor(hades, policy)
+
+			id, attempts, err := retrier.ExecuteWithAttempts(context.Background(), payload.RESTPayload{})
+			if len(attempts) != test.attempts || int(requests.Load()) != test.attempts {
+				t.Fatalf("expected %d attempts, got %d with %d requests", test.attempts, len(attempts), requests.Load())
+			}
+			for i, attempt := range attempts {
+				if attempt.Number != i+1 {
+					t.Fatalf("expected attempt number %d, got %d", i+1, attempt.Number)
+				}
+			}
+			last := attempts[len(attempts)-1]
+			if test.success {
+				if err != nil || id != jobID || last.JobID == nil || *last.JobID != jobID {
+					t.Fatalf("expected job %s, got %s, %v", jobID, id, err)
+				}
+				return
+			}
+			var statusErr *StatusError
+			if !errors.As(err, &statusErr) || statusErr.StatusCode != test.status || last.StatusCode != test.status {
+				t.Fatalf("expected status %d, got %v", test.status, err)
+			}
+		})
+	}
+}
+
+func TestRetryExecutorStopsOnCancel(t *testing.T) {
+	hades, requests, _ := failingHades(t, 10, http.StatusServiceUnavailable)
+	retrier := NewRetryExecutor(hades, RetryPolicy{
+		MaxAttempts:          10,
+		InitialBackoff:       time.Hour,
+		MaxBackoff:           time.Hour,
+		RetryableStatusCodes: []int{503},
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(20*time.Millisecond, cancel)
+	start := time.Now()
+	_, attempts, err := retrier.ExecuteWithAttempts(ctx, payload.RESTPayload{})
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Fatalf("expected the backoff to end with the context, took %s", elapsed)
+	}
+	if err == nil || len(attempts) != 1 || requests.Load() != 1 {
+		t.Fatalf("expected one failed attempt, got %d attempts and %v", len(attempts), err)
+	}
+
+	// A cancelled context is not retried at all
+	_, attempts, _ = retrier.ExecuteWithAttempts(ctx, payload.RESTPayload{})
+	if len(attempts) != 1 {
+		t.Fatalf("expected one attempt with a cancelled context, got %d", len(attempts))
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	tests := []struct {
+		name    string
+		policy  RetryPolicy
+		attempt int
+		min     time.Duration
+		max     time.Duration
+	}{
+		{"first retry", RetryPolicy{InitialBackoff: 100 * time.Millisecond, MaxBackoff: time.Second}, 1, 100 * time.Millisecond, 100 * time.Millisecond},
+		{"doubles", RetryPolicy{InitialBackoff: 100 * time.Millisecond, MaxBackoff: time.Second}, 3, 400 * time.Millisecond, 400 * time.Millisecond},
+		{"capped", RetryPolicy{InitialBackoff: 100 * time.Millisecond, MaxBackoff: time.Second}, 5, time.Second, time.Second},
+		{"overflow is capped", RetryPolicy{InitialBackoff: time.Second, MaxBackoff: time.Minute}, 70, time.Minute, time.Minute},
+		{"no cap", RetryPolicy{InitialBackoff: time.Second}, 10, 512 * time.Second, 512 * time.Second},
+		{"overflow without cap", RetryPolicy{InitialBackoff: time.Second}, 70, math.MaxInt64, math.MaxInt64},
+		{"jitter", RetryPolicy{InitialBackoff: time.Second, MaxBackoff: time.Minute, Jitter: 0.5}, 1, 500 * time.Millisecond, time.Second},
+		{"jitter is limited to 1", RetryPolicy{InitialBackoff: time.Second, MaxBackoff: time.Minute, Jitter: 2}, 1, 0, time.Second},
+		{"negative jitter counts as 0", RetryPolicy{InitialBackoff: time.Second, MaxBackoff: time.Minute, Jitter: -1}, 1, time.Second, time.Second},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			retrier := NewRetryExecutor(nil, test.policy)
+			for range 100 {
+				if delay := retrier.backoff(test.attempt); delay < test.min || delay > test.max {
+					t.Fatalf("expected a delay in [%s, %s], got %s", test.min, test.max, delay)
+				}
+			}
+		})
+	}
+}
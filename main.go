@@ -1,10 +1,26 @@
 package main
 
 import (
+	"context"
+	"errors"
+	"fmt"
 	"log/slog"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
 	"strings"
+	"sync"
+	"syscall"
+	"time"
 
+	"github.com/Mtze/CI-Benchmarker/backup"
+	"github.com/Mtze/CI-Benchmarker/benchmarkController"
+	"github.com/Mtze/CI-Benchmarker/executor"
+	"github.com/Mtze/CI-Benchmarker/payloadtemplate"
 	"github.com/Mtze/CI-Benchmarker/persister"
+	"github.com/Mtze/CI-Benchmarker/reconciler"
+	"github.com/Mtze/CI-Benchmarker/retention"
 	"github.com/Mtze/CI-Benchmarker/shared/config"
 
 	docs "github.com/Mtze/CI-Benchmarker/docs"
@@ -27,9 +43,30 @@ import (
 
 // @schemes http https
 
+// @securityDefinitions.apikey  AdminToken
+// @in                          header
+// @name                        Authorization
+// @description                 Admin endpoints require "Bearer <ADMIN_TOKEN>", they are disabled if ADMIN_TOKEN is not set
+
 // Persister handles to store the job results in the database
 var p persister.Persister
 
+// callbackQueue batches the writes of the callbacks, it is nil if batching is disabled
+var callbackQueue *persister.CallbackQueue
+
+// Services shared by the benchmark handlers, i.e. the reconciler polling in-flight jobs,
+// the registry of runs which can be cancelled and the HTTP client used to talk to the CI systems
+var services benchmarkController.Services
+
+// background tracks the reconciler, the pruner and the backup scheduler
+var background sync.WaitGroup
+
+// shutdownTimeout bounds how long in-flight requests are awaited when the server shuts down
+const shutdownTimeout = 10 * time.Second
+
+// stopTimeout bounds how long runs, experiments and background work are awaited before the database is closed
+const stopTimeout = 30 * time.Second
+
 func main() {
 	// Set the log level to debug if the DEBUG environment variable is set to true
 	if is_debug := config.GetEnv("DEBUG"); is_debug == "true" {
@@ -37,27 +74,204 @@ func main() {
 		slog.SetLogLoggerLevel(slog.LevelDebug)
 	}
 
+	// "bench" runs a single scenario without a long-lived server
+	if len(os.Args) > 1 && os.Args[1] == "bench" {
+		os.Exit(bench(os.Args[2:]))
+	}
+	// "migrate" applies the pending schema migrations and exits
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		os.Exit(migrate(os.Args[2:]))
+	}
+
+	os.Exit(run())
+}
+
+// run serves requests until the server is shut down and returns the exit code. It returns instead of
+// exiting, so the deferred functions close the database on failures as well.
+func run() int {
 	cfg := config.Load()
 
+	// The context is cancelled on shutdown, which stops all runs and the reconciler
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	if err := setup(ctx, cfg); err != nil {
+		slog.Error("Setup failed", slog.Any("error", err))
+		return 1
+	}
+	defer closePersister(stop)
+
+	srv, ln, err := listen(ctx, cfg)
+	if err != nil {
+		slog.Error("Failed to start server", slog.Any("error", err))
+		return 1
+	}
+	go serve(srv, ln, stop)
+
+	<-ctx.Done()
+	shutdown(srv)
+	return 0
+}
+
+// setup creates the persister and the services shared by the handlers. Runs, the reconciler,
+// the pruner and the scheduled backups are stopped once ctx is cancelled.
+func setup(ctx context.Context, cfg config.Config) error {
+	publicURL := cfg.PublicURL
+	if publicURL == "" {
+		publicURL = "http://localhost" + serverAddress(cfg)
+	}
+
+	startTimePolicy, err := persister.ParseWritePolicy(cfg.CallbackStartTimePolicy)
+	if err != nil {
+		return fmt.Errorf("invalid CALLBACK_START_TIME_POLICY: %w", err)
+	}
+	resultPolicy, err := persister.ParseWritePolicy(cfg.CallbackResultPolicy)
+	if err != nil {
+		return fmt.Errorf("invalid CALLBACK_RESULT_POLICY: %w", err)
+	}
+	if cfg.BackupInterval > 0 && persister.IsPostgresURL(databaseDSN(cfg)) {
+		return errors.New("BACKUP_INTERVAL is only supported for SQLite databases, back up PostgreSQL with pg_dump")
+	}
+
 	slog.Debug("Creating DB persister")
-	p = persister.NewDBPersister()
+	db, err := persister.NewDBPersister(databaseDSN(cfg))
+	if err != nil {
+		return fmt.Errorf("failed to open database: %w", err)
+	}
+	db = db.WithCallbackPolicy(persister.CallbackPolicy{StartTime: startTimePolicy, Result: resultPolicy})
+	p = db
+	if cfg.CallbackBatchSize > 1 {
+		callbackQueue = persister.NewCallbackQueue(db, cfg.CallbackQueueSize, cfg.CallbackBatchSize, cfg.CallbackBatchDelay)
+		p = callbackQueue
+	}
 
-	addr := cfg.ServerAddress
-	if addr == "" {
-		addr = ":8080"
-	} else if !strings.HasPrefix(addr, ":") {
-		addr = ":" + addr
+	rec := reconciler.NewReconciler(p, cfg.ReconcileInterval, cfg.ReconcileJobTimeout)
+	goBackground(func() { rec.Run(ctx) })
+
+	pruner := retention.NewPruner(db, retention.Policy{
+		MaxAge:   cfg.RetentionMaxAge,
+		MaxJobs:  cfg.RetentionMaxJobs,
+		KeepRuns: cfg.RetentionKeepRuns,
+	}, cfg.RetentionInterval, cfg.RetentionArchiveDir)
+	goBackground(func() { pruner.Run(ctx) })
+
+	scheduler := backup.NewScheduler(db, cfg.BackupInterval, cfg.BackupDir, cfg.BackupKeep)
+	goBackground(func() { scheduler.Run(ctx) })
+
+	services = benchmarkController.Services{
+		Persister:  db,
+		Reconciler: rec,
+		Pruner:     pruner,
+		Runs:       benchmarkController.NewRunRegistry(ctx),
+		HTTPClient: executor.NewHTTPClient(cfg.HTTPConnectTimeout, cfg.HTTPResponseTimeout),
+		RetryPolicy: executor.RetryPolicy{
+			MaxAttempts:          cfg.RetryMaxAttempts,
+			InitialBackoff:       cfg.RetryInitialBackoff,
+			MaxBackoff:           cfg.RetryMaxBackoff,
+			Jitter:               cfg.RetryJitter,
+			RetryableStatusCodes: cfg.RetryStatusCodes,
+		},
+		Concurrency: cfg.SubmitConcurrency,
+		ScenarioDir: cfg.ScenarioDir,
+		PublicURL:   strings.TrimSuffix(publicURL, "/"),
+		Reporters: payloadtemplate.Reporters{
+			BaseURL:     strings.TrimSuffix(publicURL, "/"),
+			StartImage:  cfg.StartReporterImage,
+			ResultImage: cfg.ResultReporterImage,
+		},
+	}
+	return nil
+}
+
+// goBackground runs f in a goroutine which is awaited before the database is closed
+func goBackground(f func()) {
+	background.Add(1)
+	go func() {
+		defer background.Done()
+		f()
+	}()
+}
+
+// closePersister stops the runs and the background work with stop and waits for them for at most
+// stopTimeout. Then it writes the queued callbacks and closes the database, once no more requests are served.
+func closePersister(stop func()) {
+	stop()
+	stopped := make(chan struct{})
+	go func() {
+		services.Runs.Wait()
+		background.Wait()
+		close(stopped)
+	}()
+	select {
+	case <-stopped:
+	case <-time.After(stopTimeout):
+		slog.Warn("Runs or background work did not stop in time, closing the database anyway", slog.Duration("timeout", stopTimeout))
 	}
 
+	if callbackQueue != nil {
+		callbackQueue.Close()
+	}
+	services.Persister.Close()
+}
+
+// listen binds the configured address, so the callback endpoints are reachable once it returns.
+// Requests are served with ctx as their base context.
+func listen(ctx context.Context, cfg config.Config) (*http.Server, net.Listener, error) {
+	addr := serverAddress(cfg)
+
 	slog.Info("Starting server", slog.String("address", addr))
 
 	port := strings.TrimPrefix(addr, ":")
 	docs.SwaggerInfo.Host = "localhost:" + port
 	docs.SwaggerInfo.Schemes = []string{"http"}
 
-	r := startRouter()
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, nil, err
+	}
 
-	if err := r.Run(addr); err != nil {
-		slog.Error("Failed to start server", slog.Any("error", err))
+	srv := &http.Server{
+		Addr:        addr,
+		Handler:     startRouter(cfg.AdminToken),
+		BaseContext: func(net.Listener) context.Context { return ctx },
+	}
+	return srv, ln, nil
+}
+
+// databaseDSN returns the database to use, a PostgreSQL database takes precedence over the SQLite database
+func databaseDSN(cfg config.Config) string {
+	if cfg.DatabaseURL != "" {
+		return cfg.DatabaseURL
+	}
+	return cfg.DatabasePath
+}
+
+// serverAddress returns the address to listen on, SERVER_ADDRESS may be given as port only
+func serverAddress(cfg config.Config) string {
+	addr := cfg.ServerAddress
+	if addr == "" {
+		return ":8080"
+	} else if !strings.HasPrefix(addr, ":") {
+		return ":" + addr
+	}
+	return addr
+}
+
+// serve handles requests until the server is shut down, stop is called if serving fails
+func serve(srv *http.Server, ln net.Listener, stop func()) {
+	if err := srv.Serve(ln); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		slog.Error("Failed to serve requests", slog.Any("error", err))
+		stop()
+	}
+}
+
+// shutdown waits for in-flight requests for at most shutdownTimeout
+func shutdown(srv *http.Server) {
+	slog.Info("Shutting down server")
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		slog.Error("Failed to shut down server gracefully", slog.Any("error", err))
 	}
 }
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log/slog"
+
+	"github.com/Mtze/CI-Benchmarker/persister"
+	"github.com/Mtze/CI-Benchmarker/shared/config"
+)
+
+// migrate applies the pending migrations to the configured database, so the schema can be updated
+// before a new version of the server is started. With --status the pending migrations are only listed.
+func migrate(args []string) int {
+	fs := flag.NewFlagSet("migrate", flag.ContinueOnError)
+	status := fs.Bool("status", false, "List the pending migrations without applying them")
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return 0
+		}
+		return 1
+	}
+
+	cfg := config.Load()
+	p, err := persister.Open(databaseDSN(cfg))
+	if err != nil {
+		slog.Error("Failed to open database", slog.Any("error", err))
+		return 1
+	}
+	defer p.Close()
+
+	if *status {
+		pending, err := p.PendingMigrations()
+		if err != nil {
+			slog.Error("Failed to look up the pending migrations", slog.Any("error", err))
+			return 1
+		}
+		for _, m := range pending {
+			fmt.Printf("pending %d_%s\n", m.Version, m.Name)
+		}
+	} else {
+		applied, err := p.Migrate(context.Background())
+		for _, m := range applied {
+			fmt.Printf("applied %d_%s\n", m.Version, m.Name)
+		}
+		if err != nil {
+			slog.Error("Failed to migrate database", slog.Any("error", err))
+			return 1
+		}
+	}
+
+	version, err := p.SchemaVersion()
+	if err != nil {
+		slog.Error("Failed to look up the schema version", slog.Any("error", err))
+		return 1
+	}
+	fmt.Printf("schema version %d\n", version)
+	return 0
+}
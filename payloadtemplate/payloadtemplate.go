@@ -0,0 +1,206 @@
+// Package payloadtemplate renders payloads per job, so the jobs of a run differ from each other and
+// caches of the CI system do not make later jobs unrealistically fast.
+//
+// All string fields of a payload, i.e. its name, the metadata values and the name, image, script,
+// metadata values and memory limit of its steps, are Go templates evaluated with Data, e.g.
+//
+//	REPOSITORY: {{ choice .Choices.repositories }}
+//	BUILD_ID:   run-{{ .RunID }}-job-{{ .JobIndex }}
+//	ENDPOINT:   {{ .StartTimeURL }}
+//
+// Reporters adds the steps which report the start time and result of a job to the benchmarker.
+package payloadtemplate
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"maps"
+	"math/rand/v2"
+	"slices"
+	"strings"
+	"text/template"
+
+	"github.com/google/uuid"
+	"github.com/ls1intum/hades/shared/payload"
+)
+
+// Data is available to the templates of a payload
+type Data struct {
+	// JobIndex is the index of the job within its run, warm-up jobs are numbered separately
+	JobIndex int
+	Warmup   bool
+	// JobUUID is derived from the run ID and job index and set as the ID of the payload, the CI system may
+	// assign a different job ID
+	JobUUID uuid.UUID
+	RunID   uuid.UUID
+	// BaseURL is the externally reachable URL of the benchmarker, StartTimeURL and ResultURL its callback endpoints
+	BaseURL      string
+	StartTimeURL string
+	ResultURL    string
+	// Choices are the lists configured for the run, e.g. assignment repositories or commits
+	Choices map[string][]string
+}
+
+// Renderer renders the payloads of a run
+type Renderer struct {
+	BaseURL string
+	Choices map[string][]string
+}
+
+// Render returns a copy of p with all templates evaluated for a job. The ID of the payload is replaced
+// by the JobUUID of the job. The JobUUID and the seed of the random functions are derived from the run ID,
+// the job index and whether it is a warm-up job, so rendering the same job again yields the same payload.
+func (r Renderer) Render(p payload.RESTPayload, runID uuid.UUID, jobIndex int, warmup bool) (payload.RESTPayload, error) {
+	data := Data{
+		JobIndex:     jobIndex,
+		Warmup:       warmup,
+		JobUUID:      jobUUID(runID, jobIndex, warmup),
+		RunID:        runID,
+		BaseURL:      r.BaseURL,
+		StartTimeURL: r.BaseURL + "/v1/start_time",
+		ResultURL:    r.BaseURL + "/v1/result",
+		Choices:      r.Choices,
+	}
+
+	seed := binary.BigEndian.Uint64(runID[8:]) ^ uint64(jobIndex)
+	if warmup {
+		seed = ^seed
+	}
+	rng := rand.New(rand.NewPCG(binary.BigEndian.Uint64(runID[:8]), seed))
+
+	rendered := clone(p)
+	rendered.ID = data.JobUUID
+	err := walk(&rendered, func(s *string) error {
+		out, err := execute(*s, data, rng)
+		if err != nil {
+			return err
+		}
+		*s = out
+		return nil
+	})
+	return rendered, err
+}
+
+// jobUUID derives the ID of a job from its run, index and whether it is a warm-up job
+func jobUUID(runID uuid.UUID, jobIndex int, warmup bool) uuid.UUID {
+	name := fmt.Sprintf("job-%d", jobIndex)
+	if warmup {
+		name = fmt.Sprintf("warmup-%d", jobIndex)
+	}
+	return uuid.NewSHA1(runID, []byte(name))
+}
+
+// Check parses all templates of p, so syntax errors are reported before a run starts
+func Check(p payload.RESTPayload) error {
+	p = clone(p)
+	return walk(&p, func(s *string) error {
+		_, err := parse(*s, nil)
+		return err
+	})
+}
+
+// walk calls fn for every templated string field of p
+func walk(p *payload.RESTPayload, fn func(s *string) error) error {
+	if err := field("name", &p.Name, fn); err != nil {
+		return err
+	}
+	if err := metadata("metadata", p.Metadata, fn); err != nil {
+		return err
+	}
+	for i := range p.Steps {
+		step := &p.Steps[i]
+		prefix := fmt.Sprintf("steps[%d].", i)
+		fields := []struct {
+			name string
+			s    *string
+		}{{"name", &step.Name}, {"image", &step.Image}, {"script", &step.Script}, {"memory_limit", &step.MemoryLimit}}
+		for _, f := range fields {
+			if err := field(prefix+f.name, f.s, fn); err != nil {
+				return err
+			}
+		}
+		if err := metadata(prefix+"metadata", step.Metadata, fn); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func field(name string, s *string, fn func(s *string) error) error {
+	// Most fields are plain strings, they are left untouched
+	if !strings.Contains(*s, "{{") {
+		return nil
+	}
+	if err := fn(s); err != nil {
+		return fmt.Errorf("%s: %w", name, err)
+	}
+	return nil
+}
+
+func metadata(name string, m map[string]string, fn func(s *string) error) error {
+	// Sorted, so the random functions are called in the same order for every job
+	for _, key := range slices.Sorted(maps.Keys(m)) {
+		value := m[key]
+		if err := field(name+"."+key, &value, fn); err != nil {
+			return err
+		}
+		m[key] = value
+	}
+	return nil
+}
+
+func parse(text string, rng *rand.Rand) (*template.Template, error) {
+	return template.New("payload").Option("missingkey=error").Funcs(funcs(rng)).Parse(text)
+}
+
+func execute(text string, data Data, rng *rand.Rand) (string, error) {
+	tmpl, err := parse(text, rng)
+	if err != nil {
+		return "", err
+	}
+	var b bytes.Buffer
+	if err := tmpl.Execute(&b, data); err != nil {
+		return "", err
+	}
+	return b.String(), nil
+}
+
+// funcs are the functions available to templates, rng is nil if the templates are only parsed
+func funcs(rng *rand.Rand) template.FuncMap {
+	return template.FuncMap{
+		// choice returns a random element of a list, e.g. {{ choice .Choices.repos }} or {{ choice "a" "b" }}
+		"choice": func(items ...any) (any, error) {
+			if len(items) == 1 {
+				if list, ok := items[0].([]string); ok {
+					items = make([]any, len(list))
+					for i, item := range list {
+						items[i] = item
+					}
+				}
+			}
+			if len(items) == 0 {
+				return nil, errors.New("choice of an empty list")
+			}
+			return items[rng.IntN(len(items))], nil
+		},
+		// randInt returns a random number in [0, n), e.g. to pick matching elements of two lists with index
+		"randInt": func(n int) (int, error) {
+			if n <= 0 {
+				return 0, errors.New("randInt requires a positive bound")
+			}
+			return rng.IntN(n), nil
+		},
+	}
+}
+
+// clone deep copies the maps and steps of p, which are shared by all jobs of a run
+func clone(p payload.RESTPayload) payload.RESTPayload {
+	p.Metadata = maps.Clone(p.Metadata)
+	p.Steps = slices.Clone(p.Steps)
+	for i := range p.Steps {
+		p.Steps[i].Metadata = maps.Clone(p.Steps[i].Metadata)
+	}
+	return p
+}
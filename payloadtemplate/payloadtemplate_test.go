@@ -0,0 +1,205 @@
+package payloadtemplate
+
+import (
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/ls1intum/hades/shared/payload"
+)
+
+var testRunID = uuid.MustParse("6f1c2a0e-8d3b-4f5a-9c7e-1b2d3e4f5a6b")
+
+func testRenderer() Renderer {
+	return Renderer{
+		BaseURL: "http://benchmarker",
+		Choices: map[string][]string{"repos": {"a", "b", "c"}},
+	}
+}
+
+// templatePayload returns a payload with text as its name
+func templatePayload(text string) payload.RESTPayload {
+	return payload.RESTPayload{QueuePayload: payload.QueuePayload{Name: text}}
+}
+
+func TestRender(t *testing.T) {
+	jobUUID := jobUUID(testRunID, 2, false)
+	tests := []struct {
+		name     string
+		text     string
+		warmup   bool
+		expected string
+		err      string
+	}{
+		{"plain string", "build", false, "build", ""},
+		{"job index", "job-{{ .JobIndex }}", false, "job-2", ""},
+		{"warm-up", "{{ if .Warmup }}warmup{{ else }}job{{ end }}", true, "warmup", ""},
+		{"run and job ID", "{{ .RunID }}/{{ .JobUUID }}", false, testRunID.String() + "/" + jobUUID.String(), ""},
+		{"callback URLs", "{{ .StartTimeURL }} {{ .ResultURL }}", false, "http://benchmarker/v1/start_time http://benchmarker/v1/result", ""},
+		{"choices", "{{ index .Choices.repos 1 }}", false, "b", ""},
+		{"syntax error", "{{ .JobIndex", false, "", "name:"},
+		{"missing choice", "{{ .Choices.missing }}", false, "", "missing"},
+		{"unknown field", "{{ .Unknown }}", false, "", "Unknown"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			rendered, err := testRenderer().Render(templatePayload(test.text), testRunID, 2, test.warmup)
+			if test.err != "" {
+				if err == nil || !strings.Contains(err.Error(), test.err) {
+					t.Fatalf("expected an error containing %q, got %v", test.err, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if rendered.Name != test.expected {
+				t.Fatalf("expected %q, got %q", test.expected, rendered.Name)
+			}
+		})
+	}
+}
+
+func TestRenderFields(t *testing.T) {
+	p := payload.RESTPayload{QueuePayload: payload.QueuePayload{
+		Name:     "job-{{ .JobIndex }}",
+		Metadata: map[string]string{"BUILD": "{{ .JobIndex }}"},
+		Steps: []payload.Step{{
+			ID:          1,
+			Name:        "step-{{ .JobIndex }}",
+			Image:       "image:{{ .JobIndex }}",
+			Script:      "echo {{ .JobIndex }}",
+			MemoryLimit: "{{ .JobIndex }}G",
+			Metadata:    map[string]string{"ENDPOINT": "{{ .ResultURL }}"},
+		}},
+	}}
+
+	rendered, err := testRenderer().Render(p, testRunID, 4, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rendered.ID != jobUUID(testRunID, 4, false) {
+		t.Fatalf("expected the payload ID to be the job UUID, got %s", rendered.ID)
+	}
+	step := rendered.Steps[0]
+	got := []string{rendered.Name, rendered.Metadata["BUILD"], step.Name, step.Image, step.Script, step.MemoryLimit, step.Metadata["ENDPOINT"]}
+	expected := []string{"job-4", "4", "step-4", "image:4", "echo 4", "4G", "http://benchmarker/v1/result"}
+	if !reflect.DeepEqual(got, expected) {
+		t.Fatalf("expected %v, got %v", expected, got)
+	}
+
+	// The templates are shared by all jobs of a run and must not be overwritten
+	if p.Metadata["BUILD"] != "{{ .JobIndex }}" || p.Steps[0].Metadata["ENDPOINT"] != "{{ .ResultURL }}" {
+		t.Fatalf("expected the payload to be unchanged, got %+v", p)
+	}
+}
+
+func TestRenderRandom(t *testing.T) {
+	tests := []struct {
+		name    string
+		text    string
+		allowed []string
+		err     string
+	}{
+		{"choice of a list", "{{ choice .Choices.repos }}", []string{"a", "b", "c"}, ""},
+		{"choice of arguments", `{{ choice "x" "y" }}`, []string{"x", "y"}, ""},
+		{"randInt", "{{ randInt 3 }}", []string{"0", "1", "2"}, ""},
+		{"matching elements", "{{ $i := randInt 3 }}{{ index .Choices.repos $i }}", []string{"a", "b", "c"}, ""},
+		{"choice of nothing", "{{ choice }}", nil, "empty list"},
+		{"choice of an empty list", "{{ choice .Choices.empty }}", nil, "empty list"},
+		{"randInt without a positive bound", "{{ randInt 0 }}", nil, "positive bound"},
+	}
+	renderer := testRenderer()
+	renderer.Choices["empty"] = []string{}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			seen := make(map[string]bool)
+			for jobIndex := range 50 {
+				rendered, err := renderer.Render(templatePayload(test.text), testRunID, jobIndex, false)
+				if test.err != "" {
+					if err == nil || !strings.Contains(err.Error(), test.err) {
+						t.Fatalf("expected an error containing %q, got %v", test.err, err)
+					}
+					return
+				}
+				if err != nil {
+					t.Fatal(err)
+				}
+				if !slices.Contains(test.allowed, rendered.Name) {
+					t.Fatalf("job %d: expected one of %v, got %q", jobIndex, test.allowed, rendered.Name)
+				}
+				seen[rendered.Name] = true
+			}
+			// The jobs of a run differ from each other
+			if len(seen) < 2 {
+				t.Fatalf("expected different values for the jobs, got %v", seen)
+			}
+		})
+	}
+}
+
+func TestRenderDeterministic(t *testing.T) {
+	p := payload.RESTPayload{QueuePayload: payload.QueuePayload{
+		Name:     "{{ choice .Choices.repos }}-{{ randInt 1000 }}",
+		Metadata: map[string]string{"A": "{{ randInt 1000 }}", "B": "{{ randInt 1000 }}", "C": "{{ choice .Choices.repos }}"},
+	}}
+	render := func(runID uuid.UUID, jobIndex int, warmup bool) payload.RESTPayload {
+		t.Helper()
+		rendered, err := testRenderer().Render(p, runID, jobIndex, warmup)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return rendered
+	}
+
+	first := render(testRunID, 7, false)
+	for range 10 {
+		if again := render(testRunID, 7, false); !reflect.DeepEqual(first, again) {
+			t.Fatalf("expected rendering a job again to yield %+v, got %+v", first, again)
+		}
+	}
+	if warmup := render(testRunID, 7, true); warmup.ID == first.ID {
+		t.Fatal("expected warm-up jobs to have different IDs")
+	}
+	if other := render(uuid.New(), 7, false); other.ID == first.ID {
+		t.Fatal("expected the jobs of different runs to have different IDs")
+	}
+}
+
+func TestJobUUID(t *testing.T) {
+	if jobUUID(testRunID, 1, false) != jobUUID(testRunID, 1, false) {
+		t.Fatal("expected the job UUID to be deterministic")
+	}
+	// Jobs are identified by their run, index and whether they are warm-up jobs
+	ids := []uuid.UUID{
+		jobUUID(testRunID, 1, false),
+		jobUUID(testRunID, 2, false),
+		jobUUID(testRunID, 1, true),
+		jobUUID(uuid.New(), 1, false),
+	}
+	for i := range ids {
+		for j := range i {
+			if ids[i] == ids[j] {
+				t.Fatalf("expected distinct job UUIDs, got %s twice", ids[i])
+			}
+		}
+	}
+	if expected := uuid.NewSHA1(testRunID, []byte("job-1")); ids[0] != expected {
+		t.Fatalf("expected %s, got %s", expected, ids[0])
+	}
+}
+
+func TestCheck(t *testing.T) {
+	if err := Check(templatePayload("{{ choice .Choices.repos }} {{ randInt 3 }}")); err != nil {
+		t.Fatalf("expected a valid payload, got %v", err)
+	}
+	p := payload.RESTPayload{QueuePayload: payload.QueuePayload{
+		Name:  "job",
+		Steps: []payload.Step{{ID: 1, Metadata: map[string]string{"KEY": "{{ .JobIndex"}}},
+	}}
+	if err := Check(p); err == nil || !strings.Contains(err.Error(), "steps[0].metadata.KEY") {
+		t.Fatalf("expected an error for steps[0].metadata.KEY, got %v", err)
+	}
+}
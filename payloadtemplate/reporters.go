@@ -0,0 +1,82 @@
+package payloadtemplate
+
+import (
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/ls1intum/hades/shared/payload"
+)
+
+// Default images of the reporter steps
+const (
+	DefaultStartReporterImage  = "ghcr.io/ls1intum/hades-reporter/hades-reporter:latest"
+	DefaultResultReporterImage = "ghcr.io/ls1intum/hades/junit-result-parser:latest"
+)
+
+// Reporters are the steps which report the start time and the result of a job to the benchmarker
+type Reporters struct {
+	// BaseURL is the externally reachable URL of the benchmarker
+	BaseURL     string
+	StartImage  string
+	ResultImage string
+	// ResultMetadata is added to the metadata of the result step, e.g. INGEST_DIR
+	ResultMetadata map[string]string
+}
+
+// Inject returns a copy of p with a start reporter step before and a result reporter step after its
+// steps. The steps are renumbered accordingly. Reporter steps which p already contains, identified by
+// their image, are not added again.
+func (r Reporters) Inject(p payload.RESTPayload) payload.RESTPayload {
+	p = clone(p)
+
+	var hasStart, hasResult bool
+	for _, step := range p.Steps {
+		hasStart = hasStart || sameImage(step.Image, r.StartImage)
+		hasResult = hasResult || sameImage(step.Image, r.ResultImage)
+	}
+
+	steps := make([]payload.Step, 0, len(p.Steps)+2)
+	if !hasStart {
+		steps = append(steps, payload.Step{
+			Name:     "Report Starting Time",
+			Image:    r.StartImage,
+			Metadata: map[string]string{"ENDPOINT": r.BaseURL + "/v1/start_time"},
+		})
+	}
+	// Hades executes the steps in the order of their IDs, which is kept when they are renumbered
+	existing := slices.Clone(p.Steps)
+	slices.SortStableFunc(existing, func(a, b payload.Step) int { return a.ID - b.ID })
+	steps = append(steps, existing...)
+	if !hasResult {
+		metadata := maps.Clone(r.ResultMetadata)
+		if metadata == nil {
+			metadata = make(map[string]string)
+		}
+		metadata["API_ENDPOINT"] = r.BaseURL + "/v1/result"
+		steps = append(steps, payload.Step{
+			Name:     "Result",
+			Image:    r.ResultImage,
+			Metadata: metadata,
+		})
+	}
+
+	for i := range steps {
+		steps[i].ID = i + 1
+	}
+	p.Steps = steps
+	return p
+}
+
+// sameImage compares two images ignoring their tags
+func sameImage(a string, b string) bool {
+	return a != "" && b != "" && imageName(a) == imageName(b)
+}
+
+func imageName(image string) string {
+	// A colon after the last slash separates the tag, earlier ones belong to the registry port
+	if i := strings.LastIndex(image, ":"); i > strings.LastIndex(image, "/") {
+		return image[:i]
+	}
+	return image
+}
@@ -0,0 +1,116 @@
+package payloadtemplate
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/ls1intum/hades/shared/payload"
+)
+
+func testReporters() Reporters {
+	return Reporters{
+		BaseURL:     "http://benchmarker",
+		StartImage:  DefaultStartReporterImage,
+		ResultImage: DefaultResultReporterImage,
+	}
+}
+
+// stepNames returns the names of the steps after checking that they are numbered from 1 in order
+func stepNames(t *testing.T, steps []payload.Step) []string {
+	t.Helper()
+	names := make([]string, 0, len(steps))
+	for i, step := range steps {
+		if step.ID != i+1 {
+			t.Fatalf("step %q: expected ID %d, got %d", step.Name, i+1, step.ID)
+		}
+		names = append(names, step.Name)
+	}
+	return names
+}
+
+func TestInject(t *testing.T) {
+	p := payload.RESTPayload{QueuePayload: payload.QueuePayload{
+		Name: "job",
+		Steps: []payload.Step{
+			{ID: 3, Name: "Test", Image: "gradle"},
+			{ID: 1, Name: "Clone", Image: "clone"},
+			{ID: 2, Name: "Build", Image: "gradle"},
+		},
+	}}
+
+	injected := testReporters().Inject(p)
+	expected := []string{"Report Starting Time", "Clone", "Build", "Test", "Result"}
+	if names := stepNames(t, injected.Steps); !reflect.DeepEqual(names, expected) {
+		t.Fatalf("expected steps %v, got %v", expected, names)
+	}
+
+	start, result := injected.Steps[0], injected.Steps[4]
+	if start.Image != DefaultStartReporterImage || start.Metadata["ENDPOINT"] != "http://benchmarker/v1/start_time" {
+		t.Fatalf("unexpected start step %+v", start)
+	}
+	if result.Image != DefaultResultReporterImage || !reflect.DeepEqual(result.Metadata, map[string]string{"API_ENDPOINT": "http://benchmarker/v1/result"}) {
+		t.Fatalf("unexpected result step %+v", result)
+	}
+
+	// The payload itself is left unchanged
+	if p.Steps[0].ID != 3 || len(p.Steps) != 3 {
+		t.Fatalf("expected the payload to be unchanged, got %+v", p.Steps)
+	}
+}
+
+func TestInjectResultMetadata(t *testing.T) {
+	reporters := testReporters()
+	reporters.ResultMetadata = map[string]string{"INGEST_DIR": "/results"}
+
+	injected := reporters.Inject(payload.RESTPayload{QueuePayload: payload.QueuePayload{Name: "job"}})
+	expected := []string{"Report Starting Time", "Result"}
+	if names := stepNames(t, injected.Steps); !reflect.DeepEqual(names, expected) {
+		t.Fatalf("expected steps %v, got %v", expected, names)
+	}
+	metadata := map[string]string{"INGEST_DIR": "/results", "API_ENDPOINT": "http://benchmarker/v1/result"}
+	if !reflect.DeepEqual(injected.Steps[1].Metadata, metadata) {
+		t.Fatalf("expected result metadata %v, got %v", metadata, injected.Steps[1].Metadata)
+	}
+	if len(reporters.ResultMetadata) != 1 {
+		t.Fatalf("expected the configured metadata to be unchanged, got %v", reporters.ResultMetadata)
+	}
+}
+
+func TestInjectKeepsExistingReporters(t *testing.T) {
+	p := payload.RESTPayload{QueuePayload: payload.QueuePayload{
+		Name: "job",
+		Steps: []payload.Step{
+			{ID: 1, Name: "Own Start", Image: "ghcr.io/ls1intum/hades-reporter/hades-reporter:v1"},
+			{ID: 2, Name: "Build", Image: "gradle"},
+		},
+	}}
+
+	injected := testReporters().Inject(p)
+	expected := []string{"Own Start", "Build", "Result"}
+	if names := stepNames(t, injected.Steps); !reflect.DeepEqual(names, expected) {
+		t.Fatalf("expected steps %v, got %v", expected, names)
+	}
+
+	// Injecting twice does not add the reporters again
+	again := testReporters().Inject(injected)
+	if names := stepNames(t, again.Steps); !reflect.DeepEqual(names, expected) {
+		t.Fatalf("expected steps %v, got %v", expected, names)
+	}
+}
+
+func TestSameImage(t *testing.T) {
+	tests := []struct {
+		a, b string
+		same bool
+	}{
+		{"ghcr.io/ls1intum/hades-reporter/hades-reporter:latest", "ghcr.io/ls1intum/hades-reporter/hades-reporter:v1", true},
+		{"registry:5000/reporter", "registry:5000/reporter:latest", true},
+		{"registry:5000/reporter", "registry:5001/reporter", false},
+		{"gradle", "", false},
+	}
+	for _, test := range tests {
+		if same := sameImage(test.a, test.b); same != test.same {
+			t.Errorf("sameImage(%q, %q): expected %v, got %v", test.a, test.b, test.same, same)
+		}
+	}
+}
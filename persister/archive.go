@@ -0,0 +1,335 @@
+package persister
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Mtze/CI-Benchmarker/persister/model"
+	"github.com/google/uuid"
+)
+
+// pruneBatchSize is the number of jobs archived and deleted in one transaction
+const pruneBatchSize = 500
+
+// ArchiveRecord is a line of a JSONL archive, it holds either a job with its times or a run with its
+// submission attempts
+type ArchiveRecord struct {
+	Job *ArchivedJob `json:"job,omitempty"`
+	Run *ArchivedRun `json:"run,omitempty"`
+}
+
+// ArchivedJob is a scheduled job with its labels and the times reported or observed for it
+type ArchivedJob struct {
+	ID                 uuid.UUID         `json:"id"`
+	CreationTime       time.Time         `json:"creation_time"`
+	Executor           string            `json:"executor"`
+	Labels             map[string]string `json:"labels,omitempty"`
+	CommitHash         *string           `json:"commit_hash,omitempty"`
+	Status             string            `json:"status"`
+	RunID              *uuid.UUID        `json:"run_id,omitempty"`
+	PayloadID          *uuid.UUID        `json:"payload_id,omitempty"`
+	PayloadVersion     *int64            `json:"payload_version,omitempty"`
+	PayloadHash        *string           `json:"payload_hash,omitempty"`
+	StartTime          *time.Time        `json:"start_time,omitempty"`
+	EndTime            *time.Time        `json:"end_time,omitempty"`
+	StartTimeCallbacks int64             `json:"start_time_callbacks,omitempty"`
+	EndTimeCallbacks   int64             `json:"end_time_callbacks,omitempty"`
+	Invalid            bool              `json:"invalid,omitempty"`
+}
+
+// ArchivedRun is a benchmark run with the attempts to submit its jobs
+type ArchivedRun struct {
+	ID           uuid.UUID         `json:"id"`
+	CreationTime time.Time         `json:"creation_time"`
+	Executor     string            `json:"executor"`
+	JobCount     int64             `json:"job_count"`
+	Concurrency  int64             `json:"concurrency"`
+	Status       string            `json:"status"`
+	Error        *string           `json:"error,omitempty"`
+	FinishTime   *time.Time        `json:"finish_time,omitempty"`
+	Scenario     *string           `json:"scenario,omitempty"`
+	Labels       map[string]string `json:"labels,omitempty"`
+	ExperimentID *uuid.UUID        `json:"experiment_id,omitempty"`
+	Attempts     []ArchivedAttempt `json:"attempts,omitempty"`
+}
+
+// ArchivedAttempt is an attempt to submit a job of a run
+type ArchivedAttempt struct {
+	JobIndex      int64      `json:"job_index"`
+	Attempt       int64      `json:"attempt"`
+	SubmitDelayMs int64      `json:"submit_delay_ms"`
+	StartTime     time.Time  `json:"start_time"`
+	DurationMs    int64      `json:"duration_ms"`
+	StatusCode    *int64     `json:"status_code,omitempty"`
+	Error         *string    `json:"error,omitempty"`
+	JobID         *uuid.UUID `json:"job_id,omitempty"`
+}
+
+// Archive receives the records of pruned jobs and runs. Flush is called before the records written
+// so far are deleted, it has to make them durable.
+type Archive interface {
+	Write(record ArchiveRecord) error
+	Flush() error
+}
+
+// PruneFilter selects the jobs to prune, unset conditions match all jobs
+type PruneFilter struct {
+	// Before prunes the jobs created before it
+	Before   *time.Time
+	Executor *string
+	RunID    *uuid.UUID
+}
+
+// PruneResult counts the pruned jobs and runs
+type PruneResult struct {
+	Jobs int
+	Runs int
+}
+
+// Prune writes the jobs matching filter to archive and deletes them afterwards, in batches. Runs matching
+// filter which are left without jobs are archived and deleted as well, unless they are still running.
+func (d DBPersister) Prune(ctx context.Context, filter PruneFilter, archive Archive) (PruneResult, error) {
+	var result PruneResult
+	params := model.ListJobsToPruneParams{
+		Before:    sql.NullTime{Valid: false},
+		Executor:  nullableString(filter.Executor),
+		RunID:     nullableUUID(filter.RunID),
+		BatchSize: pruneBatchSize,
+	}
+	if filter.Before != nil {
+		params.Before = sql.NullTime{Time: filter.Before.UTC(), Valid: true}
+	}
+
+	for ctx.Err() == nil {
+		jobs, err := d.queries.ListJobsToPrune(ctx, params)
+		if err != nil {
+			return result, err
+		}
+		if len(jobs) == 0 {
+			break
+		}
+
+		for _, job := range jobs {
+			record, err := archivedJob(job)
+			if err != nil {
+				return result, err
+			}
+			if err := archive.Write(ArchiveRecord{Job: &record}); err != nil {
+				return result, fmt.Errorf("failed to archive job %s: %w", job.ID, err)
+			}
+		}
+		if err := archive.Flush(); err != nil {
+			return result, fmt.Errorf("failed to flush archive: %w", err)
+		}
+
+		if err := d.inTx(func(ctx context.Context, queries model.Querier) error {
+			for _, job := range jobs {
+				if err := queries.DeleteJobResult(ctx, job.ID); err != nil {
+					return err
+				}
+				if err := queries.DeleteScheduledJob(ctx, job.ID); err != nil {
+					return err
+				}
+			}
+			return nil
+		}); err != nil {
+			return result, fmt.Errorf("failed to delete pruned jobs: %w", err)
+		}
+		result.Jobs += len(jobs)
+	}
+	if ctx.Err() != nil {
+		return result, ctx.Err()
+	}
+
+	runs, err := d.queries.ListRunsToPrune(ctx, model.ListRunsToPruneParams{
+		Before:   params.Before,
+		Executor: params.Executor,
+		RunID:    params.RunID,
+	})
+	if err != nil {
+		return result, err
+	}
+	for _, run := range runs {
+		record, err := d.archivedRun(ctx, run)
+		if err != nil {
+			return result, err
+		}
+		if err := archive.Write(ArchiveRecord{Run: &record}); err != nil {
+			return result, fmt.Errorf("failed to archive run %s: %w", run.ID, err)
+		}
+	}
+	if len(runs) == 0 {
+		return result, nil
+	}
+	if err := archive.Flush(); err != nil {
+		return result, fmt.Errorf("failed to flush archive: %w", err)
+	}
+
+	if err := d.inTx(func(ctx context.Context, queries model.Querier) error {
+		for _, run := range runs {
+			if err := queries.DeleteSubmissionAttemptsByRun(ctx, run.ID); err != nil {
+				return err
+			}
+			if err := queries.DeleteRun(ctx, run.ID); err != nil {
+				return err
+			}
+		}
+		return nil
+	}); err != nil {
+		return result, fmt.Errorf("failed to delete pruned runs: %w", err)
+	}
+	result.Runs = len(runs)
+	return result, nil
+}
+
+// JobCreationTimeAt returns the creation time of the job at offset, counted from the latest job.
+// It returns nil if there are not as many jobs.
+func (d DBPersister) JobCreationTimeAt(offset int) (*time.Time, error) {
+	creationTime, err := d.queries.GetJobCreationTimeAtOffset(context.Background(), int64(offset))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &creationTime, nil
+}
+
+// RunIDsAfter returns the IDs of all runs but the latest n
+func (d DBPersister) RunIDsAfter(n int) ([]uuid.UUID, error) {
+	return d.queries.ListRunIDsAfterOffset(context.Background(), int64(n))
+}
+
+func archivedJob(job model.ListJobsToPruneRow) (ArchivedJob, error) {
+	labels, err := decodeLabels(job.Metadata)
+	if err != nil {
+		return ArchivedJob{}, fmt.Errorf("failed to decode labels of job %s: %w", job.ID, err)
+	}
+	var start, end sql.NullTime
+	if err := start.Scan(job.StartTime); err != nil {
+		return ArchivedJob{}, err
+	}
+	if err := end.Scan(job.EndTime); err != nil {
+		return ArchivedJob{}, err
+	}
+
+	return ArchivedJob{
+		ID:                 job.ID,
+		CreationTime:       job.CreationTime.UTC(),
+		Executor:           job.Executor,
+		Labels:             labels,
+		CommitHash:         stringPtr(job.CommitHash),
+		Status:             job.Status,
+		RunID:              uuidPtr(job.RunID),
+		PayloadID:          uuidPtr(job.PayloadID),
+		PayloadVersion:     int64Ptr(job.PayloadVersion),
+		PayloadHash:        stringPtr(job.PayloadHash),
+		StartTime:          timePtr(start),
+		EndTime:            timePtr(end),
+		StartTimeCallbacks: job.StartTimeCallbacks.Int64,
+		EndTimeCallbacks:   job.EndTimeCallbacks.Int64,
+		Invalid:            job.Invalid.Bool,
+	}, nil
+}
+
+func (d DBPersister) archivedRun(ctx context.Context, run model.BenchmarkRun) (ArchivedRun, error) {
+	labels, err := RunLabels(run)
+	if err != nil {
+		return ArchivedRun{}, fmt.Errorf("failed to decode labels of run %s: %w", run.ID, err)
+	}
+	attempts, err := d.queries.ListSubmissionAttemptsByRun(ctx, run.ID)
+	if err != nil {
+		return ArchivedRun{}, err
+	}
+
+	record := ArchivedRun{
+		ID:           run.ID,
+		CreationTime: run.CreationTime.UTC(),
+		Executor:     run.Executor,
+		JobCount:     run.JobCount,
+		Concurrency:  run.Concurrency,
+		Status:       run.Status,
+		Error:        stringPtr(run.Error),
+		FinishTime:   timePtr(run.FinishTime),
+		Scenario:     stringPtr(run.Scenario),
+		Labels:       labels,
+		ExperimentID: uuidPtr(run.ExperimentID),
+	}
+	for _, attempt := range attempts {
+		record.Attempts = append(record.Attempts, ArchivedAttempt{
+			JobIndex:      attempt.JobIndex,
+			Attempt:       attempt.Attempt,
+			SubmitDelayMs: attempt.SubmitDelayMs,
+			StartTime:     attempt.StartTime.UTC(),
+			DurationMs:    attempt.DurationMs,
+			StatusCode:    int64Ptr(attempt.StatusCode),
+			Error:         stringPtr(attempt.Error),
+			JobID:         uuidPtr(attempt.JobID),
+		})
+	}
+	return record, nil
+}
+
+// decodeLabels decodes labels stored as JSON object, SQLite returns them as string or []byte
+func decodeLabels(value interface{}) (map[string]string, error) {
+	var data []byte
+	switch v := value.(type) {
+	case nil:
+		return nil, nil
+	case []byte:
+		data = v
+	case string:
+		data = []byte(v)
+	default:
+		return nil, fmt.Errorf("unexpected labels type %T", v)
+	}
+	if len(data) == 0 {
+		return nil, nil
+	}
+
+	var labels map[string]string
+	if err := json.Unmarshal(data, &labels); err != nil {
+		return nil, err
+	}
+	return labels, nil
+}
+
+func nullableString(s *string) sql.NullString {
+	if s == nil {
+		return sql.NullString{Valid: false}
+	}
+	return sql.NullString{String: *s, Valid: true}
+}
+
+func stringPtr(s sql.NullString) *string {
+	if !s.Valid {
+		return nil
+	}
+	return &s.String
+}
+
+func int64Ptr(i sql.NullInt64) *int64 {
+	if !i.Valid {
+		return nil
+	}
+	return &i.Int64
+}
+
+func timePtr(t sql.NullTime) *time.Time {
+	if !t.Valid {
+		return nil
+	}
+	utc := t.Time.UTC()
+	return &utc
+}
+
+func uuidPtr(id uuid.NullUUID) *uuid.UUID {
+	if !id.Valid {
+		return nil
+	}
+	return &id.UUID
+}
@@ -0,0 +1,50 @@
+package persister
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/mattn/go-sqlite3"
+)
+
+// ErrBackupUnsupported is returned by Backup for PostgreSQL databases, they are backed up with pg_dump
+var ErrBackupUnsupported = errors.New("backups are only supported for SQLite databases")
+
+// Backup writes a consistent snapshot of the SQLite database to the file at path, which is replaced if it exists.
+// It uses the online backup API of SQLite and copies the database in one step, writes wait until it is done.
+func (d DBPersister) Backup(ctx context.Context, path string) error {
+	if d.postgres {
+		return ErrBackupUnsupported
+	}
+
+	conn, err := d.db.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	return conn.Raw(func(driverConn any) error {
+		src, ok := driverConn.(*sqlite3.SQLiteConn)
+		if !ok {
+			return fmt.Errorf("unexpected connection type %T", driverConn)
+		}
+
+		destConn, err := (&sqlite3.SQLiteDriver{}).Open(path)
+		if err != nil {
+			return fmt.Errorf("failed to open backup %s: %w", path, err)
+		}
+		dest := destConn.(*sqlite3.SQLiteConn)
+		defer dest.Close()
+
+		backup, err := dest.Backup("main", src, "main")
+		if err != nil {
+			return fmt.Errorf("failed to start backup: %w", err)
+		}
+		if _, err := backup.Step(-1); err != nil {
+			backup.Finish()
+			return fmt.Errorf("failed to copy database: %w", err)
+		}
+		return backup.Finish()
+	})
+}
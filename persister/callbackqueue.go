@@ -0,0 +1,256 @@
+package persister
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/Mtze/CI-Benchmarker/persister/model"
+	"github.com/google/uuid"
+)
+
+// enqueueTimeout bounds how long a callback waits for space in a full queue
+const enqueueTimeout = 5 * time.Second
+
+// ErrQueueFull is returned for callbacks which found the queue full for longer than enqueueTimeout,
+// the database does not keep up with the callbacks
+var ErrQueueFull = errors.New("callback queue is full")
+
+// CallbackQueue is a persister which stores the times reported by the pipelines of jobs in batches. Every
+// batch is written in one transaction, which saves a commit per callback when many jobs finish at once.
+// A batch holds at most maxBatch callbacks, it takes the callbacks which queued up while the previous batch
+// was written and waits for more until its first callback waited for maxDelay. Callers wait for the answer
+// of their callback before they send the next one, so without maxDelay the batches stay small.
+//
+// StoreStartTime and StoreResult return once the batch of their callback is committed, so their errors
+// keep their meaning. They block while the queue is full and fail with ErrQueueFull after enqueueTimeout.
+// All other methods are passed on to the DBPersister. Close writes the queued callbacks.
+type CallbackQueue struct {
+	DBPersister
+	requests chan callbackRequest
+	maxBatch int
+	maxDelay time.Duration
+	// closing is closed by Close, callbacks are stored directly once the queue is closed
+	closing   chan struct{}
+	closeOnce sync.Once
+	done      chan struct{}
+}
+
+type callbackRequest struct {
+	jobID    uuid.UUID
+	callback string
+	reported time.Time
+	result   chan error
+}
+
+// NewCallbackQueue creates a queue for size callbacks which writes batches of at most maxBatch callbacks
+// to d, a batch waits at most maxDelay to fill
+func NewCallbackQueue(d DBPersister, size int, maxBatch int, maxDelay time.Duration) *CallbackQueue {
+	q := &CallbackQueue{
+		DBPersister: d,
+		requests:    make(chan callbackRequest, size),
+		maxBatch:    maxBatch,
+		maxDelay:    maxDelay,
+		closing:     make(chan struct{}),
+		done:        make(chan struct{}),
+	}
+	go q.run()
+	return q
+}
+
+func (q *CallbackQueue) StoreStartTime(uuid uuid.UUID, startTime time.Time) error {
+	if err := q.enqueue(uuid, CallbackStartTime, startTime); err != nil {
+		return fmt.Errorf("failed to store start time of job %s: %w", uuid, err)
+	}
+	return nil
+}
+
+func (q *CallbackQueue) StoreResult(uuid uuid.UUID, endTime time.Time) error {
+	if err := q.enqueue(uuid, CallbackResult, endTime); err != nil {
+		return fmt.Errorf("failed to store result of job %s: %w", uuid, err)
+	}
+	return nil
+}
+
+// Close writes the queued callbacks and stops the queue, callbacks which arrive later are stored directly.
+// The DBPersister is left open.
+func (q *CallbackQueue) Close() {
+	q.closeOnce.Do(func() { close(q.closing) })
+	<-q.done
+}
+
+func (q *CallbackQueue) enqueue(jobID uuid.UUID, callback string, reported time.Time) error {
+	request := callbackRequest{jobID: jobID, callback: callback, reported: reported, result: make(chan error, 1)}
+
+	timer := time.NewTimer(enqueueTimeout)
+	defer timer.Stop()
+	select {
+	case q.requests <- request:
+	case <-q.closing:
+		return q.storeCallback(jobID, callback, reported)
+	case <-timer.C:
+		return ErrQueueFull
+	}
+
+	select {
+	case err := <-request.result:
+		return err
+	case <-q.done:
+		// The queue stopped, the callback was either written before or is left in the queue
+		select {
+		case err := <-request.result:
+			return err
+		default:
+			return q.storeCallback(jobID, callback, reported)
+		}
+	}
+}
+
+// run writes batches of callbacks until the queue is closed and empty
+func (q *CallbackQueue) run() {
+	defer close(q.done)
+	slog.Debug("Starting callback queue", slog.Int("maxBatch", q.maxBatch), slog.Duration("maxDelay", q.maxDelay))
+
+	for {
+		select {
+		case request := <-q.requests:
+			q.write(q.collect(request))
+		case <-q.closing:
+			for {
+				select {
+				case request := <-q.requests:
+					q.write(q.collect(request))
+				default:
+					return
+				}
+			}
+		}
+	}
+}
+
+// collect returns a batch of first and the callbacks which queued up while the previous batch was written.
+// If the queue runs empty before the batch is full, it waits for more callbacks until first waited for maxDelay.
+func (q *CallbackQueue) collect(first callbackRequest) []callbackRequest {
+	batch := []callbackRequest{first}
+	var timeout <-chan time.Time
+	if q.maxDelay > 0 {
+		timer := time.NewTimer(q.maxDelay)
+		defer timer.Stop()
+		timeout = timer.C
+	}
+
+	for len(batch) < q.maxBatch {
+		select {
+		case request := <-q.requests:
+			batch = append(batch, request)
+			continue
+		default:
+		}
+		if timeout == nil {
+			return batch
+		}
+
+		select {
+		case request := <-q.requests:
+			batch = append(batch, request)
+		case <-q.closing:
+			return batch
+		case <-timeout:
+			return batch
+		}
+	}
+	return batch
+}
+
+// write stores a batch in one transaction and answers its callbacks. If the transaction fails, the
+// callbacks are stored one by one, so a single failing callback does not fail the others.
+func (q *CallbackQueue) write(batch []callbackRequest) {
+	orphans := make([]bool, len(batch))
+	err := withRetry(func(ctx context.Context) error {
+		tx, err := q.db.BeginTx(ctx, nil)
+		if err != nil {
+			return err
+		}
+		defer tx.Rollback()
+
+		// The callbacks of a batch run the same queries, they are prepared once
+		queries := q.newQueries(&preparedConn{db: tx})
+		for i, request := range batch {
+			orphan, err := q.storeCallbackTx(ctx, queries, request.jobID, request.callback, request.reported)
+			if err != nil {
+				return err
+			}
+			orphans[i] = orphan
+		}
+		return tx.Commit()
+	})
+
+	if err != nil && len(batch) > 1 {
+		slog.Warn("Failed to store batch of callbacks, storing them one by one", slog.Int("callbacks", len(batch)), slog.Any("error", err))
+		for _, request := range batch {
+			request.result <- q.storeCallback(request.jobID, request.callback, request.reported)
+		}
+		return
+	}
+	for i, request := range batch {
+		switch {
+		case err != nil:
+			request.result <- err
+		case orphans[i]:
+			request.result <- ErrUnknownJob
+		default:
+			request.result <- nil
+		}
+	}
+}
+
+// preparedConn prepares every query once and reuses the statement, the statements of a transaction
+// are closed with it
+type preparedConn struct {
+	db         model.DBTX
+	statements map[string]*sql.Stmt
+}
+
+func (c *preparedConn) PrepareContext(ctx context.Context, query string) (*sql.Stmt, error) {
+	if statement, ok := c.statements[query]; ok {
+		return statement, nil
+	}
+	statement, err := c.db.PrepareContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	if c.statements == nil {
+		c.statements = make(map[string]*sql.Stmt)
+	}
+	c.statements[query] = statement
+	return statement, nil
+}
+
+func (c *preparedConn) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	statement, err := c.PrepareContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	return statement.ExecContext(ctx, args...)
+}
+
+func (c *preparedConn) QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	statement, err := c.PrepareContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	return statement.QueryContext(ctx, args...)
+}
+
+func (c *preparedConn) QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row {
+	statement, err := c.PrepareContext(ctx, query)
+	if err != nil {
+		// The row reports the error of the query
+		return c.db.QueryRowContext(ctx, query, args...)
+	}
+	return statement.QueryRowContext(ctx, args...)
+}
@@ -0,0 +1,173 @@
+package persister
+
+import (
+	"errors"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestCallbackQueue(t *testing.T) {
+	p, err := NewDBPersister(InMemory)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer p.Close()
+	q := NewCallbackQueue(p, 10, 5, time.Millisecond)
+
+	created := time.Date(2025, 2, 4, 11, 0, 0, 0, time.UTC)
+	jobs := make([]uuid.UUID, 20)
+	for i := range jobs {
+		jobs[i] = uuid.New()
+		if err := p.StoreJob(jobs[i], created, "test", nil, nil, JobPayload{}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 2*len(jobs)+1)
+	for _, id := range jobs {
+		wg.Add(1)
+		go func(id uuid.UUID) {
+			defer wg.Done()
+			errs <- q.StoreStartTime(id, created.Add(10*time.Second))
+			errs <- q.StoreResult(id, created.Add(70*time.Second))
+		}(id)
+	}
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		errs <- q.StoreResult(uuid.New(), created)
+	}()
+	wg.Wait()
+	close(errs)
+
+	unknown := 0
+	for err := range errs {
+		if errors.Is(err, ErrUnknownJob) {
+			unknown++
+		} else if err != nil {
+			t.Fatal(err)
+		}
+	}
+	if unknown != 1 {
+		t.Fatalf("expected the callback of the unknown job to fail, got %d failures", unknown)
+	}
+
+	buildTimes, err := p.GetBuildTimesInRange(MetricsFilter{Executor: "test"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(buildTimes) != len(jobs) {
+		t.Fatalf("expected %d build times, got %d", len(jobs), len(buildTimes))
+	}
+
+	// Callbacks after closing the queue are stored directly
+	q.Close()
+	if err := q.StoreResult(jobs[0], created.Add(80*time.Second)); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestCallbackQueueCloseWhileFull(t *testing.T) {
+	p, err := NewDBPersister(InMemory)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer p.Close()
+
+	created := time.Date(2025, 2, 4, 11, 0, 0, 0, time.UTC)
+	queued, blocked := uuid.New(), uuid.New()
+	for _, id := range []uuid.UUID{queued, blocked} {
+		if err := p.StoreJob(id, created, "test", nil, nil, JobPayload{}); err != nil {
+			t.Fatal(err)
+		}
+		if err := p.StoreStartTime(id, created.Add(10*time.Second)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// The queue is full and not written yet, so the second callback blocks
+	q := &CallbackQueue{DBPersister: p, requests: make(chan callbackRequest, 1), maxBatch: 1, closing: make(chan struct{}), done: make(chan struct{})}
+	q.requests <- callbackRequest{jobID: queued, callback: CallbackResult, reported: created.Add(time.Minute), result: make(chan error, 1)}
+	errs := make(chan error, 1)
+	go func() { errs <- q.StoreResult(blocked, created.Add(time.Minute)) }()
+	time.Sleep(10 * time.Millisecond)
+
+	// Closing does not wait for the blocked callback, which is stored either by the queue or directly
+	go q.run()
+	start := time.Now()
+	q.Close()
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Fatalf("expected Close to return at once, took %s", elapsed)
+	}
+	if err := <-errs; err != nil {
+		t.Fatal(err)
+	}
+
+	buildTimes, err := p.GetBuildTimesInRange(MetricsFilter{Executor: "test"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(buildTimes) != 2 {
+		t.Fatalf("expected 2 build times, got %d", len(buildTimes))
+	}
+}
+
+// BenchmarkCallbacks compares storing concurrent callbacks one transaction each with the callback queue,
+// run it with go test -run '^$' -bench Callbacks ./persister
+func BenchmarkCallbacks(b *testing.B) {
+	b.Run("direct", func(b *testing.B) {
+		p := benchmarkPersister(b)
+		benchmarkCallbacks(b, p, p)
+	})
+	b.Run("batched", func(b *testing.B) {
+		p := benchmarkPersister(b)
+		q := NewCallbackQueue(p, 1000, 100, 100*time.Microsecond)
+		defer q.Close()
+		benchmarkCallbacks(b, p, q)
+	})
+}
+
+// benchmarkPersister opens a database file, commits to it are synced to disk like in production
+func benchmarkPersister(b *testing.B) DBPersister {
+	p, err := NewDBPersister(filepath.Join(b.TempDir(), "benchmark.db"))
+	if err != nil {
+		b.Fatal(err)
+	}
+	b.Cleanup(func() { p.Close() })
+	return p
+}
+
+func benchmarkCallbacks(b *testing.B, p DBPersister, callbacks Persister) {
+	created := time.Now()
+	jobs := make([]uuid.UUID, 500)
+	for i := range jobs {
+		jobs[i] = uuid.New()
+		if err := p.StoreJob(jobs[i], created, "benchmark", nil, nil, JobPayload{}); err != nil {
+			b.Fatal(err)
+		}
+	}
+
+	// Hundreds of jobs report their times at once, the next callback of a job is sent once the previous one is answered
+	b.SetParallelism(50)
+	b.ResetTimer()
+	var mu sync.Mutex
+	next := 0
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			mu.Lock()
+			id := jobs[next%len(jobs)]
+			next++
+			mu.Unlock()
+			if err := callbacks.StoreResult(id, created.Add(time.Minute)); err != nil {
+				b.Error(err)
+				return
+			}
+		}
+	})
+	b.ReportMetric(float64(b.N)/b.Elapsed().Seconds(), "callbacks/s")
+}
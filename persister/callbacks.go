@@ -0,0 +1,200 @@
+package persister
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sort"
+	"time"
+
+	"github.com/Mtze/CI-Benchmarker/persister/model"
+	"github.com/google/uuid"
+)
+
+// Callbacks the pipeline of a job reports its times with
+const (
+	CallbackStartTime = "start_time"
+	CallbackResult    = "result"
+)
+
+// ErrUnknownJob is returned for callbacks of jobs which are not scheduled, see ListOrphanCallbacks
+var ErrUnknownJob = errors.New("unknown job")
+
+// WritePolicy decides which time is kept if a time of a job is reported more than once,
+// e.g. because a reporter retried its callback or the pipeline was restarted
+type WritePolicy string
+
+const (
+	FirstWriteWins WritePolicy = "first"
+	LastWriteWins  WritePolicy = "last"
+)
+
+// ParseWritePolicy parses "first" or "last"
+func ParseWritePolicy(policy string) (WritePolicy, error) {
+	switch p := WritePolicy(policy); p {
+	case FirstWriteWins, LastWriteWins:
+		return p, nil
+	}
+	return "", fmt.Errorf("unknown write policy %q, expected first or last", policy)
+}
+
+// CallbackPolicy holds the write policies of the times reported by callbacks, unset policies are last-write-wins.
+// Times reported by callbacks replace observed times regardless of the policy.
+type CallbackPolicy struct {
+	StartTime WritePolicy
+	Result    WritePolicy
+}
+
+// WithCallbackPolicy returns a copy of the persister which applies policy to the times reported by callbacks
+func (d DBPersister) WithCallbackPolicy(policy CallbackPolicy) DBPersister {
+	d.callbackPolicy = policy
+	return d
+}
+
+// jobTimes are the stored times of a job and the number of callbacks which reported them
+type jobTimes struct {
+	start, end                   sql.NullTime
+	startCallbacks, endCallbacks int64
+}
+
+// report applies a time reported by a callback to a time and its number of callbacks
+func report(current *sql.NullTime, callbacks *int64, reported time.Time, policy WritePolicy) {
+	if *callbacks == 0 || policy != FirstWriteWins {
+		*current = sql.NullTime{Time: reported.UTC(), Valid: true}
+	}
+	*callbacks++
+}
+
+// invalid reports whether the job finished before it started, its build time is left out of the metrics
+func (t jobTimes) invalid() bool {
+	return t.start.Valid && t.end.Valid && t.end.Time.Before(t.start.Time)
+}
+
+// updateJobTimes applies update to the stored times of a job
+func updateJobTimes(ctx context.Context, queries model.Querier, jobID uuid.UUID, update func(times *jobTimes)) error {
+	var times jobTimes
+	stored, err := queries.GetJobResult(ctx, jobID)
+	if err == nil {
+		if err := times.start.Scan(stored.StartTime); err != nil {
+			return err
+		}
+		if err := times.end.Scan(stored.EndTime); err != nil {
+			return err
+		}
+		times.startCallbacks, times.endCallbacks = stored.StartTimeCallbacks, stored.EndTimeCallbacks
+	} else if !errors.Is(err, sql.ErrNoRows) {
+		return err
+	}
+
+	update(&times)
+
+	if times.invalid() && !stored.Invalid {
+		slog.Warn("Job finished before it started, its build time is left out", slog.Any("uuid", jobID),
+			slog.Time("start", times.start.Time), slog.Time("end", times.end.Time))
+	}
+	return queries.SaveJobResult(ctx, model.SaveJobResultParams{
+		ID:                 jobID,
+		StartTime:          times.start,
+		EndTime:            times.end,
+		StartTimeCallbacks: times.startCallbacks,
+		EndTimeCallbacks:   times.endCallbacks,
+		Invalid:            times.invalid(),
+	})
+}
+
+// inTx runs op in a transaction, which is retried while the database is busy
+func (d DBPersister) inTx(op func(ctx context.Context, queries model.Querier) error) error {
+	return withRetry(func(ctx context.Context) error {
+		tx, err := d.db.BeginTx(ctx, nil)
+		if err != nil {
+			return err
+		}
+		defer tx.Rollback()
+
+		if err := op(ctx, d.withTx(tx)); err != nil {
+			return err
+		}
+		return tx.Commit()
+	})
+}
+
+// storeJob stores a job with store and applies the callbacks which arrived before, in the same transaction.
+// Jobs are stored once their submission returned, a fast pipeline may report its start time before.
+func (d DBPersister) storeJob(jobID uuid.UUID, store func(ctx context.Context, queries model.Querier) error) error {
+	return d.inTx(func(ctx context.Context, queries model.Querier) error {
+		if err := store(ctx, queries); err != nil {
+			return err
+		}
+		callbacks, err := queries.ClaimOrphanCallbacks(ctx, jobID)
+		if err != nil {
+			return err
+		}
+		sort.Slice(callbacks, func(i, j int) bool { return callbacks[i].ID < callbacks[j].ID })
+		for _, callback := range callbacks {
+			if err := d.applyCallback(ctx, queries, jobID, callback.Callback, callback.ReportedTime); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// storeCallback stores a time reported by the pipeline of a job, or an orphan callback if the job is not scheduled
+func (d DBPersister) storeCallback(jobID uuid.UUID, callback string, reported time.Time) error {
+	orphan := false
+	if err := d.inTx(func(ctx context.Context, queries model.Querier) error {
+		var err error
+		orphan, err = d.storeCallbackTx(ctx, queries, jobID, callback, reported)
+		return err
+	}); err != nil {
+		return err
+	}
+
+	if orphan {
+		return ErrUnknownJob
+	}
+	return nil
+}
+
+// storeCallbackTx is storeCallback within a transaction, it reports whether the callback was stored as orphan callback
+func (d DBPersister) storeCallbackTx(ctx context.Context, queries model.Querier, jobID uuid.UUID, callback string, reported time.Time) (bool, error) {
+	jobs, err := queries.CountScheduledJobs(ctx, jobID)
+	if err != nil {
+		return false, err
+	}
+	if jobs == 0 {
+		return true, queries.StoreOrphanCallback(ctx, model.StoreOrphanCallbackParams{
+			JobID:        jobID,
+			Callback:     callback,
+			ReportedTime: reported.UTC(),
+			ReceivedAt:   time.Now().UTC(),
+		})
+	}
+	return false, d.applyCallback(ctx, queries, jobID, callback, reported)
+}
+
+// applyCallback applies a reported time to a job according to the callback policy and counts the callback
+func (d DBPersister) applyCallback(ctx context.Context, queries model.Querier, jobID uuid.UUID, callback string, reported time.Time) error {
+	if callback != CallbackStartTime && callback != CallbackResult {
+		return fmt.Errorf("unknown callback %s", callback)
+	}
+	return updateJobTimes(ctx, queries, jobID, func(times *jobTimes) {
+		callbacks := &times.startCallbacks
+		if callback == CallbackStartTime {
+			report(&times.start, callbacks, reported, d.callbackPolicy.StartTime)
+		} else {
+			callbacks = &times.endCallbacks
+			report(&times.end, callbacks, reported, d.callbackPolicy.Result)
+		}
+		if *callbacks > 1 {
+			slog.Debug("Received duplicate callback", slog.Any("uuid", jobID), slog.String("callback", callback))
+		}
+	})
+}
+
+// ListOrphanCallbacks returns the latest callbacks received for jobs which are not scheduled, at most limit
+func (d DBPersister) ListOrphanCallbacks(limit int) ([]model.OrphanCallback, error) {
+	return d.queries.ListOrphanCallbacks(context.Background(), int64(limit))
+}
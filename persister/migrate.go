@@ -0,0 +1,185 @@
+package persister
+
+import (
+	"context"
+	"embed"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Mtze/CI-Benchmarker/persister/model"
+)
+
+//go:embed migrations
+var migrationFiles embed.FS
+
+// migrationsTable has to exist before the applied migrations can be looked up. The first migration
+// creates it as well, so it is part of the schema sqlc generates the code from.
+const migrationsTable = `CREATE TABLE IF NOT EXISTS schema_migrations
+(
+    version    integer   PRIMARY KEY,
+    name       text      NOT NULL,
+    applied_at timestamp NOT NULL
+)`
+
+// postgresMigrationLock serializes the migrations of benchmarkers sharing a PostgreSQL database
+const postgresMigrationLock = 7_300_361
+
+// Migration is a numbered up-migration of the schema, read from migrations/<dialect>/<version>_<name>.up.sql
+type Migration struct {
+	Version int64
+	Name    string
+	SQL     string
+}
+
+// migrations returns the migrations of a dialect ordered by their version.
+// Versions have to start at 1 and must not have gaps.
+func migrations(dialect string) ([]Migration, error) {
+	dir := path.Join("migrations", dialect)
+	entries, err := fs.ReadDir(migrationFiles, dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var result []Migration
+	for _, entry := range entries {
+		base, ok := strings.CutSuffix(entry.Name(), ".up.sql")
+		if !ok {
+			continue
+		}
+		number, name, ok := strings.Cut(base, "_")
+		if !ok {
+			return nil, fmt.Errorf("migration %s is not named <version>_<name>.up.sql", entry.Name())
+		}
+		version, err := strconv.ParseInt(number, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("migration %s has an invalid version: %w", entry.Name(), err)
+		}
+		content, err := fs.ReadFile(migrationFiles, path.Join(dir, entry.Name()))
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, Migration{Version: version, Name: name, SQL: string(content)})
+	}
+
+	sort.Slice(result, func(i, j int) bool { return result[i].Version < result[j].Version })
+	for i, m := range result {
+		if m.Version != int64(i+1) {
+			return nil, fmt.Errorf("expected migration %d of %s, found %d_%s", i+1, dialect, m.Version, m.Name)
+		}
+	}
+	return result, nil
+}
+
+func (d DBPersister) dialect() string {
+	if d.postgres {
+		return "postgres"
+	}
+	return "sqlite"
+}
+
+// SchemaVersion returns the version of the latest migration applied to the database
+func (d DBPersister) SchemaVersion() (int64, error) {
+	return d.queries.GetSchemaVersion(context.Background())
+}
+
+// PendingMigrations returns the migrations which are not yet applied to the database
+func (d DBPersister) PendingMigrations() ([]Migration, error) {
+	all, err := migrations(d.dialect())
+	if err != nil {
+		return nil, err
+	}
+
+	ctx := context.Background()
+	if _, err := d.db.ExecContext(ctx, migrationsTable); err != nil {
+		return nil, fmt.Errorf("failed to create schema_migrations: %w", err)
+	}
+	version, err := d.queries.GetSchemaVersion(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if version >= int64(len(all)) {
+		return nil, nil
+	}
+	return all[version:], nil
+}
+
+// Migrate applies the migrations which are not yet recorded in schema_migrations and returns them.
+// Every migration is applied in its own transaction. A database created before migrations were
+// introduced has no schema_migrations table and is treated like an empty one, its tables are kept
+// by the first migration.
+func (d DBPersister) Migrate(ctx context.Context) ([]Migration, error) {
+	all, err := migrations(d.dialect())
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := d.db.ExecContext(ctx, migrationsTable); err != nil {
+		return nil, fmt.Errorf("failed to create schema_migrations: %w", err)
+	}
+
+	var applied []Migration
+	for _, m := range all {
+		ok, err := d.applyMigration(ctx, m)
+		if err != nil {
+			return applied, fmt.Errorf("migration %d_%s failed: %w", m.Version, m.Name, err)
+		}
+		if ok {
+			applied = append(applied, m)
+		}
+	}
+	return applied, nil
+}
+
+// applyMigration applies m unless it is already recorded, it reports whether it was applied
+func (d DBPersister) applyMigration(ctx context.Context, m Migration) (bool, error) {
+	tx, err := d.db.BeginTx(ctx, nil)
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+
+	if d.postgres {
+		if _, err := tx.ExecContext(ctx, "SELECT pg_advisory_xact_lock("+strconv.Itoa(postgresMigrationLock)+")"); err != nil {
+			return false, err
+		}
+	}
+
+	queries := d.withTx(tx)
+	version, err := queries.GetSchemaVersion(ctx)
+	if err != nil {
+		return false, err
+	}
+	if version >= m.Version {
+		return false, nil
+	}
+
+	if _, err := tx.ExecContext(ctx, m.SQL); err != nil {
+		return false, err
+	}
+	if err := queries.RecordMigration(ctx, model.RecordMigrationParams{
+		Version:   m.Version,
+		Name:      m.Name,
+		AppliedAt: time.Now().UTC(),
+	}); err != nil {
+		return false, err
+	}
+	return true, tx.Commit()
+}
+
+// migrate brings the schema of a newly opened database up to date
+func (d DBPersister) migrate() error {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	applied, err := d.Migrate(ctx)
+	for _, m := range applied {
+		slog.Info("Applied DB migration", slog.Int64("version", m.Version), slog.String("name", m.Name))
+	}
+	return err
+}
@@ -0,0 +1,184 @@
+package persister
+
+import (
+	"context"
+	"database/sql"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// baselineSchema is the schema of databases created before migrations were introduced
+const baselineSchema = `
+CREATE TABLE IF NOT EXISTS scheduled_job
+(
+    id            uuid      PRIMARY KEY,
+    creation_time timestamp NOT NULL,
+    executor      text      NOT NULL,
+    metadata      jsonb,
+    commit_hash   text      DEFAULT NULL
+);
+
+CREATE TABLE IF NOT EXISTS job_results
+(
+    id         uuid PRIMARY KEY,
+    start_time timestamp NULL,
+    end_time   timestamp NULL
+);
+`
+
+func TestPostgresMigrationsMatchSQLiteMigrations(t *testing.T) {
+	sqlite, err := migrations("sqlite")
+	if err != nil {
+		t.Fatal(err)
+	}
+	postgres, err := migrations("postgres")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(sqlite) != len(postgres) {
+		t.Fatalf("expected %d PostgreSQL migrations, got %d", len(sqlite), len(postgres))
+	}
+	for i := range sqlite {
+		if sqlite[i].Name != postgres[i].Name {
+			t.Errorf("expected PostgreSQL migration %d to be %s, got %s", sqlite[i].Version, sqlite[i].Name, postgres[i].Name)
+		}
+	}
+}
+
+func TestMigrateBaselineDatabase(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "benchmark.db")
+	jobID := uuid.New()
+	created := time.Date(2025, 2, 4, 11, 0, 0, 0, time.UTC)
+
+	// Create a database with the old schema and a finished job
+	db, err := sql.Open("sqlite3", "file:"+path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := db.Exec(baselineSchema); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := db.Exec("INSERT INTO scheduled_job (id, creation_time, executor, commit_hash) VALUES (?, ?, ?, ?)",
+		jobID, created, "HadesDockerExecutor", "abc"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := db.Exec("INSERT INTO job_results (id, start_time, end_time) VALUES (?, ?, ?)",
+		jobID, created.Add(5*time.Second), created.Add(65*time.Second)); err != nil {
+		t.Fatal(err)
+	}
+	db.Close()
+
+	p, err := NewDBPersister(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer p.Close()
+
+	all, err := migrations("sqlite")
+	if err != nil {
+		t.Fatal(err)
+	}
+	version, err := p.SchemaVersion()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if version != int64(len(all)) {
+		t.Fatalf("expected schema version %d, got %d", len(all), version)
+	}
+
+	// The existing job keeps counting and gets the defaults of the new columns
+	buildTimes, err := p.GetBuildTimesInRange(MetricsFilter{Executor: "HadesDockerExecutor"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := []int64{60}; !reflect.DeepEqual(buildTimes, expected) {
+		t.Fatalf("expected build times %v, got %v", expected, buildTimes)
+	}
+	var status string
+	if err := p.db.QueryRow("SELECT status FROM scheduled_job WHERE id = ?", jobID).Scan(&status); err != nil {
+		t.Fatal(err)
+	}
+	if status != "scheduled" {
+		t.Fatalf("expected status scheduled, got %s", status)
+	}
+
+	// The tables added since can be used
+	runID := uuid.New()
+	if err := p.StoreRun(Run{ID: runID, CreationTime: created, Executor: "HadesDockerExecutor", JobCount: 1}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := p.GetRun(runID); err != nil {
+		t.Fatalf("expected the run to be stored: %v", err)
+	}
+	if _, err := p.StorePayloadVersion(PayloadVersion{ID: uuid.New(), Name: "payload", Content: []byte(`{}`), Hash: "hash"}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestMigrateIsIdempotent(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "benchmark.db")
+
+	p, err := NewDBPersister(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p.Close()
+
+	p, err = NewDBPersister(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer p.Close()
+
+	applied, err := p.Migrate(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(applied) != 0 {
+		t.Fatalf("expected no migrations to be applied again, got %v", applied)
+	}
+}
+
+func TestMigrateStopsAtFailingMigration(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "benchmark.db")
+
+	// A database with a status column which was added outside of the migrations
+	db, err := sql.Open("sqlite3", "file:"+path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := db.Exec(baselineSchema + "ALTER TABLE scheduled_job ADD COLUMN status text;"); err != nil {
+		t.Fatal(err)
+	}
+	db.Close()
+
+	if _, err := NewDBPersister(path); err == nil {
+		t.Fatal("expected the migration adding the status column to fail")
+	}
+
+	// The failed migration is rolled back and not recorded
+	db, err = sql.Open("sqlite3", "file:"+path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	var version int64
+	if err := db.QueryRow("SELECT MAX(version) FROM schema_migrations").Scan(&version); err != nil {
+		t.Fatal(err)
+	}
+	if version != 1 {
+		t.Fatalf("expected only the first migration to be recorded, got version %d", version)
+	}
+	var tables int
+	if err := db.QueryRow("SELECT COUNT(*) FROM sqlite_master WHERE type = 'table' AND name = 'benchmark_run'").Scan(&tables); err != nil {
+		t.Fatal(err)
+	}
+	if tables != 0 {
+		t.Fatal("expected the tables of the failed migration to be rolled back")
+	}
+}
@@ -0,0 +1,47 @@
+ALTER TABLE scheduled_job ADD COLUMN status text NOT NULL DEFAULT 'scheduled';
+ALTER TABLE scheduled_job ADD COLUMN run_id uuid DEFAULT NULL;
+
+CREATE TABLE benchmark_run
+(
+    id            uuid      PRIMARY KEY,
+    creation_time timestamp NOT NULL,
+    executor      text      NOT NULL,
+    job_count     integer   NOT NULL,
+    concurrency   integer   NOT NULL DEFAULT 0,
+    status        text      NOT NULL DEFAULT 'running',
+    error         text      DEFAULT NULL,
+    finish_time   timestamp DEFAULT NULL,
+    scenario      text      DEFAULT NULL,
+    labels        jsonb     DEFAULT NULL,
+    experiment_id uuid      DEFAULT NULL
+);
+
+CREATE TABLE experiment
+(
+    id            uuid      PRIMARY KEY,
+    creation_time timestamp NOT NULL,
+    scenario      text      NOT NULL,
+    run_order     text      NOT NULL,
+    seed          bigint    NOT NULL DEFAULT 0,
+    run_count     integer   NOT NULL,
+    status        text      NOT NULL DEFAULT 'running',
+    error         text      DEFAULT NULL,
+    finish_time   timestamp DEFAULT NULL
+);
+
+CREATE TABLE submission_attempt
+(
+    run_id          uuid      NOT NULL,
+    job_index       integer   NOT NULL,
+    attempt         integer   NOT NULL,
+    submit_delay_ms bigint    NOT NULL DEFAULT 0,
+    start_time      timestamp NOT NULL,
+    duration_ms     bigint    NOT NULL,
+    status_code     integer   DEFAULT NULL,
+    error           text      DEFAULT NULL,
+    job_id          uuid      DEFAULT NULL,
+    PRIMARY KEY (run_id, job_index, attempt)
+);
+
+CREATE INDEX idx_scheduled_job_run        ON scheduled_job(run_id);
+CREATE INDEX idx_benchmark_run_experiment ON benchmark_run(experiment_id);
@@ -0,0 +1,17 @@
+ALTER TABLE scheduled_job ADD COLUMN payload_id uuid DEFAULT NULL;
+ALTER TABLE scheduled_job ADD COLUMN payload_version integer DEFAULT NULL;
+ALTER TABLE scheduled_job ADD COLUMN payload_hash text DEFAULT NULL;
+
+CREATE TABLE payload
+(
+    id            uuid      NOT NULL,
+    version       integer   NOT NULL,
+    name          text      NOT NULL,
+    content       jsonb     NOT NULL,
+    content_hash  text      NOT NULL,
+    creation_time timestamp NOT NULL,
+    deleted       boolean   NOT NULL DEFAULT false,
+    PRIMARY KEY (id, version)
+);
+
+CREATE INDEX idx_scheduled_job_payload ON scheduled_job(payload_hash);
@@ -0,0 +1,6 @@
+ALTER TABLE job_results ADD COLUMN start_time_callbacks integer NOT NULL DEFAULT 0;
+ALTER TABLE job_results ADD COLUMN end_time_callbacks integer NOT NULL DEFAULT 0;
+ALTER TABLE job_results ADD COLUMN invalid boolean NOT NULL DEFAULT false;
+
+-- Jobs which finished before they started are left out of the build times
+UPDATE job_results SET invalid = true WHERE end_time < start_time;
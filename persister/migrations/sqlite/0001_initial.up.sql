@@ -1,3 +1,12 @@
+-- The schema before migrations were introduced. Databases created back then already contain these tables.
+
+CREATE TABLE IF NOT EXISTS schema_migrations
+(
+    version    integer   PRIMARY KEY,
+    name       text      NOT NULL,
+    applied_at timestamp NOT NULL
+);
+
 CREATE TABLE IF NOT EXISTS scheduled_job
 (
     id            uuid      PRIMARY KEY,
@@ -0,0 +1,10 @@
+CREATE TABLE orphan_callbacks
+(
+    id            integer   PRIMARY KEY,
+    job_id        uuid      NOT NULL,
+    callback      text      NOT NULL,
+    reported_time timestamp NOT NULL,
+    received_at   timestamp NOT NULL
+);
+
+CREATE INDEX idx_orphan_callbacks_job ON orphan_callbacks(job_id);
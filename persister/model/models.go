@@ -11,16 +11,86 @@ import (
 	"github.com/google/uuid"
 )
 
-type JobResult struct {
-	ID        uuid.UUID   `json:"id"`
-	StartTime interface{} `json:"start_time"`
-	EndTime   interface{} `json:"end_time"`
+type BenchmarkRun struct {
+	ID           uuid.UUID      `json:"id"`
+	CreationTime time.Time      `json:"creation_time"`
+	Executor     string         `json:"executor"`
+	JobCount     int64          `json:"job_count"`
+	Concurrency  int64          `json:"concurrency"`
+	Status       string         `json:"status"`
+	Error        sql.NullString `json:"error"`
+	FinishTime   sql.NullTime   `json:"finish_time"`
+	Scenario     sql.NullString `json:"scenario"`
+	Labels       interface{}    `json:"labels"`
+	ExperimentID uuid.NullUUID  `json:"experiment_id"`
 }
 
-type ScheduledJob struct {
+type Experiment struct {
 	ID           uuid.UUID      `json:"id"`
 	CreationTime time.Time      `json:"creation_time"`
-	Executor     string         `json:"executor"`
-	Metadata     interface{}    `json:"metadata"`
-	CommitHash   sql.NullString `json:"commit_hash"`
+	Scenario     string         `json:"scenario"`
+	RunOrder     string         `json:"run_order"`
+	Seed         int64          `json:"seed"`
+	RunCount     int64          `json:"run_count"`
+	Status       string         `json:"status"`
+	Error        sql.NullString `json:"error"`
+	FinishTime   sql.NullTime   `json:"finish_time"`
+}
+
+type JobResult struct {
+	ID                 uuid.UUID   `json:"id"`
+	StartTime          interface{} `json:"start_time"`
+	EndTime            interface{} `json:"end_time"`
+	StartTimeCallbacks int64       `json:"start_time_callbacks"`
+	EndTimeCallbacks   int64       `json:"end_time_callbacks"`
+	Invalid            bool        `json:"invalid"`
+}
+
+type OrphanCallback struct {
+	ID           int64     `json:"id"`
+	JobID        uuid.UUID `json:"job_id"`
+	Callback     string    `json:"callback"`
+	ReportedTime time.Time `json:"reported_time"`
+	ReceivedAt   time.Time `json:"received_at"`
+}
+
+type Payload struct {
+	ID           uuid.UUID   `json:"id"`
+	Version      int64       `json:"version"`
+	Name         string      `json:"name"`
+	Content      interface{} `json:"content"`
+	ContentHash  string      `json:"content_hash"`
+	CreationTime time.Time   `json:"creation_time"`
+	Deleted      bool        `json:"deleted"`
+}
+
+type SchemaMigration struct {
+	Version   int64     `json:"version"`
+	Name      string    `json:"name"`
+	AppliedAt time.Time `json:"applied_at"`
+}
+
+type ScheduledJob struct {
+	ID             uuid.UUID      `json:"id"`
+	CreationTime   time.Time      `json:"creation_time"`
+	Executor       string         `json:"executor"`
+	Metadata       interface{}    `json:"metadata"`
+	CommitHash     sql.NullString `json:"commit_hash"`
+	Status         string         `json:"status"`
+	RunID          uuid.NullUUID  `json:"run_id"`
+	PayloadID      uuid.NullUUID  `json:"payload_id"`
+	PayloadVersion sql.NullInt64  `json:"payload_version"`
+	PayloadHash    sql.NullString `json:"payload_hash"`
+}
+
+type SubmissionAttempt struct {
+	RunID         uuid.UUID      `json:"run_id"`
+	JobIndex      int64          `json:"job_index"`
+	Attempt       int64          `json:"attempt"`
+	SubmitDelayMs int64          `json:"submit_delay_ms"`
+	StartTime     time.Time      `json:"start_time"`
+	DurationMs    int64          `json:"duration_ms"`
+	StatusCode    sql.NullInt64  `json:"status_code"`
+	Error         sql.NullString `json:"error"`
+	JobID         uuid.NullUUID  `json:"job_id"`
 }
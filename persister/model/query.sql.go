@@ -13,6 +13,310 @@ import (
 	"github.com/google/uuid"
 )
 
+const cancelPendingJobsByRun = `-- name: CancelPendingJobsByRun :execrows
+UPDATE scheduled_job
+SET status = 'cancelled'
+WHERE run_id = ?
+  AND status NOT IN ('succeeded', 'failed', 'cancelled')
+  AND id NOT IN (SELECT id FROM job_results WHERE end_time IS NOT NULL)
+`
+
+func (q *Queries) CancelPendingJobsByRun(ctx context.Context, runID uuid.NullUUID) (int64, error) {
+	result, err := q.db.ExecContext(ctx, cancelPendingJobsByRun, runID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const claimOrphanCallbacks = `-- name: ClaimOrphanCallbacks :many
+DELETE FROM orphan_callbacks
+WHERE job_id = ?
+RETURNING id, job_id, callback, reported_time, received_at
+`
+
+func (q *Queries) ClaimOrphanCallbacks(ctx context.Context, jobID uuid.UUID) ([]OrphanCallback, error) {
+	rows, err := q.db.QueryContext(ctx, claimOrphanCallbacks, jobID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []OrphanCallback
+	for rows.Next() {
+		var i OrphanCallback
+		if err := rows.Scan(
+			&i.ID,
+			&i.JobID,
+			&i.Callback,
+			&i.ReportedTime,
+			&i.ReceivedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const countRuns = `-- name: CountRuns :one
+SELECT COUNT(*) FROM benchmark_run
+WHERE id = ?
+`
+
+func (q *Queries) CountRuns(ctx context.Context, id uuid.UUID) (int64, error) {
+	row := q.db.QueryRowContext(ctx, countRuns, id)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
+const countScheduledJobs = `-- name: CountScheduledJobs :one
+SELECT COUNT(*) FROM scheduled_job
+WHERE id = ?
+`
+
+func (q *Queries) CountScheduledJobs(ctx context.Context, id uuid.UUID) (int64, error) {
+	row := q.db.QueryRowContext(ctx, countScheduledJobs, id)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
+const createExperiment = `-- name: CreateExperiment :exec
+INSERT INTO experiment (
+  id, creation_time, scenario, run_order, seed, run_count
+) VALUES (
+  ?, ?, ?, ?, ?, ?
+)
+`
+
+type CreateExperimentParams struct {
+	ID           uuid.UUID `json:"id"`
+	CreationTime time.Time `json:"creation_time"`
+	Scenario     string    `json:"scenario"`
+	RunOrder     string    `json:"run_order"`
+	Seed         int64     `json:"seed"`
+	RunCount     int64     `json:"run_count"`
+}
+
+func (q *Queries) CreateExperiment(ctx context.Context, arg CreateExperimentParams) error {
+	_, err := q.db.ExecContext(ctx, createExperiment,
+		arg.ID,
+		arg.CreationTime,
+		arg.Scenario,
+		arg.RunOrder,
+		arg.Seed,
+		arg.RunCount,
+	)
+	return err
+}
+
+const createPayloadVersion = `-- name: CreatePayloadVersion :exec
+INSERT INTO payload (
+  id, version, name, content, content_hash, creation_time
+) VALUES (
+  ?, ?, ?, ?, ?, ?
+)
+`
+
+type CreatePayloadVersionParams struct {
+	ID           uuid.UUID   `json:"id"`
+	Version      int64       `json:"version"`
+	Name         string      `json:"name"`
+	Content      interface{} `json:"content"`
+	ContentHash  string      `json:"content_hash"`
+	CreationTime time.Time   `json:"creation_time"`
+}
+
+func (q *Queries) CreatePayloadVersion(ctx context.Context, arg CreatePayloadVersionParams) error {
+	_, err := q.db.ExecContext(ctx, createPayloadVersion,
+		arg.ID,
+		arg.Version,
+		arg.Name,
+		arg.Content,
+		arg.ContentHash,
+		arg.CreationTime,
+	)
+	return err
+}
+
+const createRun = `-- name: CreateRun :exec
+INSERT INTO benchmark_run (
+  id, creation_time, executor, job_count, concurrency, scenario, labels, experiment_id
+) VALUES (
+  ?, ?, ?, ?, ?, ?, ?, ?
+)
+`
+
+type CreateRunParams struct {
+	ID           uuid.UUID      `json:"id"`
+	CreationTime time.Time      `json:"creation_time"`
+	Executor     string         `json:"executor"`
+	JobCount     int64          `json:"job_count"`
+	Concurrency  int64          `json:"concurrency"`
+	Scenario     sql.NullString `json:"scenario"`
+	Labels       interface{}    `json:"labels"`
+	ExperimentID uuid.NullUUID  `json:"experiment_id"`
+}
+
+func (q *Queries) CreateRun(ctx context.Context, arg CreateRunParams) error {
+	_, err := q.db.ExecContext(ctx, createRun,
+		arg.ID,
+		arg.CreationTime,
+		arg.Executor,
+		arg.JobCount,
+		arg.Concurrency,
+		arg.Scenario,
+		arg.Labels,
+		arg.ExperimentID,
+	)
+	return err
+}
+
+const deleteJobResult = `-- name: DeleteJobResult :exec
+DELETE FROM job_results
+WHERE id = ?
+`
+
+func (q *Queries) DeleteJobResult(ctx context.Context, id uuid.UUID) error {
+	_, err := q.db.ExecContext(ctx, deleteJobResult, id)
+	return err
+}
+
+const deletePayload = `-- name: DeletePayload :execrows
+UPDATE payload
+SET deleted = true
+WHERE id = ? AND deleted = false
+`
+
+func (q *Queries) DeletePayload(ctx context.Context, id uuid.UUID) (int64, error) {
+	result, err := q.db.ExecContext(ctx, deletePayload, id)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const deleteRun = `-- name: DeleteRun :exec
+DELETE FROM benchmark_run
+WHERE id = ?
+`
+
+func (q *Queries) DeleteRun(ctx context.Context, id uuid.UUID) error {
+	_, err := q.db.ExecContext(ctx, deleteRun, id)
+	return err
+}
+
+const deleteScheduledJob = `-- name: DeleteScheduledJob :exec
+DELETE FROM scheduled_job
+WHERE id = ?
+`
+
+func (q *Queries) DeleteScheduledJob(ctx context.Context, id uuid.UUID) error {
+	_, err := q.db.ExecContext(ctx, deleteScheduledJob, id)
+	return err
+}
+
+const deleteSubmissionAttemptsByRun = `-- name: DeleteSubmissionAttemptsByRun :exec
+DELETE FROM submission_attempt
+WHERE run_id = ?
+`
+
+func (q *Queries) DeleteSubmissionAttemptsByRun(ctx context.Context, runID uuid.UUID) error {
+	_, err := q.db.ExecContext(ctx, deleteSubmissionAttemptsByRun, runID)
+	return err
+}
+
+const getBuildTimeSummaryByGroup = `-- name: GetBuildTimeSummaryByGroup :many
+SELECT group_key, build_time
+FROM (
+    SELECT
+        CAST(CASE ?1
+            WHEN 'executor' THEN s.executor
+            WHEN 'commit_hash' THEN s.commit_hash
+            WHEN 'run' THEN s.run_id
+            WHEN 'hour' THEN strftime('%Y-%m-%dT%H:00:00Z', r.start_time)
+            WHEN 'day' THEN strftime('%Y-%m-%d', r.start_time)
+            ELSE (SELECT m.value FROM json_each(s.metadata) m WHERE m.key = ?2)
+        END AS TEXT) AS group_key,
+        CAST((strftime('%s', r.end_time) - strftime('%s', r.start_time)) AS INTEGER) AS build_time
+    FROM
+        job_results r
+            INNER JOIN scheduled_job s ON r.id = s.id
+    WHERE
+        r.start_time IS NOT NULL
+      AND r.end_time IS NOT NULL
+      AND r.invalid = false
+      AND (datetime(r.start_time) >= datetime(?3) OR ?3 IS NULL)
+      AND (datetime(r.end_time) <= datetime(?4) OR ?4 IS NULL)
+      AND (s.commit_hash = ?5 OR ?5 IS NULL)
+      AND (s.executor = ?6 OR ?6 IS NULL)
+      AND (s.payload_hash = ?7 OR ?7 IS NULL)
+      AND NOT EXISTS (
+          SELECT 1 FROM json_each(?8) l
+          WHERE (SELECT m.value FROM json_each(s.metadata) m WHERE m.key = l.key) IS NOT l.value
+      )
+      AND s.status != 'cancelled'
+) grouped
+WHERE group_key IS NOT NULL
+ORDER BY
+    group_key, build_time ASC
+`
+
+type GetBuildTimeSummaryByGroupParams struct {
+	GroupBy     interface{}    `json:"group_by"`
+	GroupLabel  sql.NullString `json:"group_label"`
+	From        interface{}    `json:"from"`
+	To          interface{}    `json:"to"`
+	CommitHash  sql.NullString `json:"commit_hash"`
+	Executor    sql.NullString `json:"executor"`
+	PayloadHash sql.NullString `json:"payload_hash"`
+	Labels      interface{}    `json:"labels"`
+}
+
+type GetBuildTimeSummaryByGroupRow struct {
+	GroupKey  string `json:"group_key"`
+	BuildTime int64  `json:"build_time"`
+}
+
+func (q *Queries) GetBuildTimeSummaryByGroup(ctx context.Context, arg GetBuildTimeSummaryByGroupParams) ([]GetBuildTimeSummaryByGroupRow, error) {
+	rows, err := q.db.QueryContext(ctx, getBuildTimeSummaryByGroup,
+		arg.GroupBy,
+		arg.GroupLabel,
+		arg.From,
+		arg.To,
+		arg.CommitHash,
+		arg.Executor,
+		arg.PayloadHash,
+		arg.Labels,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetBuildTimeSummaryByGroupRow
+	for rows.Next() {
+		var i GetBuildTimeSummaryByGroupRow
+		if err := rows.Scan(&i.GroupKey, &i.BuildTime); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const getBuildTimeSummaryInRangeByCommitAndExecutor = `-- name: GetBuildTimeSummaryInRangeByCommitAndExecutor :many
 SELECT
     CAST((strftime('%s', r.end_time) - strftime('%s', r.start_time)) AS INTEGER) AS build_time
@@ -22,19 +326,28 @@ FROM
 WHERE
     r.start_time IS NOT NULL
   AND r.end_time IS NOT NULL
+  AND r.invalid = false
   AND (datetime(r.start_time) >= datetime(?1) OR ?1 IS NULL)
   AND (datetime(r.end_time) <= datetime(?2) OR ?2 IS NULL)
   AND (s.commit_hash = ?3 OR ?3 IS NULL)
   AND (s.executor = ?4 OR ?4 IS NULL)
+  AND (s.payload_hash = ?5 OR ?5 IS NULL)
+  AND NOT EXISTS (
+      SELECT 1 FROM json_each(?6) l
+      WHERE (SELECT m.value FROM json_each(s.metadata) m WHERE m.key = l.key) IS NOT l.value
+  )
+  AND s.status != 'cancelled'
 ORDER BY
     build_time ASC
 `
 
 type GetBuildTimeSummaryInRangeByCommitAndExecutorParams struct {
-	From       interface{}    `json:"from"`
-	To         interface{}    `json:"to"`
-	CommitHash sql.NullString `json:"commit_hash"`
-	Executor   string         `json:"executor"`
+	From        interface{}    `json:"from"`
+	To          interface{}    `json:"to"`
+	CommitHash  sql.NullString `json:"commit_hash"`
+	Executor    string         `json:"executor"`
+	PayloadHash sql.NullString `json:"payload_hash"`
+	Labels      interface{}    `json:"labels"`
 }
 
 func (q *Queries) GetBuildTimeSummaryInRangeByCommitAndExecutor(ctx context.Context, arg GetBuildTimeSummaryInRangeByCommitAndExecutorParams) ([]int64, error) {
@@ -43,18 +356,999 @@ func (q *Queries) GetBuildTimeSummaryInRangeByCommitAndExecutor(ctx context.Cont
 		arg.To,
 		arg.CommitHash,
 		arg.Executor,
+		arg.PayloadHash,
+		arg.Labels,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []int64
+	for rows.Next() {
+		var build_time int64
+		if err := rows.Scan(&build_time); err != nil {
+			return nil, err
+		}
+		items = append(items, build_time)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getBuildTimesInRangeByCommitAndExecutor = `-- name: GetBuildTimesInRangeByCommitAndExecutor :many
+SELECT
+    CAST((strftime('%s', r.end_time) - strftime('%s', r.start_time)) AS INTEGER) AS build_time
+FROM
+    job_results r
+        INNER JOIN scheduled_job s ON r.id = s.id
+WHERE
+    r.start_time IS NOT NULL
+  AND r.end_time IS NOT NULL
+  AND r.invalid = false
+  AND (datetime(r.start_time) >= datetime(?1) OR ?1 IS NULL)
+  AND (datetime(r.end_time) <= datetime(?2) OR ?2 IS NULL)
+  AND (s.commit_hash = ?3 OR ?3 IS NULL)
+  AND (s.executor = ?4 OR ?4 IS NULL)
+  AND (s.payload_hash = ?5 OR ?5 IS NULL)
+  AND NOT EXISTS (
+      SELECT 1 FROM json_each(?6) l
+      WHERE (SELECT m.value FROM json_each(s.metadata) m WHERE m.key = l.key) IS NOT l.value
+  )
+  AND s.status != 'cancelled'
+ORDER BY
+    build_time DESC
+`
+
+type GetBuildTimesInRangeByCommitAndExecutorParams struct {
+	From        interface{}    `json:"from"`
+	To          interface{}    `json:"to"`
+	CommitHash  sql.NullString `json:"commit_hash"`
+	Executor    string         `json:"executor"`
+	PayloadHash sql.NullString `json:"payload_hash"`
+	Labels      interface{}    `json:"labels"`
+}
+
+func (q *Queries) GetBuildTimesInRangeByCommitAndExecutor(ctx context.Context, arg GetBuildTimesInRangeByCommitAndExecutorParams) ([]int64, error) {
+	rows, err := q.db.QueryContext(ctx, getBuildTimesInRangeByCommitAndExecutor,
+		arg.From,
+		arg.To,
+		arg.CommitHash,
+		arg.Executor,
+		arg.PayloadHash,
+		arg.Labels,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []int64
+	for rows.Next() {
+		var build_time int64
+		if err := rows.Scan(&build_time); err != nil {
+			return nil, err
+		}
+		items = append(items, build_time)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getExperiment = `-- name: GetExperiment :one
+SELECT id, creation_time, scenario, run_order, seed, run_count, status, error, finish_time FROM experiment
+WHERE id = ?
+`
+
+func (q *Queries) GetExperiment(ctx context.Context, id uuid.UUID) (Experiment, error) {
+	row := q.db.QueryRowContext(ctx, getExperiment, id)
+	var i Experiment
+	err := row.Scan(
+		&i.ID,
+		&i.CreationTime,
+		&i.Scenario,
+		&i.RunOrder,
+		&i.Seed,
+		&i.RunCount,
+		&i.Status,
+		&i.Error,
+		&i.FinishTime,
+	)
+	return i, err
+}
+
+const getJobCreationTimeAtOffset = `-- name: GetJobCreationTimeAtOffset :one
+SELECT creation_time FROM scheduled_job
+ORDER BY creation_time DESC
+LIMIT 1 OFFSET ?
+`
+
+func (q *Queries) GetJobCreationTimeAtOffset(ctx context.Context, offset int64) (time.Time, error) {
+	row := q.db.QueryRowContext(ctx, getJobCreationTimeAtOffset, offset)
+	var creation_time time.Time
+	err := row.Scan(&creation_time)
+	return creation_time, err
+}
+
+const getJobResult = `-- name: GetJobResult :one
+SELECT id, start_time, end_time, start_time_callbacks, end_time_callbacks, invalid FROM job_results
+WHERE id = ?
+`
+
+func (q *Queries) GetJobResult(ctx context.Context, id uuid.UUID) (JobResult, error) {
+	row := q.db.QueryRowContext(ctx, getJobResult, id)
+	var i JobResult
+	err := row.Scan(
+		&i.ID,
+		&i.StartTime,
+		&i.EndTime,
+		&i.StartTimeCallbacks,
+		&i.EndTimeCallbacks,
+		&i.Invalid,
+	)
+	return i, err
+}
+
+const getJobTimesByExperiment = `-- name: GetJobTimesByExperiment :many
+SELECT
+    s.run_id,
+    s.creation_time,
+    r.start_time,
+    r.end_time
+FROM
+    scheduled_job s
+        INNER JOIN benchmark_run b ON s.run_id = b.id
+        LEFT JOIN job_results r ON s.id = r.id
+WHERE
+    b.experiment_id = ?
+  AND s.status != 'cancelled'
+`
+
+type GetJobTimesByExperimentRow struct {
+	RunID        uuid.NullUUID `json:"run_id"`
+	CreationTime time.Time     `json:"creation_time"`
+	StartTime    sql.NullTime  `json:"start_time"`
+	EndTime      sql.NullTime  `json:"end_time"`
+}
+
+func (q *Queries) GetJobTimesByExperiment(ctx context.Context, experimentID uuid.NullUUID) ([]GetJobTimesByExperimentRow, error) {
+	rows, err := q.db.QueryContext(ctx, getJobTimesByExperiment, experimentID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetJobTimesByExperimentRow
+	for rows.Next() {
+		var i GetJobTimesByExperimentRow
+		if err := rows.Scan(
+			&i.RunID,
+			&i.CreationTime,
+			&i.StartTime,
+			&i.EndTime,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getLatestPayloadVersion = `-- name: GetLatestPayloadVersion :one
+SELECT id, version, name, content, content_hash, creation_time, deleted FROM payload
+WHERE id = ? AND deleted = false
+ORDER BY version DESC
+LIMIT 1
+`
+
+func (q *Queries) GetLatestPayloadVersion(ctx context.Context, id uuid.UUID) (Payload, error) {
+	row := q.db.QueryRowContext(ctx, getLatestPayloadVersion, id)
+	var i Payload
+	err := row.Scan(
+		&i.ID,
+		&i.Version,
+		&i.Name,
+		&i.Content,
+		&i.ContentHash,
+		&i.CreationTime,
+		&i.Deleted,
+	)
+	return i, err
+}
+
+const getPayloadVersion = `-- name: GetPayloadVersion :one
+SELECT id, version, name, content, content_hash, creation_time, deleted FROM payload
+WHERE id = ? AND version = ? AND deleted = false
+`
+
+type GetPayloadVersionParams struct {
+	ID      uuid.UUID `json:"id"`
+	Version int64     `json:"version"`
+}
+
+func (q *Queries) GetPayloadVersion(ctx context.Context, arg GetPayloadVersionParams) (Payload, error) {
+	row := q.db.QueryRowContext(ctx, getPayloadVersion, arg.ID, arg.Version)
+	var i Payload
+	err := row.Scan(
+		&i.ID,
+		&i.Version,
+		&i.Name,
+		&i.Content,
+		&i.ContentHash,
+		&i.CreationTime,
+		&i.Deleted,
 	)
+	return i, err
+}
+
+const getPendingJobIDsByRun = `-- name: GetPendingJobIDsByRun :many
+SELECT
+    s.id
+FROM
+    scheduled_job s
+        LEFT JOIN job_results r ON s.id = r.id
+WHERE
+    s.run_id = ?
+  AND s.status NOT IN ('succeeded', 'failed', 'cancelled')
+  AND r.end_time IS NULL
+`
+
+func (q *Queries) GetPendingJobIDsByRun(ctx context.Context, runID uuid.NullUUID) ([]uuid.UUID, error) {
+	rows, err := q.db.QueryContext(ctx, getPendingJobIDsByRun, runID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []uuid.UUID
+	for rows.Next() {
+		var id uuid.UUID
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		items = append(items, id)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getQueueLatenciesInRangeByCommitAndExecutor = `-- name: GetQueueLatenciesInRangeByCommitAndExecutor :many
+SELECT
+    CAST((strftime('%s', r.start_time) - strftime('%s', s.creation_time)) AS INTEGER) AS queue_latency
+FROM
+    scheduled_job s
+        INNER JOIN job_results r ON s.id = r.id
+WHERE
+    r.start_time IS NOT NULL
+  AND (datetime(r.start_time) >= datetime(?1) OR ?1 IS NULL)
+  AND (datetime(r.start_time) <= datetime(?2) OR ?2 IS NULL)
+  AND (s.commit_hash = ?3 OR ?3 IS NULL)
+  AND (s.executor = ?4 OR ?4 IS NULL)
+  AND (s.payload_hash = ?5 OR ?5 IS NULL)
+  AND NOT EXISTS (
+      SELECT 1 FROM json_each(?6) l
+      WHERE (SELECT m.value FROM json_each(s.metadata) m WHERE m.key = l.key) IS NOT l.value
+  )
+  AND s.status != 'cancelled'
+ORDER BY
+    queue_latency DESC
+`
+
+type GetQueueLatenciesInRangeByCommitAndExecutorParams struct {
+	From        interface{}    `json:"from"`
+	To          interface{}    `json:"to"`
+	CommitHash  sql.NullString `json:"commit_hash"`
+	Executor    string         `json:"executor"`
+	PayloadHash sql.NullString `json:"payload_hash"`
+	Labels      interface{}    `json:"labels"`
+}
+
+func (q *Queries) GetQueueLatenciesInRangeByCommitAndExecutor(ctx context.Context, arg GetQueueLatenciesInRangeByCommitAndExecutorParams) ([]int64, error) {
+	rows, err := q.db.QueryContext(ctx, getQueueLatenciesInRangeByCommitAndExecutor,
+		arg.From,
+		arg.To,
+		arg.CommitHash,
+		arg.Executor,
+		arg.PayloadHash,
+		arg.Labels,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []int64
+	for rows.Next() {
+		var queue_latency int64
+		if err := rows.Scan(&queue_latency); err != nil {
+			return nil, err
+		}
+		items = append(items, queue_latency)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getQueueLatencySummaryByGroup = `-- name: GetQueueLatencySummaryByGroup :many
+SELECT group_key, latency
+FROM (
+    SELECT
+        CAST(CASE ?1
+            WHEN 'executor' THEN s.executor
+            WHEN 'commit_hash' THEN s.commit_hash
+            WHEN 'run' THEN s.run_id
+            WHEN 'hour' THEN strftime('%Y-%m-%dT%H:00:00Z', r.start_time)
+            WHEN 'day' THEN strftime('%Y-%m-%d', r.start_time)
+            ELSE (SELECT m.value FROM json_each(s.metadata) m WHERE m.key = ?2)
+        END AS TEXT) AS group_key,
+        CAST((strftime('%s', r.start_time) - strftime('%s', s.creation_time)) AS INTEGER) AS latency
+    FROM
+        scheduled_job s
+            INNER JOIN job_results r ON s.id = r.id
+    WHERE
+        r.start_time IS NOT NULL
+      AND (datetime(r.start_time) >= datetime(?3) OR ?3 IS NULL)
+      AND (datetime(r.start_time) <= datetime(?4) OR ?4 IS NULL)
+      AND (s.commit_hash = ?5 OR ?5 IS NULL)
+      AND (s.executor = ?6 OR ?6 IS NULL)
+      AND (s.payload_hash = ?7 OR ?7 IS NULL)
+      AND NOT EXISTS (
+          SELECT 1 FROM json_each(?8) l
+          WHERE (SELECT m.value FROM json_each(s.metadata) m WHERE m.key = l.key) IS NOT l.value
+      )
+      AND s.status != 'cancelled'
+) grouped
+WHERE group_key IS NOT NULL
+ORDER BY
+    group_key, latency ASC
+`
+
+type GetQueueLatencySummaryByGroupParams struct {
+	GroupBy     interface{}    `json:"group_by"`
+	GroupLabel  sql.NullString `json:"group_label"`
+	From        interface{}    `json:"from"`
+	To          interface{}    `json:"to"`
+	CommitHash  sql.NullString `json:"commit_hash"`
+	Executor    sql.NullString `json:"executor"`
+	PayloadHash sql.NullString `json:"payload_hash"`
+	Labels      interface{}    `json:"labels"`
+}
+
+type GetQueueLatencySummaryByGroupRow struct {
+	GroupKey string `json:"group_key"`
+	Latency  int64  `json:"latency"`
+}
+
+func (q *Queries) GetQueueLatencySummaryByGroup(ctx context.Context, arg GetQueueLatencySummaryByGroupParams) ([]GetQueueLatencySummaryByGroupRow, error) {
+	rows, err := q.db.QueryContext(ctx, getQueueLatencySummaryByGroup,
+		arg.GroupBy,
+		arg.GroupLabel,
+		arg.From,
+		arg.To,
+		arg.CommitHash,
+		arg.Executor,
+		arg.PayloadHash,
+		arg.Labels,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetQueueLatencySummaryByGroupRow
+	for rows.Next() {
+		var i GetQueueLatencySummaryByGroupRow
+		if err := rows.Scan(&i.GroupKey, &i.Latency); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getQueueLatencySummaryInRangeByCommitAndExecutor = `-- name: GetQueueLatencySummaryInRangeByCommitAndExecutor :many
+SELECT
+    CAST((strftime('%s', r.start_time) - strftime('%s', s.creation_time)) AS INTEGER) AS latency
+FROM
+    scheduled_job s
+        INNER JOIN job_results r ON s.id = r.id
+WHERE
+    r.start_time IS NOT NULL
+  AND (datetime(r.start_time) >= datetime(?1) OR ?1 IS NULL)
+  AND (datetime(r.start_time) <= datetime(?2) OR ?2 IS NULL)
+  AND (s.commit_hash = ?3 OR ?3 IS NULL)
+  AND (s.executor = ?4 OR ?4 IS NULL)
+  AND (s.payload_hash = ?5 OR ?5 IS NULL)
+  AND NOT EXISTS (
+      SELECT 1 FROM json_each(?6) l
+      WHERE (SELECT m.value FROM json_each(s.metadata) m WHERE m.key = l.key) IS NOT l.value
+  )
+  AND s.status != 'cancelled'
+ORDER BY
+    latency ASC
+`
+
+type GetQueueLatencySummaryInRangeByCommitAndExecutorParams struct {
+	From        interface{}    `json:"from"`
+	To          interface{}    `json:"to"`
+	CommitHash  sql.NullString `json:"commit_hash"`
+	Executor    string         `json:"executor"`
+	PayloadHash sql.NullString `json:"payload_hash"`
+	Labels      interface{}    `json:"labels"`
+}
+
+func (q *Queries) GetQueueLatencySummaryInRangeByCommitAndExecutor(ctx context.Context, arg GetQueueLatencySummaryInRangeByCommitAndExecutorParams) ([]int64, error) {
+	rows, err := q.db.QueryContext(ctx, getQueueLatencySummaryInRangeByCommitAndExecutor,
+		arg.From,
+		arg.To,
+		arg.CommitHash,
+		arg.Executor,
+		arg.PayloadHash,
+		arg.Labels,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []int64
+	for rows.Next() {
+		var latency int64
+		if err := rows.Scan(&latency); err != nil {
+			return nil, err
+		}
+		items = append(items, latency)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getRun = `-- name: GetRun :one
+SELECT id, creation_time, executor, job_count, concurrency, status, error, finish_time, scenario, labels, experiment_id FROM benchmark_run
+WHERE id = ?
+`
+
+func (q *Queries) GetRun(ctx context.Context, id uuid.UUID) (BenchmarkRun, error) {
+	row := q.db.QueryRowContext(ctx, getRun, id)
+	var i BenchmarkRun
+	err := row.Scan(
+		&i.ID,
+		&i.CreationTime,
+		&i.Executor,
+		&i.JobCount,
+		&i.Concurrency,
+		&i.Status,
+		&i.Error,
+		&i.FinishTime,
+		&i.Scenario,
+		&i.Labels,
+		&i.ExperimentID,
+	)
+	return i, err
+}
+
+const getRunJobStats = `-- name: GetRunJobStats :one
+SELECT
+    COUNT(*) AS scheduled_jobs,
+    CAST(COALESCE(SUM(CASE WHEN s.status != 'cancelled' AND (r.end_time IS NOT NULL OR s.status IN ('succeeded', 'failed')) THEN 1 ELSE 0 END), 0) AS INTEGER) AS finished_jobs,
+    CAST(COALESCE(SUM(CASE WHEN s.status = 'cancelled' THEN 1 ELSE 0 END), 0) AS INTEGER) AS cancelled_jobs,
+    CAST(COALESCE(SUM(
+        CASE WHEN r.start_time_callbacks > 1 THEN r.start_time_callbacks - 1 ELSE 0 END +
+        CASE WHEN r.end_time_callbacks > 1 THEN r.end_time_callbacks - 1 ELSE 0 END
+    ), 0) AS INTEGER) AS duplicate_callbacks,
+    CAST(COALESCE(SUM(CASE WHEN r.invalid THEN 1 ELSE 0 END), 0) AS INTEGER) AS invalid_jobs
+FROM
+    scheduled_job s
+        LEFT JOIN job_results r ON s.id = r.id
+WHERE
+    s.run_id = ?
+`
+
+type GetRunJobStatsRow struct {
+	ScheduledJobs      int64 `json:"scheduled_jobs"`
+	FinishedJobs       int64 `json:"finished_jobs"`
+	CancelledJobs      int64 `json:"cancelled_jobs"`
+	DuplicateCallbacks int64 `json:"duplicate_callbacks"`
+	InvalidJobs        int64 `json:"invalid_jobs"`
+}
+
+func (q *Queries) GetRunJobStats(ctx context.Context, runID uuid.NullUUID) (GetRunJobStatsRow, error) {
+	row := q.db.QueryRowContext(ctx, getRunJobStats, runID)
+	var i GetRunJobStatsRow
+	err := row.Scan(
+		&i.ScheduledJobs,
+		&i.FinishedJobs,
+		&i.CancelledJobs,
+		&i.DuplicateCallbacks,
+		&i.InvalidJobs,
+	)
+	return i, err
+}
+
+const getRunSubmissionStats = `-- name: GetRunSubmissionStats :one
+SELECT
+    CAST(COALESCE(SUM(j.attempts), 0) AS INTEGER) AS attempts,
+    CAST(COALESCE(SUM(CASE WHEN j.attempts > 1 THEN 1 ELSE 0 END), 0) AS INTEGER) AS retried_jobs,
+    CAST(COALESCE(SUM(CASE WHEN j.submitted = 0 THEN 1 ELSE 0 END), 0) AS INTEGER) AS failed_jobs,
+    CAST(COALESCE(AVG(j.submit_latency_ms), 0) AS INTEGER) AS avg_submit_latency_ms,
+    CAST(COALESCE(MAX(j.submit_latency_ms), 0) AS INTEGER) AS max_submit_latency_ms,
+    CAST(COALESCE(AVG(j.submit_delay_ms), 0) AS INTEGER) AS avg_submit_delay_ms,
+    CAST(COALESCE(MAX(j.submit_delay_ms), 0) AS INTEGER) AS max_submit_delay_ms
+FROM (
+    SELECT
+        job_index,
+        COUNT(*) AS attempts,
+        MAX(CASE WHEN attempt = 1 THEN submit_delay_ms ELSE 0 END) AS submit_delay_ms,
+        SUM(duration_ms) AS submit_latency_ms,
+        MAX(CASE WHEN job_id IS NOT NULL THEN 1 ELSE 0 END) AS submitted
+    FROM
+        submission_attempt
+    WHERE
+        run_id = ?
+    GROUP BY
+        job_index
+) j
+`
+
+type GetRunSubmissionStatsRow struct {
+	Attempts           int64 `json:"attempts"`
+	RetriedJobs        int64 `json:"retried_jobs"`
+	FailedJobs         int64 `json:"failed_jobs"`
+	AvgSubmitLatencyMs int64 `json:"avg_submit_latency_ms"`
+	MaxSubmitLatencyMs int64 `json:"max_submit_latency_ms"`
+	AvgSubmitDelayMs   int64 `json:"avg_submit_delay_ms"`
+	MaxSubmitDelayMs   int64 `json:"max_submit_delay_ms"`
+}
+
+func (q *Queries) GetRunSubmissionStats(ctx context.Context, runID uuid.UUID) (GetRunSubmissionStatsRow, error) {
+	row := q.db.QueryRowContext(ctx, getRunSubmissionStats, runID)
+	var i GetRunSubmissionStatsRow
+	err := row.Scan(
+		&i.Attempts,
+		&i.RetriedJobs,
+		&i.FailedJobs,
+		&i.AvgSubmitLatencyMs,
+		&i.MaxSubmitLatencyMs,
+		&i.AvgSubmitDelayMs,
+		&i.MaxSubmitDelayMs,
+	)
+	return i, err
+}
+
+const getRunsByExperiment = `-- name: GetRunsByExperiment :many
+SELECT id, creation_time, executor, job_count, concurrency, status, error, finish_time, scenario, labels, experiment_id FROM benchmark_run
+WHERE experiment_id = ?
+ORDER BY creation_time
+`
+
+func (q *Queries) GetRunsByExperiment(ctx context.Context, experimentID uuid.NullUUID) ([]BenchmarkRun, error) {
+	rows, err := q.db.QueryContext(ctx, getRunsByExperiment, experimentID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []BenchmarkRun
+	for rows.Next() {
+		var i BenchmarkRun
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreationTime,
+			&i.Executor,
+			&i.JobCount,
+			&i.Concurrency,
+			&i.Status,
+			&i.Error,
+			&i.FinishTime,
+			&i.Scenario,
+			&i.Labels,
+			&i.ExperimentID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getSchemaVersion = `-- name: GetSchemaVersion :one
+SELECT CAST(COALESCE(MAX(version), 0) AS INTEGER) AS version
+FROM schema_migrations
+`
+
+func (q *Queries) GetSchemaVersion(ctx context.Context) (int64, error) {
+	row := q.db.QueryRowContext(ctx, getSchemaVersion)
+	var version int64
+	err := row.Scan(&version)
+	return version, err
+}
+
+const getTotalLatenciesInRangeByCommitAndExecutor = `-- name: GetTotalLatenciesInRangeByCommitAndExecutor :many
+SELECT
+    CAST((strftime('%s', r.end_time) - strftime('%s', s.creation_time)) AS INTEGER) AS total_latency
+FROM
+    scheduled_job s
+        INNER JOIN job_results r ON s.id = r.id
+WHERE
+    r.start_time IS NOT NULL
+  AND r.end_time IS NOT NULL
+  AND (datetime(r.start_time) >= datetime(?1) OR ?1 IS NULL)
+  AND (datetime(r.end_time) <= datetime(?2) OR ?2 IS NULL)
+  AND (s.commit_hash = ?3 OR ?3 IS NULL)
+  AND (s.executor = ?4 OR ?4 IS NULL)
+  AND (s.payload_hash = ?5 OR ?5 IS NULL)
+  AND NOT EXISTS (
+      SELECT 1 FROM json_each(?6) l
+      WHERE (SELECT m.value FROM json_each(s.metadata) m WHERE m.key = l.key) IS NOT l.value
+  )
+  AND s.status != 'cancelled'
+ORDER BY
+    total_latency DESC
+`
+
+type GetTotalLatenciesInRangeByCommitAndExecutorParams struct {
+	From        interface{}    `json:"from"`
+	To          interface{}    `json:"to"`
+	CommitHash  sql.NullString `json:"commit_hash"`
+	Executor    string         `json:"executor"`
+	PayloadHash sql.NullString `json:"payload_hash"`
+	Labels      interface{}    `json:"labels"`
+}
+
+func (q *Queries) GetTotalLatenciesInRangeByCommitAndExecutor(ctx context.Context, arg GetTotalLatenciesInRangeByCommitAndExecutorParams) ([]int64, error) {
+	rows, err := q.db.QueryContext(ctx, getTotalLatenciesInRangeByCommitAndExecutor,
+		arg.From,
+		arg.To,
+		arg.CommitHash,
+		arg.Executor,
+		arg.PayloadHash,
+		arg.Labels,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []int64
+	for rows.Next() {
+		var total_latency int64
+		if err := rows.Scan(&total_latency); err != nil {
+			return nil, err
+		}
+		items = append(items, total_latency)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getTotalLatenciesSummaryByGroup = `-- name: GetTotalLatenciesSummaryByGroup :many
+SELECT group_key, total_latency
+FROM (
+    SELECT
+        CAST(CASE ?1
+            WHEN 'executor' THEN s.executor
+            WHEN 'commit_hash' THEN s.commit_hash
+            WHEN 'run' THEN s.run_id
+            WHEN 'hour' THEN strftime('%Y-%m-%dT%H:00:00Z', r.start_time)
+            WHEN 'day' THEN strftime('%Y-%m-%d', r.start_time)
+            ELSE (SELECT m.value FROM json_each(s.metadata) m WHERE m.key = ?2)
+        END AS TEXT) AS group_key,
+        CAST((strftime('%s', r.end_time) - strftime('%s', s.creation_time)) AS INTEGER) AS total_latency
+    FROM
+        scheduled_job s
+            INNER JOIN job_results r ON s.id = r.id
+    WHERE
+        r.start_time IS NOT NULL
+      AND r.end_time IS NOT NULL
+      AND (datetime(r.start_time) >= datetime(?3) OR ?3 IS NULL)
+      AND (datetime(r.end_time) <= datetime(?4) OR ?4 IS NULL)
+      AND (s.commit_hash = ?5 OR ?5 IS NULL)
+      AND (s.executor = ?6 OR ?6 IS NULL)
+      AND (s.payload_hash = ?7 OR ?7 IS NULL)
+      AND NOT EXISTS (
+          SELECT 1 FROM json_each(?8) l
+          WHERE (SELECT m.value FROM json_each(s.metadata) m WHERE m.key = l.key) IS NOT l.value
+      )
+      AND s.status != 'cancelled'
+) grouped
+WHERE group_key IS NOT NULL
+ORDER BY
+    group_key, total_latency ASC
+`
+
+type GetTotalLatenciesSummaryByGroupParams struct {
+	GroupBy     interface{}    `json:"group_by"`
+	GroupLabel  sql.NullString `json:"group_label"`
+	From        interface{}    `json:"from"`
+	To          interface{}    `json:"to"`
+	CommitHash  sql.NullString `json:"commit_hash"`
+	Executor    sql.NullString `json:"executor"`
+	PayloadHash sql.NullString `json:"payload_hash"`
+	Labels      interface{}    `json:"labels"`
+}
+
+type GetTotalLatenciesSummaryByGroupRow struct {
+	GroupKey     string `json:"group_key"`
+	TotalLatency int64  `json:"total_latency"`
+}
+
+func (q *Queries) GetTotalLatenciesSummaryByGroup(ctx context.Context, arg GetTotalLatenciesSummaryByGroupParams) ([]GetTotalLatenciesSummaryByGroupRow, error) {
+	rows, err := q.db.QueryContext(ctx, getTotalLatenciesSummaryByGroup,
+		arg.GroupBy,
+		arg.GroupLabel,
+		arg.From,
+		arg.To,
+		arg.CommitHash,
+		arg.Executor,
+		arg.PayloadHash,
+		arg.Labels,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetTotalLatenciesSummaryByGroupRow
+	for rows.Next() {
+		var i GetTotalLatenciesSummaryByGroupRow
+		if err := rows.Scan(&i.GroupKey, &i.TotalLatency); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getTotalLatenciesSummaryInRangeByCommitAndExecutor = `-- name: GetTotalLatenciesSummaryInRangeByCommitAndExecutor :many
+SELECT
+    CAST((strftime('%s', r.end_time) - strftime('%s', s.creation_time)) AS INTEGER) AS total_latency
+FROM
+    scheduled_job s
+        INNER JOIN job_results r ON s.id = r.id
+WHERE
+    r.start_time IS NOT NULL
+  AND r.end_time IS NOT NULL
+  AND (datetime(r.start_time) >= datetime(?1) OR ?1 IS NULL)
+  AND (datetime(r.end_time) <= datetime(?2) OR ?2 IS NULL)
+  AND (s.commit_hash = ?3 OR ?3 IS NULL)
+  AND (s.executor = ?4 OR ?4 IS NULL)
+  AND (s.payload_hash = ?5 OR ?5 IS NULL)
+  AND NOT EXISTS (
+      SELECT 1 FROM json_each(?6) l
+      WHERE (SELECT m.value FROM json_each(s.metadata) m WHERE m.key = l.key) IS NOT l.value
+  )
+  AND s.status != 'cancelled'
+ORDER BY
+    total_latency ASC
+`
+
+type GetTotalLatenciesSummaryInRangeByCommitAndExecutorParams struct {
+	From        interface{}    `json:"from"`
+	To          interface{}    `json:"to"`
+	CommitHash  sql.NullString `json:"commit_hash"`
+	Executor    string         `json:"executor"`
+	PayloadHash sql.NullString `json:"payload_hash"`
+	Labels      interface{}    `json:"labels"`
+}
+
+func (q *Queries) GetTotalLatenciesSummaryInRangeByCommitAndExecutor(ctx context.Context, arg GetTotalLatenciesSummaryInRangeByCommitAndExecutorParams) ([]int64, error) {
+	rows, err := q.db.QueryContext(ctx, getTotalLatenciesSummaryInRangeByCommitAndExecutor,
+		arg.From,
+		arg.To,
+		arg.CommitHash,
+		arg.Executor,
+		arg.PayloadHash,
+		arg.Labels,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []int64
+	for rows.Next() {
+		var total_latency int64
+		if err := rows.Scan(&total_latency); err != nil {
+			return nil, err
+		}
+		items = append(items, total_latency)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const importRun = `-- name: ImportRun :exec
+INSERT INTO benchmark_run (
+  id, creation_time, executor, job_count, concurrency, status, error, finish_time, scenario, labels, experiment_id
+) VALUES (
+  ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?
+)
+`
+
+type ImportRunParams struct {
+	ID           uuid.UUID      `json:"id"`
+	CreationTime time.Time      `json:"creation_time"`
+	Executor     string         `json:"executor"`
+	JobCount     int64          `json:"job_count"`
+	Concurrency  int64          `json:"concurrency"`
+	Status       string         `json:"status"`
+	Error        sql.NullString `json:"error"`
+	FinishTime   sql.NullTime   `json:"finish_time"`
+	Scenario     sql.NullString `json:"scenario"`
+	Labels       interface{}    `json:"labels"`
+	ExperimentID uuid.NullUUID  `json:"experiment_id"`
+}
+
+func (q *Queries) ImportRun(ctx context.Context, arg ImportRunParams) error {
+	_, err := q.db.ExecContext(ctx, importRun,
+		arg.ID,
+		arg.CreationTime,
+		arg.Executor,
+		arg.JobCount,
+		arg.Concurrency,
+		arg.Status,
+		arg.Error,
+		arg.FinishTime,
+		arg.Scenario,
+		arg.Labels,
+		arg.ExperimentID,
+	)
+	return err
+}
+
+const importScheduledJob = `-- name: ImportScheduledJob :exec
+INSERT INTO scheduled_job (
+  id, creation_time, executor, metadata, commit_hash, status, run_id, payload_id, payload_version, payload_hash
+) VALUES (
+  ?, ?, ?, ?, ?, ?, ?, ?, ?, ?
+)
+`
+
+type ImportScheduledJobParams struct {
+	ID             uuid.UUID      `json:"id"`
+	CreationTime   time.Time      `json:"creation_time"`
+	Executor       string         `json:"executor"`
+	Metadata       interface{}    `json:"metadata"`
+	CommitHash     sql.NullString `json:"commit_hash"`
+	Status         string         `json:"status"`
+	RunID          uuid.NullUUID  `json:"run_id"`
+	PayloadID      uuid.NullUUID  `json:"payload_id"`
+	PayloadVersion sql.NullInt64  `json:"payload_version"`
+	PayloadHash    sql.NullString `json:"payload_hash"`
+}
+
+func (q *Queries) ImportScheduledJob(ctx context.Context, arg ImportScheduledJobParams) error {
+	_, err := q.db.ExecContext(ctx, importScheduledJob,
+		arg.ID,
+		arg.CreationTime,
+		arg.Executor,
+		arg.Metadata,
+		arg.CommitHash,
+		arg.Status,
+		arg.RunID,
+		arg.PayloadID,
+		arg.PayloadVersion,
+		arg.PayloadHash,
+	)
+	return err
+}
+
+const listJobsForExport = `-- name: ListJobsForExport :many
+SELECT
+    s.id, s.creation_time, s.executor, s.metadata, s.commit_hash, s.status, s.run_id, s.payload_id, s.payload_version, s.payload_hash,
+    r.start_time,
+    r.end_time,
+    r.start_time_callbacks,
+    r.end_time_callbacks,
+    r.invalid
+FROM
+    scheduled_job s
+        LEFT JOIN job_results r ON s.id = r.id
+ORDER BY
+    s.creation_time, s.id
+LIMIT ? OFFSET ?
+`
+
+type ListJobsForExportParams struct {
+	Limit  int64 `json:"limit"`
+	Offset int64 `json:"offset"`
+}
+
+type ListJobsForExportRow struct {
+	ID                 uuid.UUID      `json:"id"`
+	CreationTime       time.Time      `json:"creation_time"`
+	Executor           string         `json:"executor"`
+	Metadata           interface{}    `json:"metadata"`
+	CommitHash         sql.NullString `json:"commit_hash"`
+	Status             string         `json:"status"`
+	RunID              uuid.NullUUID  `json:"run_id"`
+	PayloadID          uuid.NullUUID  `json:"payload_id"`
+	PayloadVersion     sql.NullInt64  `json:"payload_version"`
+	PayloadHash        sql.NullString `json:"payload_hash"`
+	StartTime          interface{}    `json:"start_time"`
+	EndTime            interface{}    `json:"end_time"`
+	StartTimeCallbacks sql.NullInt64  `json:"start_time_callbacks"`
+	EndTimeCallbacks   sql.NullInt64  `json:"end_time_callbacks"`
+	Invalid            sql.NullBool   `json:"invalid"`
+}
+
+func (q *Queries) ListJobsForExport(ctx context.Context, arg ListJobsForExportParams) ([]ListJobsForExportRow, error) {
+	rows, err := q.db.QueryContext(ctx, listJobsForExport, arg.Limit, arg.Offset)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
-	var items []int64
+	var items []ListJobsForExportRow
 	for rows.Next() {
-		var build_time int64
-		if err := rows.Scan(&build_time); err != nil {
+		var i ListJobsForExportRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreationTime,
+			&i.Executor,
+			&i.Metadata,
+			&i.CommitHash,
+			&i.Status,
+			&i.RunID,
+			&i.PayloadID,
+			&i.PayloadVersion,
+			&i.PayloadHash,
+			&i.StartTime,
+			&i.EndTime,
+			&i.StartTimeCallbacks,
+			&i.EndTimeCallbacks,
+			&i.Invalid,
+		); err != nil {
 			return nil, err
 		}
-		items = append(items, build_time)
+		items = append(items, i)
 	}
 	if err := rows.Close(); err != nil {
 		return nil, err
@@ -65,48 +1359,85 @@ func (q *Queries) GetBuildTimeSummaryInRangeByCommitAndExecutor(ctx context.Cont
 	return items, nil
 }
 
-const getBuildTimesInRangeByCommitAndExecutor = `-- name: GetBuildTimesInRangeByCommitAndExecutor :many
+const listJobsToPrune = `-- name: ListJobsToPrune :many
 SELECT
-    CAST((strftime('%s', r.end_time) - strftime('%s', r.start_time)) AS INTEGER) AS build_time
+    s.id, s.creation_time, s.executor, s.metadata, s.commit_hash, s.status, s.run_id, s.payload_id, s.payload_version, s.payload_hash,
+    r.start_time,
+    r.end_time,
+    r.start_time_callbacks,
+    r.end_time_callbacks,
+    r.invalid
 FROM
-    job_results r
-        INNER JOIN scheduled_job s ON r.id = s.id
+    scheduled_job s
+        LEFT JOIN job_results r ON s.id = r.id
 WHERE
-    r.start_time IS NOT NULL
-  AND r.end_time IS NOT NULL
-  AND (datetime(r.start_time) >= datetime(?1) OR ?1 IS NULL)
-  AND (datetime(r.end_time) <= datetime(?2) OR ?2 IS NULL)
-  AND (s.commit_hash = ?3 OR ?3 IS NULL)
-  AND (s.executor = ?4 OR ?4 IS NULL)
+    (julianday(s.creation_time) < julianday(?1) OR ?1 IS NULL)
+  AND (s.executor = ?2 OR ?2 IS NULL)
+  AND (s.run_id = ?3 OR ?3 IS NULL)
 ORDER BY
-    build_time DESC
+    s.creation_time
+LIMIT ?4
 `
 
-type GetBuildTimesInRangeByCommitAndExecutorParams struct {
-	From       interface{}    `json:"from"`
-	To         interface{}    `json:"to"`
-	CommitHash sql.NullString `json:"commit_hash"`
-	Executor   string         `json:"executor"`
+type ListJobsToPruneParams struct {
+	Before    interface{}    `json:"before"`
+	Executor  sql.NullString `json:"executor"`
+	RunID     uuid.NullUUID  `json:"run_id"`
+	BatchSize int64          `json:"batch_size"`
 }
 
-func (q *Queries) GetBuildTimesInRangeByCommitAndExecutor(ctx context.Context, arg GetBuildTimesInRangeByCommitAndExecutorParams) ([]int64, error) {
-	rows, err := q.db.QueryContext(ctx, getBuildTimesInRangeByCommitAndExecutor,
-		arg.From,
-		arg.To,
-		arg.CommitHash,
+type ListJobsToPruneRow struct {
+	ID                 uuid.UUID      `json:"id"`
+	CreationTime       time.Time      `json:"creation_time"`
+	Executor           string         `json:"executor"`
+	Metadata           interface{}    `json:"metadata"`
+	CommitHash         sql.NullString `json:"commit_hash"`
+	Status             string         `json:"status"`
+	RunID              uuid.NullUUID  `json:"run_id"`
+	PayloadID          uuid.NullUUID  `json:"payload_id"`
+	PayloadVersion     sql.NullInt64  `json:"payload_version"`
+	PayloadHash        sql.NullString `json:"payload_hash"`
+	StartTime          interface{}    `json:"start_time"`
+	EndTime            interface{}    `json:"end_time"`
+	StartTimeCallbacks sql.NullInt64  `json:"start_time_callbacks"`
+	EndTimeCallbacks   sql.NullInt64  `json:"end_time_callbacks"`
+	Invalid            sql.NullBool   `json:"invalid"`
+}
+
+func (q *Queries) ListJobsToPrune(ctx context.Context, arg ListJobsToPruneParams) ([]ListJobsToPruneRow, error) {
+	rows, err := q.db.QueryContext(ctx, listJobsToPrune,
+		arg.Before,
 		arg.Executor,
+		arg.RunID,
+		arg.BatchSize,
 	)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
-	var items []int64
+	var items []ListJobsToPruneRow
 	for rows.Next() {
-		var build_time int64
-		if err := rows.Scan(&build_time); err != nil {
+		var i ListJobsToPruneRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreationTime,
+			&i.Executor,
+			&i.Metadata,
+			&i.CommitHash,
+			&i.Status,
+			&i.RunID,
+			&i.PayloadID,
+			&i.PayloadVersion,
+			&i.PayloadHash,
+			&i.StartTime,
+			&i.EndTime,
+			&i.StartTimeCallbacks,
+			&i.EndTimeCallbacks,
+			&i.Invalid,
+		); err != nil {
 			return nil, err
 		}
-		items = append(items, build_time)
+		items = append(items, i)
 	}
 	if err := rows.Close(); err != nil {
 		return nil, err
@@ -117,47 +1448,68 @@ func (q *Queries) GetBuildTimesInRangeByCommitAndExecutor(ctx context.Context, a
 	return items, nil
 }
 
-const getQueueLatenciesInRangeByCommitAndExecutor = `-- name: GetQueueLatenciesInRangeByCommitAndExecutor :many
-SELECT
-    CAST((strftime('%s', r.start_time) - strftime('%s', s.creation_time)) AS INTEGER) AS queue_latency
-FROM
-    scheduled_job s
-        INNER JOIN job_results r ON s.id = r.id
-WHERE
-    r.start_time IS NOT NULL
-  AND (datetime(r.start_time) >= datetime(?1) OR ?1 IS NULL)
-  AND (datetime(r.start_time) <= datetime(?2) OR ?2 IS NULL)
-  AND (s.commit_hash = ?3 OR ?3 IS NULL)
-  AND (s.executor = ?4 OR ?4 IS NULL)
-ORDER BY
-    queue_latency DESC
+const listOrphanCallbacks = `-- name: ListOrphanCallbacks :many
+SELECT id, job_id, callback, reported_time, received_at FROM orphan_callbacks
+ORDER BY received_at DESC, id DESC
+LIMIT ?
 `
 
-type GetQueueLatenciesInRangeByCommitAndExecutorParams struct {
-	From       interface{}    `json:"from"`
-	To         interface{}    `json:"to"`
-	CommitHash sql.NullString `json:"commit_hash"`
-	Executor   string         `json:"executor"`
+func (q *Queries) ListOrphanCallbacks(ctx context.Context, limit int64) ([]OrphanCallback, error) {
+	rows, err := q.db.QueryContext(ctx, listOrphanCallbacks, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []OrphanCallback
+	for rows.Next() {
+		var i OrphanCallback
+		if err := rows.Scan(
+			&i.ID,
+			&i.JobID,
+			&i.Callback,
+			&i.ReportedTime,
+			&i.ReceivedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
 }
 
-func (q *Queries) GetQueueLatenciesInRangeByCommitAndExecutor(ctx context.Context, arg GetQueueLatenciesInRangeByCommitAndExecutorParams) ([]int64, error) {
-	rows, err := q.db.QueryContext(ctx, getQueueLatenciesInRangeByCommitAndExecutor,
-		arg.From,
-		arg.To,
-		arg.CommitHash,
-		arg.Executor,
-	)
+const listPayloadVersions = `-- name: ListPayloadVersions :many
+SELECT id, version, name, content, content_hash, creation_time, deleted FROM payload
+WHERE id = ? AND deleted = false
+ORDER BY version
+`
+
+func (q *Queries) ListPayloadVersions(ctx context.Context, id uuid.UUID) ([]Payload, error) {
+	rows, err := q.db.QueryContext(ctx, listPayloadVersions, id)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
-	var items []int64
+	var items []Payload
 	for rows.Next() {
-		var queue_latency int64
-		if err := rows.Scan(&queue_latency); err != nil {
+		var i Payload
+		if err := rows.Scan(
+			&i.ID,
+			&i.Version,
+			&i.Name,
+			&i.Content,
+			&i.ContentHash,
+			&i.CreationTime,
+			&i.Deleted,
+		); err != nil {
 			return nil, err
 		}
-		items = append(items, queue_latency)
+		items = append(items, i)
 	}
 	if err := rows.Close(); err != nil {
 		return nil, err
@@ -168,47 +1520,63 @@ func (q *Queries) GetQueueLatenciesInRangeByCommitAndExecutor(ctx context.Contex
 	return items, nil
 }
 
-const getQueueLatencySummaryInRangeByCommitAndExecutor = `-- name: GetQueueLatencySummaryInRangeByCommitAndExecutor :many
-SELECT
-    CAST((strftime('%s', r.start_time) - strftime('%s', s.creation_time)) AS INTEGER) AS latency
-FROM
-    scheduled_job s
-        INNER JOIN job_results r ON s.id = r.id
-WHERE
-    r.start_time IS NOT NULL
-  AND (datetime(r.start_time) >= datetime(?1) OR ?1 IS NULL)
-  AND (datetime(r.start_time) <= datetime(?2) OR ?2 IS NULL)
-  AND (s.commit_hash = ?3 OR ?3 IS NULL)
-  AND (s.executor = ?4 OR ?4 IS NULL)
-ORDER BY
-    latency ASC
+const listPayloads = `-- name: ListPayloads :many
+SELECT p.id, p.version, p.name, p.content, p.content_hash, p.creation_time, p.deleted FROM payload p
+WHERE p.deleted = false
+  AND p.version = (SELECT MAX(version) FROM payload WHERE id = p.id)
+ORDER BY p.name, p.id
 `
 
-type GetQueueLatencySummaryInRangeByCommitAndExecutorParams struct {
-	From       interface{}    `json:"from"`
-	To         interface{}    `json:"to"`
-	CommitHash sql.NullString `json:"commit_hash"`
-	Executor   string         `json:"executor"`
+func (q *Queries) ListPayloads(ctx context.Context) ([]Payload, error) {
+	rows, err := q.db.QueryContext(ctx, listPayloads)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Payload
+	for rows.Next() {
+		var i Payload
+		if err := rows.Scan(
+			&i.ID,
+			&i.Version,
+			&i.Name,
+			&i.Content,
+			&i.ContentHash,
+			&i.CreationTime,
+			&i.Deleted,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
 }
 
-func (q *Queries) GetQueueLatencySummaryInRangeByCommitAndExecutor(ctx context.Context, arg GetQueueLatencySummaryInRangeByCommitAndExecutorParams) ([]int64, error) {
-	rows, err := q.db.QueryContext(ctx, getQueueLatencySummaryInRangeByCommitAndExecutor,
-		arg.From,
-		arg.To,
-		arg.CommitHash,
-		arg.Executor,
-	)
+const listRunIDsAfterOffset = `-- name: ListRunIDsAfterOffset :many
+SELECT id FROM benchmark_run
+ORDER BY creation_time DESC
+LIMIT -1 OFFSET ?
+`
+
+func (q *Queries) ListRunIDsAfterOffset(ctx context.Context, offset int64) ([]uuid.UUID, error) {
+	rows, err := q.db.QueryContext(ctx, listRunIDsAfterOffset, offset)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
-	var items []int64
+	var items []uuid.UUID
 	for rows.Next() {
-		var latency int64
-		if err := rows.Scan(&latency); err != nil {
+		var id uuid.UUID
+		if err := rows.Scan(&id); err != nil {
 			return nil, err
 		}
-		items = append(items, latency)
+		items = append(items, id)
 	}
 	if err := rows.Close(); err != nil {
 		return nil, err
@@ -219,48 +1587,42 @@ func (q *Queries) GetQueueLatencySummaryInRangeByCommitAndExecutor(ctx context.C
 	return items, nil
 }
 
-const getTotalLatenciesInRangeByCommitAndExecutor = `-- name: GetTotalLatenciesInRangeByCommitAndExecutor :many
-SELECT
-    CAST((strftime('%s', r.end_time) - strftime('%s', s.creation_time)) AS INTEGER) AS total_latency
-FROM
-    scheduled_job s
-        INNER JOIN job_results r ON s.id = r.id
-WHERE
-    r.start_time IS NOT NULL
-  AND r.end_time IS NOT NULL
-  AND (datetime(r.start_time) >= datetime(?1) OR ?1 IS NULL)
-  AND (datetime(r.end_time) <= datetime(?2) OR ?2 IS NULL)
-  AND (s.commit_hash = ?3 OR ?3 IS NULL)
-  AND (s.executor = ?4 OR ?4 IS NULL)
-ORDER BY
-    total_latency DESC
+const listRunsForExport = `-- name: ListRunsForExport :many
+SELECT id, creation_time, executor, job_count, concurrency, status, error, finish_time, scenario, labels, experiment_id FROM benchmark_run
+ORDER BY creation_time, id
+LIMIT ? OFFSET ?
 `
 
-type GetTotalLatenciesInRangeByCommitAndExecutorParams struct {
-	From       interface{}    `json:"from"`
-	To         interface{}    `json:"to"`
-	CommitHash sql.NullString `json:"commit_hash"`
-	Executor   string         `json:"executor"`
+type ListRunsForExportParams struct {
+	Limit  int64 `json:"limit"`
+	Offset int64 `json:"offset"`
 }
 
-func (q *Queries) GetTotalLatenciesInRangeByCommitAndExecutor(ctx context.Context, arg GetTotalLatenciesInRangeByCommitAndExecutorParams) ([]int64, error) {
-	rows, err := q.db.QueryContext(ctx, getTotalLatenciesInRangeByCommitAndExecutor,
-		arg.From,
-		arg.To,
-		arg.CommitHash,
-		arg.Executor,
-	)
+func (q *Queries) ListRunsForExport(ctx context.Context, arg ListRunsForExportParams) ([]BenchmarkRun, error) {
+	rows, err := q.db.QueryContext(ctx, listRunsForExport, arg.Limit, arg.Offset)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
-	var items []int64
+	var items []BenchmarkRun
 	for rows.Next() {
-		var total_latency int64
-		if err := rows.Scan(&total_latency); err != nil {
+		var i BenchmarkRun
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreationTime,
+			&i.Executor,
+			&i.JobCount,
+			&i.Concurrency,
+			&i.Status,
+			&i.Error,
+			&i.FinishTime,
+			&i.Scenario,
+			&i.Labels,
+			&i.ExperimentID,
+		); err != nil {
 			return nil, err
 		}
-		items = append(items, total_latency)
+		items = append(items, i)
 	}
 	if err := rows.Close(); err != nil {
 		return nil, err
@@ -271,48 +1633,86 @@ func (q *Queries) GetTotalLatenciesInRangeByCommitAndExecutor(ctx context.Contex
 	return items, nil
 }
 
-const getTotalLatenciesSummaryInRangeByCommitAndExecutor = `-- name: GetTotalLatenciesSummaryInRangeByCommitAndExecutor :many
-SELECT
-    CAST((strftime('%s', r.end_time) - strftime('%s', s.creation_time)) AS INTEGER) AS total_latency
-FROM
-    scheduled_job s
-        INNER JOIN job_results r ON s.id = r.id
-WHERE
-    r.start_time IS NOT NULL
-  AND r.end_time IS NOT NULL
-  AND (datetime(r.start_time) >= datetime(?1) OR ?1 IS NULL)
-  AND (datetime(r.end_time) <= datetime(?2) OR ?2 IS NULL)
-  AND (s.commit_hash = ?3 OR ?3 IS NULL)
-  AND (s.executor = ?4 OR ?4 IS NULL)
-ORDER BY
-    total_latency ASC
+const listRunsToPrune = `-- name: ListRunsToPrune :many
+SELECT id, creation_time, executor, job_count, concurrency, status, error, finish_time, scenario, labels, experiment_id FROM benchmark_run b
+WHERE b.status != 'running'
+  AND NOT EXISTS (SELECT 1 FROM scheduled_job s WHERE s.run_id = b.id)
+  AND (julianday(b.creation_time) < julianday(?1) OR ?1 IS NULL)
+  AND (b.executor = ?2 OR ?2 IS NULL)
+  AND (b.id = ?3 OR ?3 IS NULL)
+ORDER BY b.creation_time
 `
 
-type GetTotalLatenciesSummaryInRangeByCommitAndExecutorParams struct {
-	From       interface{}    `json:"from"`
-	To         interface{}    `json:"to"`
-	CommitHash sql.NullString `json:"commit_hash"`
-	Executor   string         `json:"executor"`
+type ListRunsToPruneParams struct {
+	Before   interface{}    `json:"before"`
+	Executor sql.NullString `json:"executor"`
+	RunID    uuid.NullUUID  `json:"run_id"`
 }
 
-func (q *Queries) GetTotalLatenciesSummaryInRangeByCommitAndExecutor(ctx context.Context, arg GetTotalLatenciesSummaryInRangeByCommitAndExecutorParams) ([]int64, error) {
-	rows, err := q.db.QueryContext(ctx, getTotalLatenciesSummaryInRangeByCommitAndExecutor,
-		arg.From,
-		arg.To,
-		arg.CommitHash,
-		arg.Executor,
-	)
+func (q *Queries) ListRunsToPrune(ctx context.Context, arg ListRunsToPruneParams) ([]BenchmarkRun, error) {
+	rows, err := q.db.QueryContext(ctx, listRunsToPrune, arg.Before, arg.Executor, arg.RunID)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
-	var items []int64
+	var items []BenchmarkRun
 	for rows.Next() {
-		var total_latency int64
-		if err := rows.Scan(&total_latency); err != nil {
+		var i BenchmarkRun
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreationTime,
+			&i.Executor,
+			&i.JobCount,
+			&i.Concurrency,
+			&i.Status,
+			&i.Error,
+			&i.FinishTime,
+			&i.Scenario,
+			&i.Labels,
+			&i.ExperimentID,
+		); err != nil {
 			return nil, err
 		}
-		items = append(items, total_latency)
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listSubmissionAttemptsByRun = `-- name: ListSubmissionAttemptsByRun :many
+SELECT run_id, job_index, attempt, submit_delay_ms, start_time, duration_ms, status_code, error, job_id FROM submission_attempt
+WHERE run_id = ?
+ORDER BY job_index, attempt
+`
+
+func (q *Queries) ListSubmissionAttemptsByRun(ctx context.Context, runID uuid.UUID) ([]SubmissionAttempt, error) {
+	rows, err := q.db.QueryContext(ctx, listSubmissionAttemptsByRun, runID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []SubmissionAttempt
+	for rows.Next() {
+		var i SubmissionAttempt
+		if err := rows.Scan(
+			&i.RunID,
+			&i.JobIndex,
+			&i.Attempt,
+			&i.SubmitDelayMs,
+			&i.StartTime,
+			&i.DurationMs,
+			&i.StatusCode,
+			&i.Error,
+			&i.JobID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
 	}
 	if err := rows.Close(); err != nil {
 		return nil, err
@@ -323,20 +1723,103 @@ func (q *Queries) GetTotalLatenciesSummaryInRangeByCommitAndExecutor(ctx context
 	return items, nil
 }
 
+const recordMigration = `-- name: RecordMigration :exec
+INSERT INTO schema_migrations (
+  version, name, applied_at
+) VALUES (
+  ?, ?, ?
+)
+`
+
+type RecordMigrationParams struct {
+	Version   int64     `json:"version"`
+	Name      string    `json:"name"`
+	AppliedAt time.Time `json:"applied_at"`
+}
+
+func (q *Queries) RecordMigration(ctx context.Context, arg RecordMigrationParams) error {
+	_, err := q.db.ExecContext(ctx, recordMigration, arg.Version, arg.Name, arg.AppliedAt)
+	return err
+}
+
+const saveJobResult = `-- name: SaveJobResult :exec
+INSERT INTO job_results (
+  id, start_time, end_time, start_time_callbacks, end_time_callbacks, invalid
+) VALUES (
+  ?, ?, ?, ?, ?, ?
+)
+ON CONFLICT (id) DO UPDATE
+  SET start_time = EXCLUDED.start_time,
+  end_time = EXCLUDED.end_time,
+  start_time_callbacks = EXCLUDED.start_time_callbacks,
+  end_time_callbacks = EXCLUDED.end_time_callbacks,
+  invalid = EXCLUDED.invalid
+`
+
+type SaveJobResultParams struct {
+	ID                 uuid.UUID   `json:"id"`
+	StartTime          interface{} `json:"start_time"`
+	EndTime            interface{} `json:"end_time"`
+	StartTimeCallbacks int64       `json:"start_time_callbacks"`
+	EndTimeCallbacks   int64       `json:"end_time_callbacks"`
+	Invalid            bool        `json:"invalid"`
+}
+
+func (q *Queries) SaveJobResult(ctx context.Context, arg SaveJobResultParams) error {
+	_, err := q.db.ExecContext(ctx, saveJobResult,
+		arg.ID,
+		arg.StartTime,
+		arg.EndTime,
+		arg.StartTimeCallbacks,
+		arg.EndTimeCallbacks,
+		arg.Invalid,
+	)
+	return err
+}
+
+const storeOrphanCallback = `-- name: StoreOrphanCallback :exec
+INSERT INTO orphan_callbacks (
+  job_id, callback, reported_time, received_at
+) VALUES (
+  ?, ?, ?, ?
+)
+`
+
+type StoreOrphanCallbackParams struct {
+	JobID        uuid.UUID `json:"job_id"`
+	Callback     string    `json:"callback"`
+	ReportedTime time.Time `json:"reported_time"`
+	ReceivedAt   time.Time `json:"received_at"`
+}
+
+func (q *Queries) StoreOrphanCallback(ctx context.Context, arg StoreOrphanCallbackParams) error {
+	_, err := q.db.ExecContext(ctx, storeOrphanCallback,
+		arg.JobID,
+		arg.Callback,
+		arg.ReportedTime,
+		arg.ReceivedAt,
+	)
+	return err
+}
+
 const storeScheduledJob = `-- name: StoreScheduledJob :one
 INSERT INTO scheduled_job (
-  id, creation_time, executor, commit_hash
+  id, creation_time, executor, commit_hash, run_id, payload_id, payload_version, payload_hash
 ) VALUES (
-  ?, ?, ?, ?
+  ?, ?, ?, ?, ?, ?, ?, ?
 )
-RETURNING id, creation_time, executor, metadata, commit_hash
+RETURNING id, creation_time, executor, metadata, commit_hash, status, run_id, payload_id, payload_version, payload_hash
 `
 
 type StoreScheduledJobParams struct {
-	ID           uuid.UUID      `json:"id"`
-	CreationTime time.Time      `json:"creation_time"`
-	Executor     string         `json:"executor"`
-	CommitHash   sql.NullString `json:"commit_hash"`
+	ID             uuid.UUID      `json:"id"`
+	CreationTime   time.Time      `json:"creation_time"`
+	Executor       string         `json:"executor"`
+	CommitHash     sql.NullString `json:"commit_hash"`
+	RunID          uuid.NullUUID  `json:"run_id"`
+	PayloadID      uuid.NullUUID  `json:"payload_id"`
+	PayloadVersion sql.NullInt64  `json:"payload_version"`
+	PayloadHash    sql.NullString `json:"payload_hash"`
 }
 
 func (q *Queries) StoreScheduledJob(ctx context.Context, arg StoreScheduledJobParams) (ScheduledJob, error) {
@@ -345,6 +1828,10 @@ func (q *Queries) StoreScheduledJob(ctx context.Context, arg StoreScheduledJobPa
 		arg.CreationTime,
 		arg.Executor,
 		arg.CommitHash,
+		arg.RunID,
+		arg.PayloadID,
+		arg.PayloadVersion,
+		arg.PayloadHash,
 	)
 	var i ScheduledJob
 	err := row.Scan(
@@ -353,25 +1840,34 @@ func (q *Queries) StoreScheduledJob(ctx context.Context, arg StoreScheduledJobPa
 		&i.Executor,
 		&i.Metadata,
 		&i.CommitHash,
+		&i.Status,
+		&i.RunID,
+		&i.PayloadID,
+		&i.PayloadVersion,
+		&i.PayloadHash,
 	)
 	return i, err
 }
 
 const storeScheduledJobWithMetadata = `-- name: StoreScheduledJobWithMetadata :one
 INSERT INTO scheduled_job (
-  id, creation_time, executor, metadata, commit_hash
+  id, creation_time, executor, metadata, commit_hash, run_id, payload_id, payload_version, payload_hash
 ) VALUES (
-  ?, ?, ?, ?, ?
+  ?, ?, ?, ?, ?, ?, ?, ?, ?
 )
-RETURNING id, creation_time, executor, metadata, commit_hash
+RETURNING id, creation_time, executor, metadata, commit_hash, status, run_id, payload_id, payload_version, payload_hash
 `
 
 type StoreScheduledJobWithMetadataParams struct {
-	ID           uuid.UUID      `json:"id"`
-	CreationTime time.Time      `json:"creation_time"`
-	Executor     string         `json:"executor"`
-	Metadata     interface{}    `json:"metadata"`
-	CommitHash   sql.NullString `json:"commit_hash"`
+	ID             uuid.UUID      `json:"id"`
+	CreationTime   time.Time      `json:"creation_time"`
+	Executor       string         `json:"executor"`
+	Metadata       interface{}    `json:"metadata"`
+	CommitHash     sql.NullString `json:"commit_hash"`
+	RunID          uuid.NullUUID  `json:"run_id"`
+	PayloadID      uuid.NullUUID  `json:"payload_id"`
+	PayloadVersion sql.NullInt64  `json:"payload_version"`
+	PayloadHash    sql.NullString `json:"payload_hash"`
 }
 
 func (q *Queries) StoreScheduledJobWithMetadata(ctx context.Context, arg StoreScheduledJobWithMetadataParams) (ScheduledJob, error) {
@@ -381,6 +1877,10 @@ func (q *Queries) StoreScheduledJobWithMetadata(ctx context.Context, arg StoreSc
 		arg.Executor,
 		arg.Metadata,
 		arg.CommitHash,
+		arg.RunID,
+		arg.PayloadID,
+		arg.PayloadVersion,
+		arg.PayloadHash,
 	)
 	var i ScheduledJob
 	err := row.Scan(
@@ -389,48 +1889,110 @@ func (q *Queries) StoreScheduledJobWithMetadata(ctx context.Context, arg StoreSc
 		&i.Executor,
 		&i.Metadata,
 		&i.CommitHash,
+		&i.Status,
+		&i.RunID,
+		&i.PayloadID,
+		&i.PayloadVersion,
+		&i.PayloadHash,
 	)
 	return i, err
 }
 
-const upsertJobEndTime = `-- name: UpsertJobEndTime :one
-INSERT INTO job_results (id, end_time)
-VALUES (?, ?)
-ON CONFLICT (id) DO UPDATE
-  SET end_time = EXCLUDED.end_time
-RETURNING id, start_time, end_time
+const storeSubmissionAttempt = `-- name: StoreSubmissionAttempt :exec
+INSERT INTO submission_attempt (
+  run_id, job_index, attempt, submit_delay_ms, start_time, duration_ms, status_code, error, job_id
+) VALUES (
+  ?, ?, ?, ?, ?, ?, ?, ?, ?
+)
 `
 
-type UpsertJobEndTimeParams struct {
-	ID      uuid.UUID   `json:"id"`
-	EndTime interface{} `json:"end_time"`
+type StoreSubmissionAttemptParams struct {
+	RunID         uuid.UUID      `json:"run_id"`
+	JobIndex      int64          `json:"job_index"`
+	Attempt       int64          `json:"attempt"`
+	SubmitDelayMs int64          `json:"submit_delay_ms"`
+	StartTime     time.Time      `json:"start_time"`
+	DurationMs    int64          `json:"duration_ms"`
+	StatusCode    sql.NullInt64  `json:"status_code"`
+	Error         sql.NullString `json:"error"`
+	JobID         uuid.NullUUID  `json:"job_id"`
 }
 
-func (q *Queries) UpsertJobEndTime(ctx context.Context, arg UpsertJobEndTimeParams) (JobResult, error) {
-	row := q.db.QueryRowContext(ctx, upsertJobEndTime, arg.ID, arg.EndTime)
-	var i JobResult
-	err := row.Scan(&i.ID, &i.StartTime, &i.EndTime)
-	return i, err
+func (q *Queries) StoreSubmissionAttempt(ctx context.Context, arg StoreSubmissionAttemptParams) error {
+	_, err := q.db.ExecContext(ctx, storeSubmissionAttempt,
+		arg.RunID,
+		arg.JobIndex,
+		arg.Attempt,
+		arg.SubmitDelayMs,
+		arg.StartTime,
+		arg.DurationMs,
+		arg.StatusCode,
+		arg.Error,
+		arg.JobID,
+	)
+	return err
 }
 
-const upsertJobStartTime = `-- name: UpsertJobStartTime :one
-INSERT INTO job_results (id, start_time)
-VALUES (?, ?)
-ON CONFLICT (id) DO UPDATE
-  SET start_time = EXCLUDED.start_time
-RETURNING id, start_time, end_time
+const updateExperimentStatus = `-- name: UpdateExperimentStatus :exec
+UPDATE experiment
+SET status = ?, error = ?, finish_time = ?
+WHERE id = ?
 `
 
-type UpsertJobStartTimeParams struct {
-	ID        uuid.UUID   `json:"id"`
-	StartTime interface{} `json:"start_time"`
+type UpdateExperimentStatusParams struct {
+	Status     string         `json:"status"`
+	Error      sql.NullString `json:"error"`
+	FinishTime sql.NullTime   `json:"finish_time"`
+	ID         uuid.UUID      `json:"id"`
 }
 
-func (q *Queries) UpsertJobStartTime(ctx context.Context, arg UpsertJobStartTimeParams) (JobResult, error) {
-	row := q.db.QueryRowContext(ctx, upsertJobStartTime, arg.ID, arg.StartTime)
-	var i JobResult
-	err := row.Scan(&i.ID, &i.StartTime, &i.EndTime)
-	return i, err
+func (q *Queries) UpdateExperimentStatus(ctx context.Context, arg UpdateExperimentStatusParams) error {
+	_, err := q.db.ExecContext(ctx, updateExperimentStatus,
+		arg.Status,
+		arg.Error,
+		arg.FinishTime,
+		arg.ID,
+	)
+	return err
+}
+
+const updateJobStatus = `-- name: UpdateJobStatus :exec
+UPDATE scheduled_job
+SET status = ?
+WHERE id = ?
+`
+
+type UpdateJobStatusParams struct {
+	Status string    `json:"status"`
+	ID     uuid.UUID `json:"id"`
+}
+
+func (q *Queries) UpdateJobStatus(ctx context.Context, arg UpdateJobStatusParams) error {
+	_, err := q.db.ExecContext(ctx, updateJobStatus, arg.Status, arg.ID)
+	return err
+}
+
+const updateRunStatus = `-- name: UpdateRunStatus :exec
+UPDATE benchmark_run
+SET status = ?, error = ?, finish_time = ?
+WHERE id = ?
+`
+
+type UpdateRunStatusParams struct {
+	Status     string         `json:"status"`
+	Error      sql.NullString `json:"error"`
+	FinishTime sql.NullTime   `json:"finish_time"`
+	ID         uuid.UUID      `json:"id"`
+}
+
+func (q *Queries) UpdateRunStatus(ctx context.Context, arg UpdateRunStatusParams) error {
+	_, err := q.db.ExecContext(ctx, updateRunStatus,
+		arg.Status,
+		arg.Error,
+		arg.FinishTime,
+		arg.ID,
+	)
+	return err
 }
 
 const upsertJobTimes = `-- name: UpsertJobTimes :one
@@ -439,7 +2001,7 @@ INSERT INTO job_results (id, start_time, end_time)
 ON CONFLICT (id) DO UPDATE
   SET start_time = EXCLUDED.start_time,
   end_time = EXCLUDED.end_time
-RETURNING id, start_time, end_time
+RETURNING id, start_time, end_time, start_time_callbacks, end_time_callbacks, invalid
 `
 
 type UpsertJobTimesParams struct {
@@ -451,6 +2013,13 @@ type UpsertJobTimesParams struct {
 func (q *Queries) UpsertJobTimes(ctx context.Context, arg UpsertJobTimesParams) (JobResult, error) {
 	row := q.db.QueryRowContext(ctx, upsertJobTimes, arg.ID, arg.StartTime, arg.EndTime)
 	var i JobResult
-	err := row.Scan(&i.ID, &i.StartTime, &i.EndTime)
+	err := row.Scan(
+		&i.ID,
+		&i.StartTime,
+		&i.EndTime,
+		&i.StartTimeCallbacks,
+		&i.EndTimeCallbacks,
+		&i.Invalid,
+	)
 	return i, err
 }
@@ -4,52 +4,201 @@ import (
 	"context"
 	"database/sql"
 	_ "embed"
+	"encoding/json"
 	"errors"
 	"fmt"
-	"log/slog"
+	"io"
 	"strings"
-	"sync"
 	"time"
 
 	"github.com/mattn/go-sqlite3"
 
 	"github.com/Mtze/CI-Benchmarker/persister/model"
+	"github.com/Mtze/CI-Benchmarker/persister/postgresmodel"
 	"github.com/google/uuid"
 )
 
-const file string = "benchmark.db"
 const maxAttempts = 5
 
 // Persister interface
 // This interface is used to store the job and the result of the job
 // This implementation allows to abstract the concrete storage mechanism
+// Writes are retried while the database is busy, an error is returned if they fail nevertheless
 type Persister interface {
-	StoreJobWithMetadata(uuid uuid.UUID, creationTime time.Time, executor string, metaData *string, commitHash *string)
-	StoreJob(uuid uuid.UUID, creationTime time.Time, executor string, commitHash *string)
-	StoreStartTime(uuid uuid.UUID, startTime time.Time)
-	StoreResult(uuid uuid.UUID, time time.Time)
+	// StoreJobWithMetadata stores a job together with its labels, encoded as JSON object in metaData
+	StoreJobWithMetadata(uuid uuid.UUID, creationTime time.Time, executor string, metaData *string, commitHash *string, runID *uuid.UUID, jobPayload JobPayload) error
+	StoreJob(uuid uuid.UUID, creationTime time.Time, executor string, commitHash *string, runID *uuid.UUID, jobPayload JobPayload) error
+	// StoreStartTime and StoreResult store the times reported by the pipeline of a job. The times of jobs
+	// which were never scheduled are stored as orphan callbacks and ErrUnknownJob is returned.
+	StoreStartTime(uuid uuid.UUID, startTime time.Time) error
+	StoreResult(uuid uuid.UUID, time time.Time) error
+	// StoreJobStatus records the last known state of a job as reported by the CI system
+	StoreJobStatus(uuid uuid.UUID, status string) error
+	// StoreObservedTimes stores start/end times observed by polling the CI system.
+	// Times reported by the pipeline itself take precedence and are never overwritten.
+	StoreObservedTimes(uuid uuid.UUID, startTime *time.Time, endTime *time.Time) error
+	// StoreRun records a benchmark run which groups the jobs scheduled by a single benchmark request
+	StoreRun(run Run) error
+	// UpdateRunStatus sets the status of a run, runErr is stored if the run failed
+	UpdateRunStatus(runID uuid.UUID, status string, runErr error) error
+	// CancelPendingJobsByRun marks the jobs of a run which did not finish yet as cancelled
+	CancelPendingJobsByRun(runID uuid.UUID) (int64, error)
+	// StoreSubmissionAttempt records a single attempt to submit a job of a run
+	StoreSubmissionAttempt(attempt SubmissionAttempt) error
+	// StoreExperiment records an experiment which groups the runs of a scenario matrix
+	StoreExperiment(experiment Experiment) error
+	// UpdateExperimentStatus sets the status of an experiment, experimentErr is stored if the experiment failed
+	UpdateExperimentStatus(experimentID uuid.UUID, status string, experimentErr error) error
+}
+
+// Database is a Persister which also reads the stored jobs, runs and payloads and maintains the database.
+// It is implemented by DBPersister, the handlers and background components depend on it instead.
+type Database interface {
+	Persister
+
+	GetExperiment(experimentID uuid.UUID) (model.Experiment, error)
+	GetRunsByExperiment(experimentID uuid.UUID) ([]model.BenchmarkRun, error)
+	GetJobTimesByExperiment(experimentID uuid.UUID) ([]model.GetJobTimesByExperimentRow, error)
+	GetRun(runID uuid.UUID) (model.BenchmarkRun, error)
+	GetRunJobStats(runID uuid.UUID) (model.GetRunJobStatsRow, error)
+	GetRunSubmissionStats(runID uuid.UUID) (model.GetRunSubmissionStatsRow, error)
+	GetPendingJobIDsByRun(runID uuid.UUID) ([]uuid.UUID, error)
+	ListOrphanCallbacks(limit int) ([]model.OrphanCallback, error)
+
+	GetQueueLatenciesInRange(filter MetricsFilter) ([]int64, error)
+	GetBuildTimesInRange(filter MetricsFilter) ([]int64, error)
+	GetTotalLatenciesInRange(filter MetricsFilter) ([]int64, error)
+	GetQueueLatencySummaryInRange(filter MetricsFilter) ([]int64, error)
+	GetBuildTimeSummaryInRange(filter MetricsFilter) ([]int64, error)
+	GetTotalLatenciesSummaryInRange(filter MetricsFilter) ([]int64, error)
+	GetQueueLatencySummaryByGroup(filter MetricsFilter, grouping Grouping) (map[string][]int64, error)
+	GetBuildTimeSummaryByGroup(filter MetricsFilter, grouping Grouping) (map[string][]int64, error)
+	GetTotalLatenciesSummaryByGroup(filter MetricsFilter, grouping Grouping) (map[string][]int64, error)
+
+	StorePayloadVersion(version PayloadVersion) (model.Payload, error)
+	GetLatestPayloadVersion(id uuid.UUID) (model.Payload, error)
+	GetPayloadVersion(id uuid.UUID, version int) (model.Payload, error)
+	ListPayloads() ([]model.Payload, error)
+	ListPayloadVersions(id uuid.UUID) ([]model.Payload, error)
+	DeletePayload(id uuid.UUID) (int64, error)
+
+	// JobCreationTimeAt and RunIDsAfter find the jobs and runs beyond the limits of a retention policy
+	JobCreationTimeAt(offset int) (*time.Time, error)
+	RunIDsAfter(n int) ([]uuid.UUID, error)
+	Prune(ctx context.Context, filter PruneFilter, archive Archive) (PruneResult, error)
+	Backup(ctx context.Context, path string) error
+	Export(ctx context.Context, archive Archive) error
+	Import(ctx context.Context, r io.Reader, options ImportOptions) (ImportResult, error)
+	SchemaVersion() (int64, error)
+	Close() error
+}
+
+var _ Database = DBPersister{}
+
+// Run describes a benchmark run when it is created
+type Run struct {
+	ID           uuid.UUID
+	CreationTime time.Time
+	Executor     string
+	JobCount     int
+	Concurrency  int
+	// Scenario is the name of the scenario the run was started from, empty for ad-hoc runs
+	Scenario string
+	Labels   map[string]string
+	// ExperimentID is set if the run is part of an experiment
+	ExperimentID *uuid.UUID
+}
+
+// Experiment describes an experiment when it is created
+type Experiment struct {
+	ID           uuid.UUID
+	CreationTime time.Time
+	Scenario     string
+	// RunOrder is the order in which the runs are executed, Seed makes a randomized order reproducible
+	RunOrder string
+	Seed     uint64
+	RunCount int
+}
+
+// JobPayload identifies the payload a job was submitted with. Hash is the content hash of the payload
+// before it was rendered, ID and Version are only set if the payload is taken from the payload library.
+type JobPayload struct {
+	ID      *uuid.UUID
+	Version int
+	Hash    string
+}
+
+// PayloadVersion describes a new version of a payload of the payload library
+type PayloadVersion struct {
+	ID      uuid.UUID
+	Name    string
+	Content []byte
+	Hash    string
+}
+
+// SubmissionAttempt describes a single attempt to submit the job with the given index of a run
+type SubmissionAttempt struct {
+	RunID    uuid.UUID
+	JobIndex int
+	Attempt  int
+	// SubmitDelay is the time the attempt waited on the client side, i.e. for a free submission slot
+	// or for the backoff after the previous attempt
+	SubmitDelay time.Duration
+	StartTime   time.Time
+	Duration    time.Duration
+	// StatusCode is 0 unless the CI system answered with an unexpected status code
+	StatusCode int
+	Err        error
+	// JobID is nil if the attempt failed
+	JobID *uuid.UUID
 }
 
 // DBPersister is a concrete implementation of the Persister interface
-// It uses a SQLite database to store the job and the result
+// It uses a SQLite or a PostgreSQL database to store the job and the result
 type DBPersister struct {
 	db      *sql.DB
-	queries *model.Queries
+	queries model.Querier
+	// postgres is set for PostgreSQL databases, whose queries are generated into the postgresmodel package
+	postgres bool
+	// callbackPolicy decides which of several reported times of a job is kept
+	callbackPolicy CallbackPolicy
 }
 
-//go:embed schema.sql
-var ddl string
-var ddlOnce sync.Once
+// InMemory selects an in-memory SQLite database, e.g. for tests. Its content is lost once the persister is closed.
+const InMemory = ":memory:"
 
-func NewDBPersister() DBPersister {
-	dsn := "file:" + file + "?_journal_mode=WAL&_busy_timeout=5000&_foreign_keys=on"
+// NewDBPersister opens the database selected by dsn and applies the pending migrations. A postgres:// or
+// postgresql:// URL selects a PostgreSQL database, anything else is the path of a SQLite database file or InMemory.
+// The persister is safe for concurrent use and meant to be shared, it should be closed once it is no longer used.
+func NewDBPersister(dsn string) (DBPersister, error) {
+	d, err := Open(dsn)
+	if err != nil {
+		return DBPersister{}, err
+	}
+	if err := d.migrate(); err != nil {
+		d.Close()
+		return DBPersister{}, err
+	}
+	return d, nil
+}
+
+// Open opens the database selected by dsn like NewDBPersister, but leaves its schema as it is
+func Open(dsn string) (DBPersister, error) {
+	if IsPostgresURL(dsn) {
+		return openPostgres(dsn)
+	}
+	return openSQLite(dsn)
+}
+
+func openSQLite(path string) (DBPersister, error) {
+	dsn := "file:" + path + "?_journal_mode=WAL&_busy_timeout=5000&_foreign_keys=on"
 
 	db, err := sql.Open("sqlite3", dsn)
 	if err != nil {
-		slog.Error("Error while opening DB", slog.Any("error", err))
-		panic(err)
+		return DBPersister{}, fmt.Errorf("error while opening DB: %w", err)
 	}
 
+	// A single connection serializes the writes, it also keeps an in-memory database alive
 	db.SetMaxOpenConns(1)
 	db.SetMaxIdleConns(1)
 	db.SetConnMaxLifetime(0)
@@ -58,20 +207,29 @@ func NewDBPersister() DBPersister {
 	defer cancel()
 
 	if err := db.PingContext(ctx); err != nil {
-		slog.Error("DB ping failed", slog.Any("error", err))
-		panic(err)
+		db.Close()
+		return DBPersister{}, fmt.Errorf("DB ping failed: %w", err)
 	}
 
-	ddlOnce.Do(func() {
-		if _, err := db.ExecContext(ctx, ddl); err != nil {
-			slog.Error("Error while creating table/index", slog.Any("error", err))
-		} else {
-			slog.Info("DB schema ensured")
-		}
-	})
+	return DBPersister{db: db, queries: model.New(db)}, nil
+}
+
+// Close closes the database of the persister
+func (d DBPersister) Close() error {
+	return d.db.Close()
+}
 
-	queries := model.New(db)
-	return DBPersister{db: db, queries: queries}
+// withTx returns the queries bound to tx
+func (d DBPersister) withTx(tx *sql.Tx) model.Querier {
+	return d.newQueries(tx)
+}
+
+// newQueries returns the queries of the database engine run on db
+func (d DBPersister) newQueries(db model.DBTX) model.Querier {
+	if d.postgres {
+		return postgresQueries{postgresmodel.New(db)}
+	}
+	return model.New(db)
 }
 
 func withRetry(op func(ctx context.Context) error) error {
@@ -115,7 +273,19 @@ func isDatabaseLockedMsg(err error) bool {
 	return strings.Contains(msg, "database is locked") || strings.Contains(msg, "database table is locked")
 }
 
-func (d DBPersister) StoreJobWithMetadata(uuid uuid.UUID, creationTime time.Time, executor string, metaData *string, commitHash *string) {
+func nullableUUID(id *uuid.UUID) uuid.NullUUID {
+	if id == nil {
+		return uuid.NullUUID{Valid: false}
+	}
+	return uuid.NullUUID{UUID: *id, Valid: true}
+}
+
+func (p JobPayload) nullable() (uuid.NullUUID, sql.NullInt64, sql.NullString) {
+	version := sql.NullInt64{Int64: int64(p.Version), Valid: p.ID != nil}
+	return nullableUUID(p.ID), version, sql.NullString{String: p.Hash, Valid: p.Hash != ""}
+}
+
+func (d DBPersister) StoreJobWithMetadata(uuid uuid.UUID, creationTime time.Time, executor string, metaData *string, commitHash *string, runID *uuid.UUID, jobPayload JobPayload) error {
 	var nullableHash sql.NullString
 	if commitHash != nil {
 		nullableHash = sql.NullString{String: *commitHash, Valid: true}
@@ -139,21 +309,20 @@ func (d DBPersister) StoreJobWithMetadata(uuid uuid.UUID, creationTime time.Time
 		Executor:     executor,
 		Metadata:     nullableMeta,
 		CommitHash:   nullableHash,
+		RunID:        nullableUUID(runID),
 	}
+	params.PayloadID, params.PayloadVersion, params.PayloadHash = jobPayload.nullable()
 
-	if err := withRetry(func(ctx context.Context) error {
-		_, err := d.queries.StoreScheduledJobWithMetadata(ctx, params)
+	if err := d.storeJob(uuid, func(ctx context.Context, queries model.Querier) error {
+		_, err := queries.StoreScheduledJobWithMetadata(ctx, params)
 		return err
 	}); err != nil {
-		slog.Error("StoreJob failed",
-			slog.Any("uuid", uuid),
-			slog.Any("executor", executor),
-			slog.Any("error", err),
-		)
+		return fmt.Errorf("failed to store job %s: %w", uuid, err)
 	}
+	return nil
 }
 
-func (d DBPersister) StoreJob(uuid uuid.UUID, creationTime time.Time, executor string, commitHash *string) {
+func (d DBPersister) StoreJob(uuid uuid.UUID, creationTime time.Time, executor string, commitHash *string, runID *uuid.UUID, jobPayload JobPayload) error {
 	var nullableHash sql.NullString
 	if commitHash != nil {
 		nullableHash = sql.NullString{String: *commitHash, Valid: true}
@@ -166,186 +335,439 @@ func (d DBPersister) StoreJob(uuid uuid.UUID, creationTime time.Time, executor s
 		CreationTime: creationTime.UTC(),
 		Executor:     executor,
 		CommitHash:   nullableHash,
+		RunID:        nullableUUID(runID),
 	}
+	params.PayloadID, params.PayloadVersion, params.PayloadHash = jobPayload.nullable()
 
-	if err := withRetry(func(ctx context.Context) error {
-		_, err := d.queries.StoreScheduledJob(ctx, params)
+	if err := d.storeJob(uuid, func(ctx context.Context, queries model.Querier) error {
+		_, err := queries.StoreScheduledJob(ctx, params)
 		return err
 	}); err != nil {
-		slog.Error("StoreJob failed",
-			slog.Any("uuid", uuid),
-			slog.Any("executor", executor),
-			slog.Any("error", err),
-		)
+		return fmt.Errorf("failed to store job %s: %w", uuid, err)
 	}
+	return nil
 }
 
-func (d DBPersister) StoreStartTime(uuid uuid.UUID, startTime time.Time) {
-	d.queries.UpsertJobStartTime(context.Background(), model.UpsertJobStartTimeParams{
-		ID: uuid,
-		StartTime: sql.NullTime{
-			Time:  startTime.UTC(),
-			Valid: true,
-		},
-	})
+func (d DBPersister) StoreStartTime(uuid uuid.UUID, startTime time.Time) error {
+	if err := d.storeCallback(uuid, CallbackStartTime, startTime); err != nil {
+		return fmt.Errorf("failed to store start time of job %s: %w", uuid, err)
+	}
+	return nil
 }
 
-func (d DBPersister) StoreResult(uuid uuid.UUID, endTime time.Time) {
-	d.queries.UpsertJobEndTime(context.Background(), model.UpsertJobEndTimeParams{
-		ID: uuid,
-		EndTime: sql.NullTime{
-			Time:  endTime.UTC(),
-			Valid: true,
-		},
-	})
+func (d DBPersister) StoreResult(uuid uuid.UUID, endTime time.Time) error {
+	if err := d.storeCallback(uuid, CallbackResult, endTime); err != nil {
+		return fmt.Errorf("failed to store result of job %s: %w", uuid, err)
+	}
+	return nil
 }
 
-func (d DBPersister) GetQueueLatenciesInRange(from, to *time.Time, commitHash *string, executor string) ([]int64, error) {
-	ctx := context.Background()
-
-	params := model.GetQueueLatenciesInRangeByCommitAndExecutorParams{
-		From:       sql.NullTime{Valid: false},
-		To:         sql.NullTime{Valid: false},
-		CommitHash: sql.NullString{Valid: false},
-		Executor:   executor,
+func (d DBPersister) StoreJobStatus(uuid uuid.UUID, status string) error {
+	params := model.UpdateJobStatusParams{
+		ID:     uuid,
+		Status: status,
 	}
 
-	if from != nil {
-		params.From = sql.NullTime{Time: from.UTC(), Valid: true}
+	if err := withRetry(func(ctx context.Context) error {
+		return d.queries.UpdateJobStatus(ctx, params)
+	}); err != nil {
+		return fmt.Errorf("failed to store status %s of job %s: %w", status, uuid, err)
 	}
-	if to != nil {
-		params.To = sql.NullTime{Time: to.UTC(), Valid: true}
+	return nil
+}
+
+func (d DBPersister) StoreObservedTimes(uuid uuid.UUID, startTime *time.Time, endTime *time.Time) error {
+	if err := d.inTx(func(ctx context.Context, queries model.Querier) error {
+		return updateJobTimes(ctx, queries, uuid, func(times *jobTimes) {
+			if startTime != nil && !times.start.Valid {
+				times.start = sql.NullTime{Time: startTime.UTC(), Valid: true}
+			}
+			if endTime != nil && !times.end.Valid {
+				times.end = sql.NullTime{Time: endTime.UTC(), Valid: true}
+			}
+		})
+	}); err != nil {
+		return fmt.Errorf("failed to store observed times of job %s: %w", uuid, err)
 	}
-	if commitHash != nil {
-		params.CommitHash = sql.NullString{String: *commitHash, Valid: true}
-	} else {
-		params.CommitHash = sql.NullString{Valid: false}
+	return nil
+}
+
+func (d DBPersister) StoreRun(run Run) error {
+	params := model.CreateRunParams{
+		ID:           run.ID,
+		CreationTime: run.CreationTime.UTC(),
+		Executor:     run.Executor,
+		JobCount:     int64(run.JobCount),
+		Concurrency:  int64(run.Concurrency),
+		Scenario:     sql.NullString{String: run.Scenario, Valid: run.Scenario != ""},
+		Labels:       sql.NullString{},
+		ExperimentID: nullableUUID(run.ExperimentID),
+	}
+	if len(run.Labels) > 0 {
+		labels, err := json.Marshal(run.Labels)
+		if err != nil {
+			return fmt.Errorf("failed to marshal labels of run %s: %w", run.ID, err)
+		}
+		params.Labels = sql.NullString{String: string(labels), Valid: true}
 	}
 
-	return d.queries.GetQueueLatenciesInRangeByCommitAndExecutor(ctx, params)
+	if err := withRetry(func(ctx context.Context) error {
+		return d.queries.CreateRun(ctx, params)
+	}); err != nil {
+		return fmt.Errorf("failed to store run %s: %w", run.ID, err)
+	}
+	return nil
 }
 
-func (d DBPersister) GetBuildTimesInRange(from, to *time.Time, commitHash *string, executor string) ([]int64, error) {
-	ctx := context.Background()
+func (d DBPersister) UpdateRunStatus(runID uuid.UUID, status string, runErr error) error {
+	params := model.UpdateRunStatusParams{
+		ID:         runID,
+		Status:     status,
+		FinishTime: sql.NullTime{Time: time.Now().UTC(), Valid: true},
+	}
+	if runErr != nil {
+		params.Error = sql.NullString{String: runErr.Error(), Valid: true}
+	}
 
-	params := model.GetBuildTimesInRangeByCommitAndExecutorParams{
-		From:       sql.NullTime{Valid: false},
-		To:         sql.NullTime{Valid: false},
-		CommitHash: sql.NullString{Valid: false},
-		Executor:   executor,
+	if err := withRetry(func(ctx context.Context) error {
+		return d.queries.UpdateRunStatus(ctx, params)
+	}); err != nil {
+		return fmt.Errorf("failed to store status %s of run %s: %w", status, runID, err)
 	}
+	return nil
+}
 
-	if from != nil {
-		params.From = sql.NullTime{Time: from.UTC(), Valid: true}
+func (d DBPersister) StoreSubmissionAttempt(attempt SubmissionAttempt) error {
+	params := model.StoreSubmissionAttemptParams{
+		RunID:         attempt.RunID,
+		JobIndex:      int64(attempt.JobIndex),
+		Attempt:       int64(attempt.Attempt),
+		SubmitDelayMs: attempt.SubmitDelay.Milliseconds(),
+		StartTime:     attempt.StartTime.UTC(),
+		DurationMs:    attempt.Duration.Milliseconds(),
+		JobID:         nullableUUID(attempt.JobID),
 	}
-	if to != nil {
-		params.To = sql.NullTime{Time: to.UTC(), Valid: true}
+	if attempt.StatusCode != 0 {
+		params.StatusCode = sql.NullInt64{Int64: int64(attempt.StatusCode), Valid: true}
 	}
-	if commitHash != nil {
-		params.CommitHash = sql.NullString{String: *commitHash, Valid: true}
-	} else {
-		params.CommitHash = sql.NullString{Valid: false}
+	if attempt.Err != nil {
+		params.Error = sql.NullString{String: attempt.Err.Error(), Valid: true}
 	}
 
-	return d.queries.GetBuildTimesInRangeByCommitAndExecutor(ctx, params)
+	if err := withRetry(func(ctx context.Context) error {
+		return d.queries.StoreSubmissionAttempt(ctx, params)
+	}); err != nil {
+		return fmt.Errorf("failed to store attempt %d of job %d of run %s: %w", attempt.Attempt, attempt.JobIndex, attempt.RunID, err)
+	}
+	return nil
 }
 
-func (d DBPersister) GetQueueLatencySummaryInRange(from, to *time.Time, commitHash *string, executor string) ([]int64, error) {
-	ctx := context.Background()
+func (d DBPersister) StoreExperiment(experiment Experiment) error {
+	params := model.CreateExperimentParams{
+		ID:           experiment.ID,
+		CreationTime: experiment.CreationTime.UTC(),
+		Scenario:     experiment.Scenario,
+		RunOrder:     experiment.RunOrder,
+		// SQLite integers are signed, the seed is stored with the same bits
+		Seed:     int64(experiment.Seed),
+		RunCount: int64(experiment.RunCount),
+	}
 
-	params := model.GetQueueLatencySummaryInRangeByCommitAndExecutorParams{
-		From:       sql.NullTime{Valid: false},
-		To:         sql.NullTime{Valid: false},
-		CommitHash: sql.NullString{Valid: false},
-		Executor:   executor,
+	if err := withRetry(func(ctx context.Context) error {
+		return d.queries.CreateExperiment(ctx, params)
+	}); err != nil {
+		return fmt.Errorf("failed to store experiment %s: %w", experiment.ID, err)
 	}
+	return nil
+}
 
-	if from != nil {
-		params.From = sql.NullTime{Time: from.UTC(), Valid: true}
+func (d DBPersister) UpdateExperimentStatus(experimentID uuid.UUID, status string, experimentErr error) error {
+	params := model.UpdateExperimentStatusParams{
+		ID:         experimentID,
+		Status:     status,
+		FinishTime: sql.NullTime{Time: time.Now().UTC(), Valid: true},
 	}
-	if to != nil {
-		params.To = sql.NullTime{Time: to.UTC(), Valid: true}
+	if experimentErr != nil {
+		params.Error = sql.NullString{String: experimentErr.Error(), Valid: true}
 	}
-	if commitHash != nil {
-		params.CommitHash = sql.NullString{String: *commitHash, Valid: true}
-	} else {
-		params.CommitHash = sql.NullString{Valid: false}
+
+	if err := withRetry(func(ctx context.Context) error {
+		return d.queries.UpdateExperimentStatus(ctx, params)
+	}); err != nil {
+		return fmt.Errorf("failed to store status %s of experiment %s: %w", status, experimentID, err)
 	}
+	return nil
+}
 
-	return d.queries.GetQueueLatencySummaryInRangeByCommitAndExecutor(ctx, params)
+func (d DBPersister) GetExperiment(experimentID uuid.UUID) (model.Experiment, error) {
+	return d.queries.GetExperiment(context.Background(), experimentID)
 }
 
-func (d DBPersister) GetBuildTimeSummaryInRange(from, to *time.Time, commitHash *string, executor string) ([]int64, error) {
-	ctx := context.Background()
+func (d DBPersister) GetRunsByExperiment(experimentID uuid.UUID) ([]model.BenchmarkRun, error) {
+	return d.queries.GetRunsByExperiment(context.Background(), uuid.NullUUID{UUID: experimentID, Valid: true})
+}
 
-	params := model.GetBuildTimeSummaryInRangeByCommitAndExecutorParams{
-		From:       sql.NullTime{Valid: false},
-		To:         sql.NullTime{Valid: false},
-		CommitHash: sql.NullString{Valid: false},
-		Executor:   executor,
-	}
+func (d DBPersister) GetJobTimesByExperiment(experimentID uuid.UUID) ([]model.GetJobTimesByExperimentRow, error) {
+	return d.queries.GetJobTimesByExperiment(context.Background(), uuid.NullUUID{UUID: experimentID, Valid: true})
+}
 
-	if from != nil {
-		params.From = sql.NullTime{Time: from.UTC(), Valid: true}
-	}
-	if to != nil {
-		params.To = sql.NullTime{Time: to.UTC(), Valid: true}
-	}
-	if commitHash != nil {
-		params.CommitHash = sql.NullString{String: *commitHash, Valid: true}
-	} else {
-		params.CommitHash = sql.NullString{Valid: false}
-	}
+func (d DBPersister) GetRun(runID uuid.UUID) (model.BenchmarkRun, error) {
+	return d.queries.GetRun(context.Background(), runID)
+}
+
+// RunLabels decodes the labels stored with a run
+func RunLabels(run model.BenchmarkRun) (map[string]string, error) {
+	return decodeLabels(run.Labels)
+}
+
+func (d DBPersister) GetRunJobStats(runID uuid.UUID) (model.GetRunJobStatsRow, error) {
+	return d.queries.GetRunJobStats(context.Background(), uuid.NullUUID{UUID: runID, Valid: true})
+}
+
+func (d DBPersister) GetRunSubmissionStats(runID uuid.UUID) (model.GetRunSubmissionStatsRow, error) {
+	return d.queries.GetRunSubmissionStats(context.Background(), runID)
+}
+
+func (d DBPersister) GetPendingJobIDsByRun(runID uuid.UUID) ([]uuid.UUID, error) {
+	return d.queries.GetPendingJobIDsByRun(context.Background(), uuid.NullUUID{UUID: runID, Valid: true})
+}
+
+// CancelPendingJobsByRun marks all jobs of a run which did not finish yet as cancelled,
+// which excludes them from all metrics
+func (d DBPersister) CancelPendingJobsByRun(runID uuid.UUID) (int64, error) {
+	var cancelled int64
+	err := withRetry(func(ctx context.Context) error {
+		var err error
+		cancelled, err = d.queries.CancelPendingJobsByRun(ctx, uuid.NullUUID{UUID: runID, Valid: true})
+		return err
+	})
+	return cancelled, err
+}
+
+// MetricsFilter selects the jobs metrics are computed for, nil and empty fields are not filtered
+type MetricsFilter struct {
+	From        *time.Time
+	To          *time.Time
+	CommitHash  *string
+	Executor    string
+	PayloadHash *string
+	// Labels selects the jobs carrying all of these labels
+	Labels map[string]string
+}
+
+// Dimensions grouped metrics are grouped by
+const (
+	GroupByExecutor   = "executor"
+	GroupByCommitHash = "commit_hash"
+	GroupByRun        = "run"
+	GroupByHour       = "hour"
+	GroupByDay        = "day"
+	GroupByLabel      = "label"
+)
 
-	return d.queries.GetBuildTimeSummaryInRangeByCommitAndExecutor(ctx, params)
+// Grouping selects the dimension grouped metrics are grouped by, Label is the key grouped by with
+// GroupByLabel. Hours and days are those of the start of a job in UTC.
+type Grouping struct {
+	By    string
+	Label string
 }
 
-func (d DBPersister) GetTotalLatenciesInRange(from, to *time.Time, commitHash *string, executor string) ([]int64, error) {
-	ctx := context.Background()
+// metricsParams are the parameters shared by all metric queries, the parameters of the other
+// queries are converted from them
+type metricsParams = model.GetQueueLatenciesInRangeByCommitAndExecutorParams
 
-	params := model.GetTotalLatenciesInRangeByCommitAndExecutorParams{
+type groupParams = model.GetQueueLatencySummaryByGroupParams
+
+func (f MetricsFilter) params() metricsParams {
+	params := metricsParams{
 		From:       sql.NullTime{Valid: false},
 		To:         sql.NullTime{Valid: false},
 		CommitHash: sql.NullString{Valid: false},
-		Executor:   executor,
+		Executor:   f.Executor,
 	}
-
-	if from != nil {
-		params.From = sql.NullTime{Time: from.UTC(), Valid: true}
+	if f.From != nil {
+		params.From = sql.NullTime{Time: f.From.UTC(), Valid: true}
 	}
-	if to != nil {
-		params.To = sql.NullTime{Time: to.UTC(), Valid: true}
+	if f.To != nil {
+		params.To = sql.NullTime{Time: f.To.UTC(), Valid: true}
 	}
-	if commitHash != nil {
-		params.CommitHash = sql.NullString{String: *commitHash, Valid: true}
-	} else {
-		params.CommitHash = sql.NullString{Valid: false}
+	if f.CommitHash != nil {
+		params.CommitHash = sql.NullString{String: *f.CommitHash, Valid: true}
+	}
+	if f.PayloadHash != nil {
+		params.PayloadHash = sql.NullString{String: *f.PayloadHash, Valid: true}
 	}
+	if len(f.Labels) > 0 {
+		// Encoding a map of strings cannot fail
+		labels, _ := json.Marshal(f.Labels)
+		params.Labels = string(labels)
+	}
+	return params
+}
 
-	return d.queries.GetTotalLatenciesInRangeByCommitAndExecutor(ctx, params)
+func (f MetricsFilter) groupParams(grouping Grouping) groupParams {
+	params := f.params()
+	return groupParams{
+		GroupBy:     grouping.By,
+		GroupLabel:  sql.NullString{String: grouping.Label, Valid: grouping.By == GroupByLabel},
+		From:        params.From,
+		To:          params.To,
+		CommitHash:  params.CommitHash,
+		Executor:    sql.NullString{String: f.Executor, Valid: f.Executor != ""},
+		PayloadHash: params.PayloadHash,
+		Labels:      params.Labels,
+	}
 }
 
-func (d DBPersister) GetTotalLatenciesSummaryInRange(from, to *time.Time, commitHash *string, executor string) ([]int64, error) {
-	ctx := context.Background()
+func (d DBPersister) GetQueueLatenciesInRange(filter MetricsFilter) ([]int64, error) {
+	return d.queries.GetQueueLatenciesInRangeByCommitAndExecutor(context.Background(), filter.params())
+}
 
-	params := model.GetTotalLatenciesSummaryInRangeByCommitAndExecutorParams{
-		From:       sql.NullTime{Valid: false},
-		To:         sql.NullTime{Valid: false},
-		CommitHash: sql.NullString{Valid: false},
-		Executor:   executor,
+func (d DBPersister) GetBuildTimesInRange(filter MetricsFilter) ([]int64, error) {
+	params := model.GetBuildTimesInRangeByCommitAndExecutorParams(filter.params())
+	return d.queries.GetBuildTimesInRangeByCommitAndExecutor(context.Background(), params)
+}
+
+func (d DBPersister) GetTotalLatenciesInRange(filter MetricsFilter) ([]int64, error) {
+	params := model.GetTotalLatenciesInRangeByCommitAndExecutorParams(filter.params())
+	return d.queries.GetTotalLatenciesInRangeByCommitAndExecutor(context.Background(), params)
+}
+
+func (d DBPersister) GetQueueLatencySummaryInRange(filter MetricsFilter) ([]int64, error) {
+	params := model.GetQueueLatencySummaryInRangeByCommitAndExecutorParams(filter.params())
+	return d.queries.GetQueueLatencySummaryInRangeByCommitAndExecutor(context.Background(), params)
+}
+
+func (d DBPersister) GetBuildTimeSummaryInRange(filter MetricsFilter) ([]int64, error) {
+	params := model.GetBuildTimeSummaryInRangeByCommitAndExecutorParams(filter.params())
+	return d.queries.GetBuildTimeSummaryInRangeByCommitAndExecutor(context.Background(), params)
+}
+
+func (d DBPersister) GetTotalLatenciesSummaryInRange(filter MetricsFilter) ([]int64, error) {
+	params := model.GetTotalLatenciesSummaryInRangeByCommitAndExecutorParams(filter.params())
+	return d.queries.GetTotalLatenciesSummaryInRangeByCommitAndExecutor(context.Background(), params)
+}
+
+// GetQueueLatencySummaryByGroup returns the queue latencies of the filtered jobs per group in a single
+// query, jobs without a value for the grouping, e.g. without commit hash, are left out
+func (d DBPersister) GetQueueLatencySummaryByGroup(filter MetricsFilter, grouping Grouping) (map[string][]int64, error) {
+	rows, err := d.queries.GetQueueLatencySummaryByGroup(context.Background(), filter.groupParams(grouping))
+	if err != nil {
+		return nil, err
+	}
+	groups := make(map[string][]int64)
+	for _, row := range rows {
+		groups[row.GroupKey] = append(groups[row.GroupKey], row.Latency)
 	}
+	return groups, nil
+}
 
-	if from != nil {
-		params.From = sql.NullTime{Time: from.UTC(), Valid: true}
+// GetBuildTimeSummaryByGroup returns the build times of the filtered jobs per group, see GetQueueLatencySummaryByGroup
+func (d DBPersister) GetBuildTimeSummaryByGroup(filter MetricsFilter, grouping Grouping) (map[string][]int64, error) {
+	params := model.GetBuildTimeSummaryByGroupParams(filter.groupParams(grouping))
+	rows, err := d.queries.GetBuildTimeSummaryByGroup(context.Background(), params)
+	if err != nil {
+		return nil, err
 	}
-	if to != nil {
-		params.To = sql.NullTime{Time: to.UTC(), Valid: true}
+	groups := make(map[string][]int64)
+	for _, row := range rows {
+		groups[row.GroupKey] = append(groups[row.GroupKey], row.BuildTime)
 	}
-	if commitHash != nil {
-		params.CommitHash = sql.NullString{String: *commitHash, Valid: true}
-	} else {
-		params.CommitHash = sql.NullString{Valid: false}
+	return groups, nil
+}
+
+// GetTotalLatenciesSummaryByGroup returns the total latencies of the filtered jobs per group, see GetQueueLatencySummaryByGroup
+func (d DBPersister) GetTotalLatenciesSummaryByGroup(filter MetricsFilter, grouping Grouping) (map[string][]int64, error) {
+	params := model.GetTotalLatenciesSummaryByGroupParams(filter.groupParams(grouping))
+	rows, err := d.queries.GetTotalLatenciesSummaryByGroup(context.Background(), params)
+	if err != nil {
+		return nil, err
 	}
+	groups := make(map[string][]int64)
+	for _, row := range rows {
+		groups[row.GroupKey] = append(groups[row.GroupKey], row.TotalLatency)
+	}
+	return groups, nil
+}
+
+// StorePayloadVersion stores a new version of a payload of the payload library, the first version if
+// the payload does not exist yet
+func (d DBPersister) StorePayloadVersion(version PayloadVersion) (model.Payload, error) {
+	var stored model.Payload
+	err := withRetry(func(ctx context.Context) error {
+		tx, err := d.db.BeginTx(ctx, nil)
+		if err != nil {
+			return err
+		}
+		defer tx.Rollback()
+		queries := d.withTx(tx)
+
+		next := int64(1)
+		latest, err := queries.GetLatestPayloadVersion(ctx, version.ID)
+		if err == nil {
+			next = latest.Version + 1
+		} else if !errors.Is(err, sql.ErrNoRows) {
+			return err
+		}
 
-	return d.queries.GetTotalLatenciesSummaryInRangeByCommitAndExecutor(ctx, params)
+		params := model.CreatePayloadVersionParams{
+			ID:           version.ID,
+			Version:      next,
+			Name:         version.Name,
+			Content:      string(version.Content),
+			ContentHash:  version.Hash,
+			CreationTime: time.Now().UTC(),
+		}
+		if err := queries.CreatePayloadVersion(ctx, params); err != nil {
+			return err
+		}
+		stored = model.Payload{
+			ID:           params.ID,
+			Version:      params.Version,
+			Name:         params.Name,
+			Content:      params.Content,
+			ContentHash:  params.ContentHash,
+			CreationTime: params.CreationTime,
+		}
+		return tx.Commit()
+	})
+	return stored, err
+}
+
+func (d DBPersister) GetLatestPayloadVersion(id uuid.UUID) (model.Payload, error) {
+	return d.queries.GetLatestPayloadVersion(context.Background(), id)
+}
+
+func (d DBPersister) GetPayloadVersion(id uuid.UUID, version int) (model.Payload, error) {
+	return d.queries.GetPayloadVersion(context.Background(), model.GetPayloadVersionParams{ID: id, Version: int64(version)})
+}
+
+func (d DBPersister) ListPayloads() ([]model.Payload, error) {
+	return d.queries.ListPayloads(context.Background())
+}
+
+func (d DBPersister) ListPayloadVersions(id uuid.UUID) ([]model.Payload, error) {
+	return d.queries.ListPayloadVersions(context.Background(), id)
+}
+
+func (d DBPersister) DeletePayload(id uuid.UUID) (int64, error) {
+	var deleted int64
+	err := withRetry(func(ctx context.Context) error {
+		var err error
+		deleted, err = d.queries.DeletePayload(ctx, id)
+		return err
+	})
+	return deleted, err
+}
+
+// PayloadContent returns the stored content of a payload version, SQLite returns it as string or []byte
+func PayloadContent(p model.Payload) []byte {
+	switch content := p.Content.(type) {
+	case string:
+		return []byte(content)
+	case []byte:
+		return content
+	default:
+		return nil
+	}
 }
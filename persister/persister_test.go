@@ -0,0 +1,581 @@
+package persister
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// TestMetrics runs against an in-memory SQLite database and the PostgreSQL database at TEST_DATABASE_URL,
+// both have to compute the same metrics
+func TestMetrics(t *testing.T) {
+	t.Run("sqlite", func(t *testing.T) {
+		testMetrics(t, InMemory)
+	})
+	t.Run("postgres", func(t *testing.T) {
+		url := os.Getenv("TEST_DATABASE_URL")
+		if url == "" {
+			t.Skip("TEST_DATABASE_URL is not set")
+		}
+		testMetrics(t, url)
+	})
+}
+
+func testMetrics(t *testing.T, dsn string) {
+	p, err := NewDBPersister(dsn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer p.Close()
+
+	// A fresh executor name keeps the jobs of previous test runs out of the metrics
+	executor := "test-" + uuid.NewString()
+	created := time.Date(2025, 2, 4, 11, 0, 0, 200_000_000, time.UTC)
+	labels := []string{`{"env":"a"}`, `{"env":"b"}`}
+	for i, label := range labels {
+		id := uuid.New()
+		if err := p.StoreJobWithMetadata(id, created, executor, &label, nil, nil, JobPayload{Hash: "hash"}); err != nil {
+			t.Fatal(err)
+		}
+		// start times with fractions of a second are truncated like by SQLite
+		if err := p.StoreStartTime(id, created.Add(time.Duration(10*(i+1))*time.Second+500*time.Millisecond)); err != nil {
+			t.Fatal(err)
+		}
+		if err := p.StoreResult(id, created.Add(70*time.Second)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	queueLatencies, err := p.GetQueueLatenciesInRange(MetricsFilter{Executor: executor})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := []int64{20, 10}; !reflect.DeepEqual(queueLatencies, expected) {
+		t.Fatalf("expected queue latencies %v, got %v", expected, queueLatencies)
+	}
+
+	buildTimes, err := p.GetBuildTimeSummaryInRange(MetricsFilter{Executor: executor, Labels: map[string]string{"env": "a"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := []int64{60}; !reflect.DeepEqual(buildTimes, expected) {
+		t.Fatalf("expected build times %v, got %v", expected, buildTimes)
+	}
+
+	groups, err := p.GetTotalLatenciesSummaryByGroup(MetricsFilter{Executor: executor}, Grouping{By: GroupByLabel, Label: "env"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := map[string][]int64{"a": {70}, "b": {70}}; !reflect.DeepEqual(groups, expected) {
+		t.Fatalf("expected total latencies %v, got %v", expected, groups)
+	}
+
+	hours, err := p.GetQueueLatencySummaryByGroup(MetricsFilter{Executor: executor}, Grouping{By: GroupByHour})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := map[string][]int64{"2025-02-04T11:00:00Z": {10, 20}}; !reflect.DeepEqual(hours, expected) {
+		t.Fatalf("expected queue latencies %v, got %v", expected, hours)
+	}
+}
+
+func TestCallbacksOfUnknownJobs(t *testing.T) {
+	p, err := NewDBPersister(InMemory)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer p.Close()
+
+	created := time.Date(2025, 2, 4, 11, 0, 0, 0, time.UTC)
+	unknown, early := uuid.New(), uuid.New()
+	if err := p.StoreResult(unknown, created.Add(time.Minute)); !errors.Is(err, ErrUnknownJob) {
+		t.Fatalf("expected ErrUnknownJob, got %v", err)
+	}
+	if err := p.StoreStartTime(early, created.Add(5*time.Second)); !errors.Is(err, ErrUnknownJob) {
+		t.Fatalf("expected ErrUnknownJob, got %v", err)
+	}
+
+	orphans, err := p.ListOrphanCallbacks(10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(orphans) != 2 {
+		t.Fatalf("expected 2 orphan callbacks, got %d", len(orphans))
+	}
+
+	// The start time reported before the job was stored is applied once it is
+	if err := p.StoreJob(early, created, "test", nil, nil, JobPayload{}); err != nil {
+		t.Fatal(err)
+	}
+	if err := p.StoreResult(early, created.Add(65*time.Second)); err != nil {
+		t.Fatal(err)
+	}
+	buildTimes, err := p.GetBuildTimesInRange(MetricsFilter{Executor: "test"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := []int64{60}; !reflect.DeepEqual(buildTimes, expected) {
+		t.Fatalf("expected build times %v, got %v", expected, buildTimes)
+	}
+
+	orphans, err = p.ListOrphanCallbacks(10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(orphans) != 1 || orphans[0].JobID != unknown || orphans[0].Callback != CallbackResult {
+		t.Fatalf("expected only the result of the unknown job to be left, got %v", orphans)
+	}
+}
+
+func TestRepeatedAndOutOfOrderCallbacks(t *testing.T) {
+	p, err := NewDBPersister(InMemory)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer p.Close()
+	p = p.WithCallbackPolicy(CallbackPolicy{StartTime: FirstWriteWins, Result: LastWriteWins})
+
+	created := time.Date(2025, 2, 4, 11, 0, 0, 0, time.UTC)
+	runID := uuid.New()
+	retried, invalid := uuid.New(), uuid.New()
+	for _, id := range []uuid.UUID{retried, invalid} {
+		if err := p.StoreJob(id, created, "test", nil, &runID, JobPayload{}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// The result arrives first, the start time callback is retried
+	callbacks := []func() error{
+		func() error { return p.StoreResult(retried, created.Add(60*time.Second)) },
+		func() error { return p.StoreStartTime(retried, created.Add(10*time.Second)) },
+		func() error { return p.StoreStartTime(retried, created.Add(20*time.Second)) },
+		func() error { return p.StoreResult(retried, created.Add(70*time.Second)) },
+		func() error { return p.StoreStartTime(invalid, created.Add(30*time.Second)) },
+		func() error { return p.StoreResult(invalid, created.Add(20*time.Second)) },
+	}
+	for _, callback := range callbacks {
+		if err := callback(); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// The first start time and the last result are kept, the job which finished before it started is left out
+	buildTimes, err := p.GetBuildTimesInRange(MetricsFilter{Executor: "test"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := []int64{60}; !reflect.DeepEqual(buildTimes, expected) {
+		t.Fatalf("expected build times %v, got %v", expected, buildTimes)
+	}
+
+	stats, err := p.GetRunJobStats(runID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stats.DuplicateCallbacks != 2 || stats.InvalidJobs != 1 {
+		t.Fatalf("expected 2 duplicate callbacks and 1 invalid job, got %d and %d", stats.DuplicateCallbacks, stats.InvalidJobs)
+	}
+
+	// Observed times never replace reported ones
+	if err := p.StoreObservedTimes(retried, &created, &created); err != nil {
+		t.Fatal(err)
+	}
+	buildTimes, err = p.GetBuildTimesInRange(MetricsFilter{Executor: "test"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := []int64{60}; !reflect.DeepEqual(buildTimes, expected) {
+		t.Fatalf("expected build times %v after observing the job, got %v", expected, buildTimes)
+	}
+}
+
+// memoryArchive keeps the archived records, the records written before a flush are durable
+type memoryArchive struct {
+	records, flushed []ArchiveRecord
+}
+
+func (a *memoryArchive) Write(record ArchiveRecord) error {
+	a.records = append(a.records, record)
+	return nil
+}
+
+func (a *memoryArchive) Flush() error {
+	a.flushed = a.records
+	return nil
+}
+
+func TestPrune(t *testing.T) {
+	p, err := NewDBPersister(InMemory)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer p.Close()
+
+	created := time.Date(2025, 2, 4, 11, 0, 0, 0, time.UTC)
+	runID := uuid.New()
+	if err := p.StoreRun(Run{ID: runID, CreationTime: created, Executor: "old", JobCount: 1, Concurrency: 1}); err != nil {
+		t.Fatal(err)
+	}
+	if err := p.UpdateRunStatus(runID, "submitted", nil); err != nil {
+		t.Fatal(err)
+	}
+	old, other, recent := uuid.New(), uuid.New(), uuid.New()
+	label := `{"env":"a"}`
+	if err := p.StoreJobWithMetadata(old, created, "old", &label, nil, &runID, JobPayload{}); err != nil {
+		t.Fatal(err)
+	}
+	if err := p.StoreResult(old, created.Add(time.Minute)); err != nil {
+		t.Fatal(err)
+	}
+	if err := p.StoreJob(other, created, "other", nil, nil, JobPayload{}); err != nil {
+		t.Fatal(err)
+	}
+	// Jobs created within the same second are told apart
+	if err := p.StoreJob(recent, created.Add(500*time.Millisecond), "old", nil, nil, JobPayload{}); err != nil {
+		t.Fatal(err)
+	}
+
+	before, executor := created.Add(100*time.Millisecond), "old"
+	archive := &memoryArchive{}
+	result, err := p.Prune(context.Background(), PruneFilter{Before: &before, Executor: &executor}, archive)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result != (PruneResult{Jobs: 1, Runs: 1}) {
+		t.Fatalf("expected 1 job and 1 run to be pruned, got %+v", result)
+	}
+	if len(archive.flushed) != 2 || archive.flushed[0].Job == nil || archive.flushed[1].Run == nil {
+		t.Fatalf("expected the job and the run to be archived, got %+v", archive.flushed)
+	}
+	job := archive.flushed[0].Job
+	if job.ID != old || job.Labels["env"] != "a" || job.EndTime == nil || !job.EndTime.Equal(created.Add(time.Minute)) {
+		t.Fatalf("unexpected archived job %+v", job)
+	}
+
+	// The jobs of other executors and later jobs are kept
+	if cutoff, err := p.JobCreationTimeAt(1); err != nil || cutoff == nil || !cutoff.Equal(created) {
+		t.Fatalf("expected 2 jobs to be left, the older created at %v, got %v (%v)", created, cutoff, err)
+	}
+	if cutoff, err := p.JobCreationTimeAt(2); err != nil || cutoff != nil {
+		t.Fatalf("expected only 2 jobs to be left, got %v (%v)", cutoff, err)
+	}
+}
+
+func TestBackup(t *testing.T) {
+	p, err := NewDBPersister(InMemory)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer p.Close()
+
+	created := time.Date(2025, 2, 4, 11, 0, 0, 0, time.UTC)
+	if err := p.StoreJob(uuid.New(), created, "test", nil, nil, JobPayload{}); err != nil {
+		t.Fatal(err)
+	}
+
+	path := filepath.Join(t.TempDir(), "backup.db")
+	if err := p.Backup(context.Background(), path); err != nil {
+		t.Fatal(err)
+	}
+	restored, err := NewDBPersister(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer restored.Close()
+	if cutoff, err := restored.JobCreationTimeAt(0); err != nil || cutoff == nil || !cutoff.Equal(created) {
+		t.Fatalf("expected the job created at %v in the backup, got %v (%v)", created, cutoff, err)
+	}
+}
+
+func TestExportAndImport(t *testing.T) {
+	source, err := NewDBPersister(InMemory)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer source.Close()
+
+	created := time.Date(2025, 2, 4, 11, 0, 0, 0, time.UTC)
+	runID, jobID := uuid.New(), uuid.New()
+	if err := source.StoreRun(Run{ID: runID, CreationTime: created, Executor: "test", JobCount: 1, Concurrency: 1}); err != nil {
+		t.Fatal(err)
+	}
+	label := `{"env":"a"}`
+	if err := source.StoreJobWithMetadata(jobID, created, "test", &label, nil, &runID, JobPayload{}); err != nil {
+		t.Fatal(err)
+	}
+	if err := source.StoreStartTime(jobID, created.Add(10*time.Second)); err != nil {
+		t.Fatal(err)
+	}
+	if err := source.StoreResult(jobID, created.Add(70*time.Second)); err != nil {
+		t.Fatal(err)
+	}
+
+	var export bytes.Buffer
+	archive := &memoryArchive{}
+	if err := source.Export(context.Background(), archive); err != nil {
+		t.Fatal(err)
+	}
+	encoder := json.NewEncoder(&export)
+	for _, record := range archive.flushed {
+		if err := encoder.Encode(record); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	target, err := NewDBPersister(InMemory)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer target.Close()
+
+	options := ImportOptions{Origin: "site-a", OnConflict: ConflictSkip}
+	result, err := target.Import(context.Background(), bytes.NewReader(export.Bytes()), options)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result != (ImportResult{Jobs: 1, Runs: 1}) {
+		t.Fatalf("expected 1 job and 1 run to be imported, got %+v", result)
+	}
+	buildTimes, err := target.GetBuildTimesInRange(MetricsFilter{Executor: "test", Labels: map[string]string{"env": "a", OriginLabel: "site-a"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := []int64{60}; !reflect.DeepEqual(buildTimes, expected) {
+		t.Fatalf("expected build times %v, got %v", expected, buildTimes)
+	}
+
+	// Importing again skips the stored job and run
+	result, err = target.Import(context.Background(), bytes.NewReader(export.Bytes()), options)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result != (ImportResult{SkippedJobs: 1, SkippedRuns: 1}) {
+		t.Fatalf("expected the job and the run to be skipped, got %+v", result)
+	}
+
+	if _, err := target.Import(context.Background(), strings.NewReader(`{"job":{}}`), options); !errors.Is(err, ErrInvalidImport) {
+		t.Fatalf("expected ErrInvalidImport, got %v", err)
+	}
+}
+
+func TestPayloadVersions(t *testing.T) {
+	p, err := NewDBPersister(InMemory)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer p.Close()
+
+	id := uuid.New()
+	contents := []string{`{"name":"v1"}`, `{"name":"v2"}`, `{"name":"v3"}`}
+	for i, content := range contents {
+		stored, err := p.StorePayloadVersion(PayloadVersion{ID: id, Name: "gradle", Content: []byte(content), Hash: content})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if stored.Version != int64(i+1) {
+			t.Fatalf("expected version %d, got %d", i+1, stored.Version)
+		}
+	}
+	// Other payloads are versioned independently
+	other, err := p.StorePayloadVersion(PayloadVersion{ID: uuid.New(), Name: "maven", Content: []byte("{}"), Hash: "{}"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if other.Version != 1 {
+		t.Fatalf("expected version 1 of another payload, got %d", other.Version)
+	}
+
+	latest, err := p.GetLatestPayloadVersion(id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if latest.Version != 3 || string(PayloadContent(latest)) != contents[2] {
+		t.Fatalf("expected version 3 with %s, got version %d with %s", contents[2], latest.Version, PayloadContent(latest))
+	}
+	first, err := p.GetPayloadVersion(id, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(PayloadContent(first)) != contents[0] || first.ContentHash != contents[0] {
+		t.Fatalf("expected version 1 with %s, got %s", contents[0], PayloadContent(first))
+	}
+	if _, err := p.GetPayloadVersion(id, 4); !errors.Is(err, sql.ErrNoRows) {
+		t.Fatalf("expected sql.ErrNoRows for a missing version, got %v", err)
+	}
+
+	versions, err := p.ListPayloadVersions(id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(versions) != 3 || versions[0].Version != 1 || versions[2].Version != 3 {
+		t.Fatalf("expected versions 1 to 3, got %+v", versions)
+	}
+	payloads, err := p.ListPayloads()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(payloads) != 2 || payloads[0].ID != id || payloads[0].Version != 3 {
+		t.Fatalf("expected the latest versions of 2 payloads, got %+v", payloads)
+	}
+
+	// Deleted payloads can no longer be referenced
+	if deleted, err := p.DeletePayload(id); err != nil || deleted != 3 {
+		t.Fatalf("expected 3 deleted versions, got %d, %v", deleted, err)
+	}
+	if _, err := p.GetLatestPayloadVersion(id); !errors.Is(err, sql.ErrNoRows) {
+		t.Fatalf("expected sql.ErrNoRows for a deleted payload, got %v", err)
+	}
+	if deleted, err := p.DeletePayload(id); err != nil || deleted != 0 {
+		t.Fatalf("expected nothing to delete, got %d, %v", deleted, err)
+	}
+}
+
+// storeGroupedJobs stores four jobs of two executors, commits, runs and days with different labels,
+// their queue latencies are 10, 20, 30 and 40 seconds and their build times 60 seconds
+func storeGroupedJobs(t *testing.T, p DBPersister) (uuid.UUID, uuid.UUID) {
+	t.Helper()
+	runs := []uuid.UUID{uuid.New(), uuid.New()}
+	days := []time.Time{time.Date(2025, 2, 4, 11, 0, 0, 0, time.UTC), time.Date(2025, 2, 5, 9, 0, 0, 0, time.UTC)}
+	labels := []*string{
+		ptr(`{"env":"a","a.b":"x","a\"b":"q"}`),
+		ptr(`{"env":"b","a.b":"x"}`),
+		ptr(`{"env":"a"}`),
+		nil,
+	}
+	for i, runID := range runs {
+		if err := p.StoreRun(Run{ID: runID, CreationTime: days[i], Executor: "test", JobCount: 2, Concurrency: 1}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for i, label := range labels {
+		id, created, commit := uuid.New(), days[i/2], fmt.Sprintf("c%d", i/2+1)
+		if err := p.StoreJobWithMetadata(id, created, fmt.Sprintf("e%d", i/2+1), label, &commit, &runs[i/2], JobPayload{}); err != nil {
+			t.Fatal(err)
+		}
+		if err := p.StoreStartTime(id, created.Add(time.Duration(10*(i+1))*time.Second)); err != nil {
+			t.Fatal(err)
+		}
+		if err := p.StoreResult(id, created.Add(time.Duration(10*(i+1)+60)*time.Second)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return runs[0], runs[1]
+}
+
+func ptr(s string) *string {
+	return &s
+}
+
+func TestLabelFilters(t *testing.T) {
+	p, err := NewDBPersister(InMemory)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer p.Close()
+	storeGroupedJobs(t, p)
+
+	tests := []struct {
+		name     string
+		filter   MetricsFilter
+		expected []int64
+	}{
+		{"no labels", MetricsFilter{Executor: "e1"}, []int64{20, 10}},
+		{"single label", MetricsFilter{Executor: "e1", Labels: map[string]string{"env": "a"}}, []int64{10}},
+		{"all labels", MetricsFilter{Executor: "e1", Labels: map[string]string{"env": "b", "a.b": "x"}}, []int64{20}},
+		{"key with a dot", MetricsFilter{Executor: "e1", Labels: map[string]string{"a.b": "x"}}, []int64{20, 10}},
+		{"key with a quote", MetricsFilter{Executor: "e1", Labels: map[string]string{`a"b`: "q"}}, []int64{10}},
+		{"unknown value", MetricsFilter{Executor: "e1", Labels: map[string]string{"env": "c"}}, nil},
+		{"unknown key", MetricsFilter{Executor: "e1", Labels: map[string]string{"cluster": "aks"}}, nil},
+		// Jobs without metadata carry no labels
+		{"jobs without labels", MetricsFilter{Executor: "e2", Labels: map[string]string{"env": "a"}}, []int64{30}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			latencies, err := p.GetQueueLatenciesInRange(test.filter)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(latencies) != len(test.expected) || (len(latencies) > 0 && !reflect.DeepEqual(latencies, test.expected)) {
+				t.Fatalf("expected queue latencies %v, got %v", test.expected, latencies)
+			}
+		})
+	}
+}
+
+func TestSummaryByGroup(t *testing.T) {
+	p, err := NewDBPersister(InMemory)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer p.Close()
+	first, second := storeGroupedJobs(t, p)
+
+	tests := []struct {
+		name     string
+		filter   MetricsFilter
+		grouping Grouping
+		expected map[string][]int64
+	}{
+		{"executor", MetricsFilter{}, Grouping{By: GroupByExecutor}, map[string][]int64{"e1": {10, 20}, "e2": {30, 40}}},
+		{"commit hash", MetricsFilter{}, Grouping{By: GroupByCommitHash}, map[string][]int64{"c1": {10, 20}, "c2": {30, 40}}},
+		{"run", MetricsFilter{}, Grouping{By: GroupByRun}, map[string][]int64{first.String(): {10, 20}, second.String(): {30, 40}}},
+		{"hour", MetricsFilter{}, Grouping{By: GroupByHour}, map[string][]int64{"2025-02-04T11:00:00Z": {10, 20}, "2025-02-05T09:00:00Z": {30, 40}}},
+		{"day", MetricsFilter{}, Grouping{By: GroupByDay}, map[string][]int64{"2025-02-04": {10, 20}, "2025-02-05": {30, 40}}},
+		// Jobs without the label are left out
+		{"label", MetricsFilter{}, Grouping{By: GroupByLabel, Label: "env"}, map[string][]int64{"a": {10, 30}, "b": {20}}},
+		{"label with a dot", MetricsFilter{}, Grouping{By: GroupByLabel, Label: "a.b"}, map[string][]int64{"x": {10, 20}}},
+		{"label with a quote", MetricsFilter{}, Grouping{By: GroupByLabel, Label: `a"b`}, map[string][]int64{"q": {10}}},
+		{"unknown label", MetricsFilter{}, Grouping{By: GroupByLabel, Label: "cluster"}, map[string][]int64{}},
+		{"filtered by executor", MetricsFilter{Executor: "e1"}, Grouping{By: GroupByDay}, map[string][]int64{"2025-02-04": {10, 20}}},
+		{"filtered by label", MetricsFilter{Labels: map[string]string{"env": "a"}}, Grouping{By: GroupByExecutor}, map[string][]int64{"e1": {10}, "e2": {30}}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			queueLatencies, err := p.GetQueueLatencySummaryByGroup(test.filter, test.grouping)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(queueLatencies, test.expected) {
+				t.Fatalf("expected queue latencies %v, got %v", test.expected, queueLatencies)
+			}
+
+			// Build times are 60 seconds and total latencies 60 seconds longer than queue latencies
+			buildTimes, err := p.GetBuildTimeSummaryByGroup(test.filter, test.grouping)
+			if err != nil {
+				t.Fatal(err)
+			}
+			totalLatencies, err := p.GetTotalLatenciesSummaryByGroup(test.filter, test.grouping)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for group, latencies := range test.expected {
+				expectedBuildTimes := make([]int64, len(latencies))
+				expectedTotalLatencies := make([]int64, len(latencies))
+				for i, latency := range latencies {
+					expectedBuildTimes[i] = 60
+					expectedTotalLatencies[i] = latency + 60
+				}
+				if !reflect.DeepEqual(buildTimes[group], expectedBuildTimes) {
+					t.Errorf("group %s: expected build times %v, got %v", group, expectedBuildTimes, buildTimes[group])
+				}
+				if !reflect.DeepEqual(totalLatencies[group], expectedTotalLatencies) {
+					t.Errorf("group %s: expected total latencies %v, got %v", group, expectedTotalLatencies, totalLatencies[group])
+				}
+			}
+			if len(buildTimes) != len(test.expected) || len(totalLatencies) != len(test.expected) {
+				t.Errorf("expected %d groups, got %d build time and %d total latency groups", len(test.expected), len(buildTimes), len(totalLatencies))
+			}
+		})
+	}
+}
@@ -0,0 +1,597 @@
+package persister
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	_ "github.com/lib/pq"
+
+	"github.com/Mtze/CI-Benchmarker/persister/model"
+	"github.com/Mtze/CI-Benchmarker/persister/postgresmodel"
+)
+
+// IsPostgresURL reports whether url selects a PostgreSQL database
+func IsPostgresURL(url string) bool {
+	return strings.HasPrefix(url, "postgres://") || strings.HasPrefix(url, "postgresql://")
+}
+
+func openPostgres(url string) (DBPersister, error) {
+	db, err := sql.Open("postgres", url)
+	if err != nil {
+		return DBPersister{}, fmt.Errorf("error while opening DB: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if err := db.PingContext(ctx); err != nil {
+		db.Close()
+		return DBPersister{}, fmt.Errorf("DB ping failed: %w", err)
+	}
+
+	return DBPersister{db: db, queries: postgresQueries{postgresmodel.New(db)}, postgres: true}, nil
+}
+
+// postgresQueries runs the queries sqlc generated for PostgreSQL behind the interface of the SQLite queries.
+// Both have the same names and results, only the types sqlc infers for some parameters and columns differ.
+type postgresQueries struct {
+	q *postgresmodel.Queries
+}
+
+var _ model.Querier = postgresQueries{}
+
+func (p postgresQueries) CancelPendingJobsByRun(ctx context.Context, runID uuid.NullUUID) (int64, error) {
+	return p.q.CancelPendingJobsByRun(ctx, runID)
+}
+
+func (p postgresQueries) ClaimOrphanCallbacks(ctx context.Context, jobID uuid.UUID) ([]model.OrphanCallback, error) {
+	rows, err := p.q.ClaimOrphanCallbacks(ctx, jobID)
+	return convertRows(rows, err, func(row postgresmodel.OrphanCallback) model.OrphanCallback { return model.OrphanCallback(row) })
+}
+
+func (p postgresQueries) CountRuns(ctx context.Context, id uuid.UUID) (int64, error) {
+	return p.q.CountRuns(ctx, id)
+}
+
+func (p postgresQueries) CountScheduledJobs(ctx context.Context, id uuid.UUID) (int64, error) {
+	return p.q.CountScheduledJobs(ctx, id)
+}
+
+func (p postgresQueries) CreateExperiment(ctx context.Context, arg model.CreateExperimentParams) error {
+	return p.q.CreateExperiment(ctx, postgresmodel.CreateExperimentParams(arg))
+}
+
+func (p postgresQueries) CreatePayloadVersion(ctx context.Context, arg model.CreatePayloadVersionParams) error {
+	return p.q.CreatePayloadVersion(ctx, postgresmodel.CreatePayloadVersionParams{
+		ID:           arg.ID,
+		Version:      arg.Version,
+		Name:         arg.Name,
+		Content:      rawJSON(arg.Content),
+		ContentHash:  arg.ContentHash,
+		CreationTime: arg.CreationTime,
+	})
+}
+
+func (p postgresQueries) CreateRun(ctx context.Context, arg model.CreateRunParams) error {
+	return p.q.CreateRun(ctx, postgresmodel.CreateRunParams{
+		ID:           arg.ID,
+		CreationTime: arg.CreationTime,
+		Executor:     arg.Executor,
+		JobCount:     arg.JobCount,
+		Concurrency:  arg.Concurrency,
+		Scenario:     arg.Scenario,
+		Labels:       nullString(arg.Labels),
+		ExperimentID: arg.ExperimentID,
+	})
+}
+
+func (p postgresQueries) DeleteJobResult(ctx context.Context, id uuid.UUID) error {
+	return p.q.DeleteJobResult(ctx, id)
+}
+
+func (p postgresQueries) DeletePayload(ctx context.Context, id uuid.UUID) (int64, error) {
+	return p.q.DeletePayload(ctx, id)
+}
+
+func (p postgresQueries) DeleteRun(ctx context.Context, id uuid.UUID) error {
+	return p.q.DeleteRun(ctx, id)
+}
+
+func (p postgresQueries) DeleteScheduledJob(ctx context.Context, id uuid.UUID) error {
+	return p.q.DeleteScheduledJob(ctx, id)
+}
+
+func (p postgresQueries) DeleteSubmissionAttemptsByRun(ctx context.Context, runID uuid.UUID) error {
+	return p.q.DeleteSubmissionAttemptsByRun(ctx, runID)
+}
+
+func (p postgresQueries) GetBuildTimeSummaryByGroup(ctx context.Context, arg model.GetBuildTimeSummaryByGroupParams) ([]model.GetBuildTimeSummaryByGroupRow, error) {
+	rows, err := p.q.GetBuildTimeSummaryByGroup(ctx, postgresmodel.GetBuildTimeSummaryByGroupParams{
+		GroupBy:     text(arg.GroupBy),
+		GroupLabel:  arg.GroupLabel,
+		From:        nullTime(arg.From),
+		To:          nullTime(arg.To),
+		CommitHash:  arg.CommitHash,
+		Executor:    arg.Executor,
+		PayloadHash: arg.PayloadHash,
+		Labels:      nullString(arg.Labels),
+	})
+	return convertRows(rows, err, func(row postgresmodel.GetBuildTimeSummaryByGroupRow) model.GetBuildTimeSummaryByGroupRow {
+		return model.GetBuildTimeSummaryByGroupRow(row)
+	})
+}
+
+func (p postgresQueries) GetBuildTimeSummaryInRangeByCommitAndExecutor(ctx context.Context, arg model.GetBuildTimeSummaryInRangeByCommitAndExecutorParams) ([]int64, error) {
+	return p.q.GetBuildTimeSummaryInRangeByCommitAndExecutor(ctx, postgresmodel.GetBuildTimeSummaryInRangeByCommitAndExecutorParams{
+		From:        nullTime(arg.From),
+		To:          nullTime(arg.To),
+		CommitHash:  arg.CommitHash,
+		Executor:    arg.Executor,
+		PayloadHash: arg.PayloadHash,
+		Labels:      nullString(arg.Labels),
+	})
+}
+
+func (p postgresQueries) GetBuildTimesInRangeByCommitAndExecutor(ctx context.Context, arg model.GetBuildTimesInRangeByCommitAndExecutorParams) ([]int64, error) {
+	return p.q.GetBuildTimesInRangeByCommitAndExecutor(ctx, postgresmodel.GetBuildTimesInRangeByCommitAndExecutorParams{
+		From:        nullTime(arg.From),
+		To:          nullTime(arg.To),
+		CommitHash:  arg.CommitHash,
+		Executor:    arg.Executor,
+		PayloadHash: arg.PayloadHash,
+		Labels:      nullString(arg.Labels),
+	})
+}
+
+func (p postgresQueries) GetExperiment(ctx context.Context, id uuid.UUID) (model.Experiment, error) {
+	row, err := p.q.GetExperiment(ctx, id)
+	return model.Experiment(row), err
+}
+
+func (p postgresQueries) GetJobCreationTimeAtOffset(ctx context.Context, offset int64) (time.Time, error) {
+	return p.q.GetJobCreationTimeAtOffset(ctx, offset)
+}
+
+func (p postgresQueries) GetJobResult(ctx context.Context, id uuid.UUID) (model.JobResult, error) {
+	row, err := p.q.GetJobResult(ctx, id)
+	return modelJobResult(row), err
+}
+
+func (p postgresQueries) GetJobTimesByExperiment(ctx context.Context, experimentID uuid.NullUUID) ([]model.GetJobTimesByExperimentRow, error) {
+	rows, err := p.q.GetJobTimesByExperiment(ctx, experimentID)
+	return convertRows(rows, err, func(row postgresmodel.GetJobTimesByExperimentRow) model.GetJobTimesByExperimentRow {
+		return model.GetJobTimesByExperimentRow(row)
+	})
+}
+
+func (p postgresQueries) GetLatestPayloadVersion(ctx context.Context, id uuid.UUID) (model.Payload, error) {
+	row, err := p.q.GetLatestPayloadVersion(ctx, id)
+	return modelPayload(row), err
+}
+
+func (p postgresQueries) GetPayloadVersion(ctx context.Context, arg model.GetPayloadVersionParams) (model.Payload, error) {
+	row, err := p.q.GetPayloadVersion(ctx, postgresmodel.GetPayloadVersionParams(arg))
+	return modelPayload(row), err
+}
+
+func (p postgresQueries) GetPendingJobIDsByRun(ctx context.Context, runID uuid.NullUUID) ([]uuid.UUID, error) {
+	return p.q.GetPendingJobIDsByRun(ctx, runID)
+}
+
+func (p postgresQueries) GetQueueLatenciesInRangeByCommitAndExecutor(ctx context.Context, arg model.GetQueueLatenciesInRangeByCommitAndExecutorParams) ([]int64, error) {
+	return p.q.GetQueueLatenciesInRangeByCommitAndExecutor(ctx, postgresmodel.GetQueueLatenciesInRangeByCommitAndExecutorParams{
+		From:        nullTime(arg.From),
+		To:          nullTime(arg.To),
+		CommitHash:  arg.CommitHash,
+		Executor:    arg.Executor,
+		PayloadHash: arg.PayloadHash,
+		Labels:      nullString(arg.Labels),
+	})
+}
+
+func (p postgresQueries) GetQueueLatencySummaryByGroup(ctx context.Context, arg model.GetQueueLatencySummaryByGroupParams) ([]model.GetQueueLatencySummaryByGroupRow, error) {
+	rows, err := p.q.GetQueueLatencySummaryByGroup(ctx, postgresmodel.GetQueueLatencySummaryByGroupParams{
+		GroupBy:     text(arg.GroupBy),
+		GroupLabel:  arg.GroupLabel,
+		From:        nullTime(arg.From),
+		To:          nullTime(arg.To),
+		CommitHash:  arg.CommitHash,
+		Executor:    arg.Executor,
+		PayloadHash: arg.PayloadHash,
+		Labels:      nullString(arg.Labels),
+	})
+	return convertRows(rows, err, func(row postgresmodel.GetQueueLatencySummaryByGroupRow) model.GetQueueLatencySummaryByGroupRow {
+		return model.GetQueueLatencySummaryByGroupRow(row)
+	})
+}
+
+func (p postgresQueries) GetQueueLatencySummaryInRangeByCommitAndExecutor(ctx context.Context, arg model.GetQueueLatencySummaryInRangeByCommitAndExecutorParams) ([]int64, error) {
+	return p.q.GetQueueLatencySummaryInRangeByCommitAndExecutor(ctx, postgresmodel.GetQueueLatencySummaryInRangeByCommitAndExecutorParams{
+		From:        nullTime(arg.From),
+		To:          nullTime(arg.To),
+		CommitHash:  arg.CommitHash,
+		Executor:    arg.Executor,
+		PayloadHash: arg.PayloadHash,
+		Labels:      nullString(arg.Labels),
+	})
+}
+
+func (p postgresQueries) GetRun(ctx context.Context, id uuid.UUID) (model.BenchmarkRun, error) {
+	row, err := p.q.GetRun(ctx, id)
+	return modelBenchmarkRun(row), err
+}
+
+func (p postgresQueries) GetRunJobStats(ctx context.Context, runID uuid.NullUUID) (model.GetRunJobStatsRow, error) {
+	row, err := p.q.GetRunJobStats(ctx, runID)
+	return model.GetRunJobStatsRow(row), err
+}
+
+func (p postgresQueries) GetRunSubmissionStats(ctx context.Context, runID uuid.UUID) (model.GetRunSubmissionStatsRow, error) {
+	row, err := p.q.GetRunSubmissionStats(ctx, runID)
+	return model.GetRunSubmissionStatsRow(row), err
+}
+
+func (p postgresQueries) GetRunsByExperiment(ctx context.Context, experimentID uuid.NullUUID) ([]model.BenchmarkRun, error) {
+	rows, err := p.q.GetRunsByExperiment(ctx, experimentID)
+	return convertRows(rows, err, modelBenchmarkRun)
+}
+
+func (p postgresQueries) GetSchemaVersion(ctx context.Context) (int64, error) {
+	return p.q.GetSchemaVersion(ctx)
+}
+
+func (p postgresQueries) GetTotalLatenciesInRangeByCommitAndExecutor(ctx context.Context, arg model.GetTotalLatenciesInRangeByCommitAndExecutorParams) ([]int64, error) {
+	return p.q.GetTotalLatenciesInRangeByCommitAndExecutor(ctx, postgresmodel.GetTotalLatenciesInRangeByCommitAndExecutorParams{
+		From:        nullTime(arg.From),
+		To:          nullTime(arg.To),
+		CommitHash:  arg.CommitHash,
+		Executor:    arg.Executor,
+		PayloadHash: arg.PayloadHash,
+		Labels:      nullString(arg.Labels),
+	})
+}
+
+func (p postgresQueries) GetTotalLatenciesSummaryByGroup(ctx context.Context, arg model.GetTotalLatenciesSummaryByGroupParams) ([]model.GetTotalLatenciesSummaryByGroupRow, error) {
+	rows, err := p.q.GetTotalLatenciesSummaryByGroup(ctx, postgresmodel.GetTotalLatenciesSummaryByGroupParams{
+		GroupBy:     text(arg.GroupBy),
+		GroupLabel:  arg.GroupLabel,
+		From:        nullTime(arg.From),
+		To:          nullTime(arg.To),
+		CommitHash:  arg.CommitHash,
+		Executor:    arg.Executor,
+		PayloadHash: arg.PayloadHash,
+		Labels:      nullString(arg.Labels),
+	})
+	return convertRows(rows, err, func(row postgresmodel.GetTotalLatenciesSummaryByGroupRow) model.GetTotalLatenciesSummaryByGroupRow {
+		return model.GetTotalLatenciesSummaryByGroupRow(row)
+	})
+}
+
+func (p postgresQueries) GetTotalLatenciesSummaryInRangeByCommitAndExecutor(ctx context.Context, arg model.GetTotalLatenciesSummaryInRangeByCommitAndExecutorParams) ([]int64, error) {
+	return p.q.GetTotalLatenciesSummaryInRangeByCommitAndExecutor(ctx, postgresmodel.GetTotalLatenciesSummaryInRangeByCommitAndExecutorParams{
+		From:        nullTime(arg.From),
+		To:          nullTime(arg.To),
+		CommitHash:  arg.CommitHash,
+		Executor:    arg.Executor,
+		PayloadHash: arg.PayloadHash,
+		Labels:      nullString(arg.Labels),
+	})
+}
+
+func (p postgresQueries) ImportRun(ctx context.Context, arg model.ImportRunParams) error {
+	return p.q.ImportRun(ctx, postgresmodel.ImportRunParams{
+		ID:           arg.ID,
+		CreationTime: arg.CreationTime,
+		Executor:     arg.Executor,
+		JobCount:     arg.JobCount,
+		Concurrency:  arg.Concurrency,
+		Status:       arg.Status,
+		Error:        arg.Error,
+		FinishTime:   arg.FinishTime,
+		Scenario:     arg.Scenario,
+		Labels:       nullString(arg.Labels),
+		ExperimentID: arg.ExperimentID,
+	})
+}
+
+func (p postgresQueries) ImportScheduledJob(ctx context.Context, arg model.ImportScheduledJobParams) error {
+	return p.q.ImportScheduledJob(ctx, postgresmodel.ImportScheduledJobParams{
+		ID:             arg.ID,
+		CreationTime:   arg.CreationTime,
+		Executor:       arg.Executor,
+		Metadata:       nullString(arg.Metadata),
+		CommitHash:     arg.CommitHash,
+		Status:         arg.Status,
+		RunID:          arg.RunID,
+		PayloadID:      arg.PayloadID,
+		PayloadVersion: arg.PayloadVersion,
+		PayloadHash:    arg.PayloadHash,
+	})
+}
+
+func (p postgresQueries) ListJobsForExport(ctx context.Context, arg model.ListJobsForExportParams) ([]model.ListJobsForExportRow, error) {
+	rows, err := p.q.ListJobsForExport(ctx, postgresmodel.ListJobsForExportParams(arg))
+	return convertRows(rows, err, modelListJobsForExportRow)
+}
+
+func (p postgresQueries) ListJobsToPrune(ctx context.Context, arg model.ListJobsToPruneParams) ([]model.ListJobsToPruneRow, error) {
+	rows, err := p.q.ListJobsToPrune(ctx, postgresmodel.ListJobsToPruneParams{
+		Before:    nullTime(arg.Before),
+		Executor:  arg.Executor,
+		RunID:     arg.RunID,
+		BatchSize: arg.BatchSize,
+	})
+	return convertRows(rows, err, modelListJobsToPruneRow)
+}
+
+func (p postgresQueries) ListOrphanCallbacks(ctx context.Context, limit int64) ([]model.OrphanCallback, error) {
+	rows, err := p.q.ListOrphanCallbacks(ctx, limit)
+	return convertRows(rows, err, func(row postgresmodel.OrphanCallback) model.OrphanCallback { return model.OrphanCallback(row) })
+}
+
+func (p postgresQueries) ListPayloadVersions(ctx context.Context, id uuid.UUID) ([]model.Payload, error) {
+	rows, err := p.q.ListPayloadVersions(ctx, id)
+	return convertRows(rows, err, modelPayload)
+}
+
+func (p postgresQueries) ListPayloads(ctx context.Context) ([]model.Payload, error) {
+	rows, err := p.q.ListPayloads(ctx)
+	return convertRows(rows, err, modelPayload)
+}
+
+func (p postgresQueries) ListRunIDsAfterOffset(ctx context.Context, offset int64) ([]uuid.UUID, error) {
+	return p.q.ListRunIDsAfterOffset(ctx, offset)
+}
+
+func (p postgresQueries) ListRunsForExport(ctx context.Context, arg model.ListRunsForExportParams) ([]model.BenchmarkRun, error) {
+	rows, err := p.q.ListRunsForExport(ctx, postgresmodel.ListRunsForExportParams(arg))
+	return convertRows(rows, err, modelBenchmarkRun)
+}
+
+func (p postgresQueries) ListRunsToPrune(ctx context.Context, arg model.ListRunsToPruneParams) ([]model.BenchmarkRun, error) {
+	rows, err := p.q.ListRunsToPrune(ctx, postgresmodel.ListRunsToPruneParams{
+		Before:   nullTime(arg.Before),
+		Executor: arg.Executor,
+		RunID:    arg.RunID,
+	})
+	return convertRows(rows, err, modelBenchmarkRun)
+}
+
+func (p postgresQueries) ListSubmissionAttemptsByRun(ctx context.Context, runID uuid.UUID) ([]model.SubmissionAttempt, error) {
+	rows, err := p.q.ListSubmissionAttemptsByRun(ctx, runID)
+	return convertRows(rows, err, func(row postgresmodel.SubmissionAttempt) model.SubmissionAttempt { return model.SubmissionAttempt(row) })
+}
+
+func (p postgresQueries) RecordMigration(ctx context.Context, arg model.RecordMigrationParams) error {
+	return p.q.RecordMigration(ctx, postgresmodel.RecordMigrationParams(arg))
+}
+
+func (p postgresQueries) SaveJobResult(ctx context.Context, arg model.SaveJobResultParams) error {
+	return p.q.SaveJobResult(ctx, postgresmodel.SaveJobResultParams{
+		ID:                 arg.ID,
+		StartTime:          nullTime(arg.StartTime),
+		EndTime:            nullTime(arg.EndTime),
+		StartTimeCallbacks: arg.StartTimeCallbacks,
+		EndTimeCallbacks:   arg.EndTimeCallbacks,
+		Invalid:            arg.Invalid,
+	})
+}
+
+func (p postgresQueries) StoreOrphanCallback(ctx context.Context, arg model.StoreOrphanCallbackParams) error {
+	return p.q.StoreOrphanCallback(ctx, postgresmodel.StoreOrphanCallbackParams(arg))
+}
+
+func (p postgresQueries) StoreScheduledJob(ctx context.Context, arg model.StoreScheduledJobParams) (model.ScheduledJob, error) {
+	row, err := p.q.StoreScheduledJob(ctx, postgresmodel.StoreScheduledJobParams(arg))
+	return modelScheduledJob(row), err
+}
+
+func (p postgresQueries) StoreScheduledJobWithMetadata(ctx context.Context, arg model.StoreScheduledJobWithMetadataParams) (model.ScheduledJob, error) {
+	row, err := p.q.StoreScheduledJobWithMetadata(ctx, postgresmodel.StoreScheduledJobWithMetadataParams{
+		ID:             arg.ID,
+		CreationTime:   arg.CreationTime,
+		Executor:       arg.Executor,
+		Metadata:       nullString(arg.Metadata),
+		CommitHash:     arg.CommitHash,
+		RunID:          arg.RunID,
+		PayloadID:      arg.PayloadID,
+		PayloadVersion: arg.PayloadVersion,
+		PayloadHash:    arg.PayloadHash,
+	})
+	return modelScheduledJob(row), err
+}
+
+func (p postgresQueries) StoreSubmissionAttempt(ctx context.Context, arg model.StoreSubmissionAttemptParams) error {
+	return p.q.StoreSubmissionAttempt(ctx, postgresmodel.StoreSubmissionAttemptParams(arg))
+}
+
+func (p postgresQueries) UpdateExperimentStatus(ctx context.Context, arg model.UpdateExperimentStatusParams) error {
+	return p.q.UpdateExperimentStatus(ctx, postgresmodel.UpdateExperimentStatusParams(arg))
+}
+
+func (p postgresQueries) UpdateJobStatus(ctx context.Context, arg model.UpdateJobStatusParams) error {
+	return p.q.UpdateJobStatus(ctx, postgresmodel.UpdateJobStatusParams(arg))
+}
+
+func (p postgresQueries) UpdateRunStatus(ctx context.Context, arg model.UpdateRunStatusParams) error {
+	return p.q.UpdateRunStatus(ctx, postgresmodel.UpdateRunStatusParams(arg))
+}
+
+func (p postgresQueries) UpsertJobTimes(ctx context.Context, arg model.UpsertJobTimesParams) (model.JobResult, error) {
+	row, err := p.q.UpsertJobTimes(ctx, postgresmodel.UpsertJobTimesParams{
+		ID:        arg.ID,
+		StartTime: nullTime(arg.StartTime),
+		EndTime:   nullTime(arg.EndTime),
+	})
+	return modelJobResult(row), err
+}
+
+func modelBenchmarkRun(row postgresmodel.BenchmarkRun) model.BenchmarkRun {
+	return model.BenchmarkRun{
+		ID:           row.ID,
+		CreationTime: row.CreationTime,
+		Executor:     row.Executor,
+		JobCount:     row.JobCount,
+		Concurrency:  row.Concurrency,
+		Status:       row.Status,
+		Error:        row.Error,
+		FinishTime:   row.FinishTime,
+		Scenario:     row.Scenario,
+		Labels:       stringValue(row.Labels),
+		ExperimentID: row.ExperimentID,
+	}
+}
+
+func modelJobResult(row postgresmodel.JobResult) model.JobResult {
+	return model.JobResult{
+		ID:                 row.ID,
+		StartTime:          timeValue(row.StartTime),
+		EndTime:            timeValue(row.EndTime),
+		StartTimeCallbacks: row.StartTimeCallbacks,
+		EndTimeCallbacks:   row.EndTimeCallbacks,
+		Invalid:            row.Invalid,
+	}
+}
+
+func modelListJobsForExportRow(row postgresmodel.ListJobsForExportRow) model.ListJobsForExportRow {
+	return model.ListJobsForExportRow{
+		ID:                 row.ID,
+		CreationTime:       row.CreationTime,
+		Executor:           row.Executor,
+		Metadata:           stringValue(row.Metadata),
+		CommitHash:         row.CommitHash,
+		Status:             row.Status,
+		RunID:              row.RunID,
+		PayloadID:          row.PayloadID,
+		PayloadVersion:     row.PayloadVersion,
+		PayloadHash:        row.PayloadHash,
+		StartTime:          timeValue(row.StartTime),
+		EndTime:            timeValue(row.EndTime),
+		StartTimeCallbacks: row.StartTimeCallbacks,
+		EndTimeCallbacks:   row.EndTimeCallbacks,
+		Invalid:            row.Invalid,
+	}
+}
+
+func modelListJobsToPruneRow(row postgresmodel.ListJobsToPruneRow) model.ListJobsToPruneRow {
+	return model.ListJobsToPruneRow{
+		ID:                 row.ID,
+		CreationTime:       row.CreationTime,
+		Executor:           row.Executor,
+		Metadata:           stringValue(row.Metadata),
+		CommitHash:         row.CommitHash,
+		Status:             row.Status,
+		RunID:              row.RunID,
+		PayloadID:          row.PayloadID,
+		PayloadVersion:     row.PayloadVersion,
+		PayloadHash:        row.PayloadHash,
+		StartTime:          timeValue(row.StartTime),
+		EndTime:            timeValue(row.EndTime),
+		StartTimeCallbacks: row.StartTimeCallbacks,
+		EndTimeCallbacks:   row.EndTimeCallbacks,
+		Invalid:            row.Invalid,
+	}
+}
+
+func modelPayload(row postgresmodel.Payload) model.Payload {
+	return model.Payload{
+		ID:           row.ID,
+		Version:      row.Version,
+		Name:         row.Name,
+		Content:      jsonValue(row.Content),
+		ContentHash:  row.ContentHash,
+		CreationTime: row.CreationTime,
+		Deleted:      row.Deleted,
+	}
+}
+
+func modelScheduledJob(row postgresmodel.ScheduledJob) model.ScheduledJob {
+	return model.ScheduledJob{
+		ID:             row.ID,
+		CreationTime:   row.CreationTime,
+		Executor:       row.Executor,
+		Metadata:       stringValue(row.Metadata),
+		CommitHash:     row.CommitHash,
+		Status:         row.Status,
+		RunID:          row.RunID,
+		PayloadID:      row.PayloadID,
+		PayloadVersion: row.PayloadVersion,
+		PayloadHash:    row.PayloadHash,
+	}
+}
+
+// convertRows converts the rows of a PostgreSQL query to the rows of its SQLite version
+func convertRows[T, U any](rows []T, err error, convert func(T) U) ([]U, error) {
+	if err != nil {
+		return nil, err
+	}
+	converted := make([]U, len(rows))
+	for i, row := range rows {
+		converted[i] = convert(row)
+	}
+	return converted, nil
+}
+
+// nullTime converts a time parameter, the SQLite queries take it as sql.NullTime or time.Time
+func nullTime(value interface{}) sql.NullTime {
+	switch v := value.(type) {
+	case sql.NullTime:
+		return v
+	case time.Time:
+		return sql.NullTime{Time: v, Valid: true}
+	}
+	return sql.NullTime{Valid: false}
+}
+
+// nullString converts a JSON parameter, the SQLite queries take it as sql.NullString, string or []byte
+func nullString(value interface{}) sql.NullString {
+	switch v := value.(type) {
+	case sql.NullString:
+		return v
+	case string:
+		return sql.NullString{String: v, Valid: true}
+	case []byte:
+		return sql.NullString{String: string(v), Valid: v != nil}
+	}
+	return sql.NullString{Valid: false}
+}
+
+func text(value interface{}) string {
+	s, _ := value.(string)
+	return s
+}
+
+func rawJSON(value interface{}) json.RawMessage {
+	switch v := value.(type) {
+	case string:
+		return json.RawMessage(v)
+	case []byte:
+		return json.RawMessage(v)
+	}
+	return nil
+}
+
+// timeValue returns a time column like SQLite does, nil if it is NULL
+func timeValue(t sql.NullTime) interface{} {
+	if !t.Valid {
+		return nil
+	}
+	return t.Time
+}
+
+// stringValue returns a JSON column like SQLite does, nil if it is NULL
+func stringValue(s sql.NullString) interface{} {
+	if !s.Valid {
+		return nil
+	}
+	return s.String
+}
+
+func jsonValue(data json.RawMessage) interface{} {
+	return []byte(data)
+}
@@ -0,0 +1,562 @@
+-- PostgreSQL versions of the queries in ../query.sql, sqlc generates the postgresmodel package from them.
+-- The persister runs them through an adapter with the interface of the SQLite queries, so every query in
+-- ../query.sql needs a version with the same name here.
+
+-- name: StoreScheduledJobWithMetadata :one
+INSERT INTO scheduled_job (
+  id, creation_time, executor, metadata, commit_hash, run_id, payload_id, payload_version, payload_hash
+) VALUES (
+  $1, $2, $3, $4, $5, $6, $7, $8, $9
+)
+RETURNING *;
+
+-- name: StoreScheduledJob :one
+INSERT INTO scheduled_job (
+  id, creation_time, executor, commit_hash, run_id, payload_id, payload_version, payload_hash
+) VALUES (
+  $1, $2, $3, $4, $5, $6, $7, $8
+)
+RETURNING *;
+
+-- name: GetJobResult :one
+SELECT * FROM job_results
+WHERE id = $1
+FOR UPDATE;
+
+-- name: SaveJobResult :exec
+INSERT INTO job_results (
+  id, start_time, end_time, start_time_callbacks, end_time_callbacks, invalid
+) VALUES (
+  $1, $2, $3, $4, $5, $6
+)
+ON CONFLICT (id) DO UPDATE
+  SET start_time = EXCLUDED.start_time,
+  end_time = EXCLUDED.end_time,
+  start_time_callbacks = EXCLUDED.start_time_callbacks,
+  end_time_callbacks = EXCLUDED.end_time_callbacks,
+  invalid = EXCLUDED.invalid;
+
+-- name: UpdateJobStatus :exec
+UPDATE scheduled_job
+SET status = $1
+WHERE id = $2;
+
+-- name: CreateRun :exec
+INSERT INTO benchmark_run (
+  id, creation_time, executor, job_count, concurrency, scenario, labels, experiment_id
+) VALUES (
+  $1, $2, $3, $4, $5, $6, $7, $8
+);
+
+-- name: UpdateRunStatus :exec
+UPDATE benchmark_run
+SET status = $1, error = $2, finish_time = $3
+WHERE id = $4;
+
+-- name: GetRun :one
+SELECT * FROM benchmark_run
+WHERE id = $1;
+
+-- name: GetRunJobStats :one
+SELECT
+    COUNT(*) AS scheduled_jobs,
+    CAST(COALESCE(SUM(CASE WHEN s.status != 'cancelled' AND (r.end_time IS NOT NULL OR s.status IN ('succeeded', 'failed')) THEN 1 ELSE 0 END), 0) AS BIGINT) AS finished_jobs,
+    CAST(COALESCE(SUM(CASE WHEN s.status = 'cancelled' THEN 1 ELSE 0 END), 0) AS BIGINT) AS cancelled_jobs,
+    CAST(COALESCE(SUM(
+        CASE WHEN r.start_time_callbacks > 1 THEN r.start_time_callbacks - 1 ELSE 0 END +
+        CASE WHEN r.end_time_callbacks > 1 THEN r.end_time_callbacks - 1 ELSE 0 END
+    ), 0) AS BIGINT) AS duplicate_callbacks,
+    CAST(COALESCE(SUM(CASE WHEN r.invalid THEN 1 ELSE 0 END), 0) AS BIGINT) AS invalid_jobs
+FROM
+    scheduled_job s
+        LEFT JOIN job_results r ON s.id = r.id
+WHERE
+    s.run_id = $1;
+
+-- name: GetPendingJobIDsByRun :many
+SELECT
+    s.id
+FROM
+    scheduled_job s
+        LEFT JOIN job_results r ON s.id = r.id
+WHERE
+    s.run_id = $1
+  AND s.status NOT IN ('succeeded', 'failed', 'cancelled')
+  AND r.end_time IS NULL;
+
+-- name: CancelPendingJobsByRun :execrows
+UPDATE scheduled_job
+SET status = 'cancelled'
+WHERE run_id = $1
+  AND status NOT IN ('succeeded', 'failed', 'cancelled')
+  AND id NOT IN (SELECT id FROM job_results WHERE end_time IS NOT NULL);
+
+-- name: StoreSubmissionAttempt :exec
+INSERT INTO submission_attempt (
+  run_id, job_index, attempt, submit_delay_ms, start_time, duration_ms, status_code, error, job_id
+) VALUES (
+  $1, $2, $3, $4, $5, $6, $7, $8, $9
+);
+
+-- name: GetRunSubmissionStats :one
+SELECT
+    CAST(COALESCE(SUM(j.attempts), 0) AS BIGINT) AS attempts,
+    CAST(COALESCE(SUM(CASE WHEN j.attempts > 1 THEN 1 ELSE 0 END), 0) AS BIGINT) AS retried_jobs,
+    CAST(COALESCE(SUM(CASE WHEN j.submitted = 0 THEN 1 ELSE 0 END), 0) AS BIGINT) AS failed_jobs,
+    CAST(trunc(COALESCE(AVG(j.submit_latency_ms), 0)) AS BIGINT) AS avg_submit_latency_ms,
+    CAST(COALESCE(MAX(j.submit_latency_ms), 0) AS BIGINT) AS max_submit_latency_ms,
+    CAST(trunc(COALESCE(AVG(j.submit_delay_ms), 0)) AS BIGINT) AS avg_submit_delay_ms,
+    CAST(COALESCE(MAX(j.submit_delay_ms), 0) AS BIGINT) AS max_submit_delay_ms
+FROM (
+    SELECT
+        job_index,
+        COUNT(*) AS attempts,
+        MAX(CASE WHEN attempt = 1 THEN submit_delay_ms ELSE 0 END) AS submit_delay_ms,
+        SUM(duration_ms) AS submit_latency_ms,
+        MAX(CASE WHEN job_id IS NOT NULL THEN 1 ELSE 0 END) AS submitted
+    FROM
+        submission_attempt
+    WHERE
+        run_id = $1
+    GROUP BY
+        job_index
+) j;
+
+-- name: UpsertJobTimes :one
+INSERT INTO job_results (id, start_time, end_time)
+  VALUES ($1, $2, $3)
+ON CONFLICT (id) DO UPDATE
+  SET start_time = EXCLUDED.start_time,
+  end_time = EXCLUDED.end_time
+RETURNING *;
+
+-- name: GetQueueLatenciesInRangeByCommitAndExecutor :many
+SELECT
+    CAST(EXTRACT(EPOCH FROM date_trunc('second', r.start_time) - date_trunc('second', s.creation_time)) AS BIGINT) AS queue_latency
+FROM
+    scheduled_job s
+        INNER JOIN job_results r ON s.id = r.id
+WHERE
+    r.start_time IS NOT NULL
+  AND (date_trunc('second', r.start_time) >= date_trunc('second', sqlc.narg(from)::timestamp) OR sqlc.narg(from)::timestamp IS NULL)
+  AND (date_trunc('second', r.start_time) <= date_trunc('second', sqlc.narg(to)::timestamp) OR sqlc.narg(to)::timestamp IS NULL)
+  AND (s.commit_hash = sqlc.narg(commit_hash)::text OR sqlc.narg(commit_hash)::text IS NULL)
+  AND (s.executor = sqlc.arg(executor)::text OR sqlc.arg(executor)::text IS NULL)
+  AND (s.payload_hash = sqlc.narg(payload_hash)::text OR sqlc.narg(payload_hash)::text IS NULL)
+  AND NOT EXISTS (
+      SELECT 1 FROM jsonb_each_text(sqlc.narg(labels)::jsonb) l
+      WHERE s.metadata ->> l.key IS DISTINCT FROM l.value
+  )
+  AND s.status != 'cancelled'
+ORDER BY
+    queue_latency DESC;
+
+-- name: GetBuildTimesInRangeByCommitAndExecutor :many
+SELECT
+    CAST(EXTRACT(EPOCH FROM date_trunc('second', r.end_time) - date_trunc('second', r.start_time)) AS BIGINT) AS build_time
+FROM
+    job_results r
+        INNER JOIN scheduled_job s ON r.id = s.id
+WHERE
+    r.start_time IS NOT NULL
+  AND r.end_time IS NOT NULL
+  AND r.invalid = false
+  AND (date_trunc('second', r.start_time) >= date_trunc('second', sqlc.narg(from)::timestamp) OR sqlc.narg(from)::timestamp IS NULL)
+  AND (date_trunc('second', r.end_time) <= date_trunc('second', sqlc.narg(to)::timestamp) OR sqlc.narg(to)::timestamp IS NULL)
+  AND (s.commit_hash = sqlc.narg(commit_hash)::text OR sqlc.narg(commit_hash)::text IS NULL)
+  AND (s.executor = sqlc.arg(executor)::text OR sqlc.arg(executor)::text IS NULL)
+  AND (s.payload_hash = sqlc.narg(payload_hash)::text OR sqlc.narg(payload_hash)::text IS NULL)
+  AND NOT EXISTS (
+      SELECT 1 FROM jsonb_each_text(sqlc.narg(labels)::jsonb) l
+      WHERE s.metadata ->> l.key IS DISTINCT FROM l.value
+  )
+  AND s.status != 'cancelled'
+ORDER BY
+    build_time DESC;
+
+-- name: GetTotalLatenciesInRangeByCommitAndExecutor :many
+SELECT
+    CAST(EXTRACT(EPOCH FROM date_trunc('second', r.end_time) - date_trunc('second', s.creation_time)) AS BIGINT) AS total_latency
+FROM
+    scheduled_job s
+        INNER JOIN job_results r ON s.id = r.id
+WHERE
+    r.start_time IS NOT NULL
+  AND r.end_time IS NOT NULL
+  AND (date_trunc('second', r.start_time) >= date_trunc('second', sqlc.narg(from)::timestamp) OR sqlc.narg(from)::timestamp IS NULL)
+  AND (date_trunc('second', r.end_time) <= date_trunc('second', sqlc.narg(to)::timestamp) OR sqlc.narg(to)::timestamp IS NULL)
+  AND (s.commit_hash = sqlc.narg(commit_hash)::text OR sqlc.narg(commit_hash)::text IS NULL)
+  AND (s.executor = sqlc.arg(executor)::text OR sqlc.arg(executor)::text IS NULL)
+  AND (s.payload_hash = sqlc.narg(payload_hash)::text OR sqlc.narg(payload_hash)::text IS NULL)
+  AND NOT EXISTS (
+      SELECT 1 FROM jsonb_each_text(sqlc.narg(labels)::jsonb) l
+      WHERE s.metadata ->> l.key IS DISTINCT FROM l.value
+  )
+  AND s.status != 'cancelled'
+ORDER BY
+    total_latency DESC;
+
+-- name: GetQueueLatencySummaryInRangeByCommitAndExecutor :many
+SELECT
+    CAST(EXTRACT(EPOCH FROM date_trunc('second', r.start_time) - date_trunc('second', s.creation_time)) AS BIGINT) AS latency
+FROM
+    scheduled_job s
+        INNER JOIN job_results r ON s.id = r.id
+WHERE
+    r.start_time IS NOT NULL
+  AND (date_trunc('second', r.start_time) >= date_trunc('second', sqlc.narg(from)::timestamp) OR sqlc.narg(from)::timestamp IS NULL)
+  AND (date_trunc('second', r.start_time) <= date_trunc('second', sqlc.narg(to)::timestamp) OR sqlc.narg(to)::timestamp IS NULL)
+  AND (s.commit_hash = sqlc.narg(commit_hash)::text OR sqlc.narg(commit_hash)::text IS NULL)
+  AND (s.executor = sqlc.arg(executor)::text OR sqlc.arg(executor)::text IS NULL)
+  AND (s.payload_hash = sqlc.narg(payload_hash)::text OR sqlc.narg(payload_hash)::text IS NULL)
+  AND NOT EXISTS (
+      SELECT 1 FROM jsonb_each_text(sqlc.narg(labels)::jsonb) l
+      WHERE s.metadata ->> l.key IS DISTINCT FROM l.value
+  )
+  AND s.status != 'cancelled'
+ORDER BY
+    latency ASC;
+
+-- name: GetBuildTimeSummaryInRangeByCommitAndExecutor :many
+SELECT
+    CAST(EXTRACT(EPOCH FROM date_trunc('second', r.end_time) - date_trunc('second', r.start_time)) AS BIGINT) AS build_time
+FROM
+    job_results r
+        INNER JOIN scheduled_job s ON r.id = s.id
+WHERE
+    r.start_time IS NOT NULL
+  AND r.end_time IS NOT NULL
+  AND r.invalid = false
+  AND (date_trunc('second', r.start_time) >= date_trunc('second', sqlc.narg(from)::timestamp) OR sqlc.narg(from)::timestamp IS NULL)
+  AND (date_trunc('second', r.end_time) <= date_trunc('second', sqlc.narg(to)::timestamp) OR sqlc.narg(to)::timestamp IS NULL)
+  AND (s.commit_hash = sqlc.narg(commit_hash)::text OR sqlc.narg(commit_hash)::text IS NULL)
+  AND (s.executor = sqlc.arg(executor)::text OR sqlc.arg(executor)::text IS NULL)
+  AND (s.payload_hash = sqlc.narg(payload_hash)::text OR sqlc.narg(payload_hash)::text IS NULL)
+  AND NOT EXISTS (
+      SELECT 1 FROM jsonb_each_text(sqlc.narg(labels)::jsonb) l
+      WHERE s.metadata ->> l.key IS DISTINCT FROM l.value
+  )
+  AND s.status != 'cancelled'
+ORDER BY
+    build_time ASC;
+
+-- name: GetTotalLatenciesSummaryInRangeByCommitAndExecutor :many
+SELECT
+    CAST(EXTRACT(EPOCH FROM date_trunc('second', r.end_time) - date_trunc('second', s.creation_time)) AS BIGINT) AS total_latency
+FROM
+    scheduled_job s
+        INNER JOIN job_results r ON s.id = r.id
+WHERE
+    r.start_time IS NOT NULL
+  AND r.end_time IS NOT NULL
+  AND (date_trunc('second', r.start_time) >= date_trunc('second', sqlc.narg(from)::timestamp) OR sqlc.narg(from)::timestamp IS NULL)
+  AND (date_trunc('second', r.end_time) <= date_trunc('second', sqlc.narg(to)::timestamp) OR sqlc.narg(to)::timestamp IS NULL)
+  AND (s.commit_hash = sqlc.narg(commit_hash)::text OR sqlc.narg(commit_hash)::text IS NULL)
+  AND (s.executor = sqlc.arg(executor)::text OR sqlc.arg(executor)::text IS NULL)
+  AND (s.payload_hash = sqlc.narg(payload_hash)::text OR sqlc.narg(payload_hash)::text IS NULL)
+  AND NOT EXISTS (
+      SELECT 1 FROM jsonb_each_text(sqlc.narg(labels)::jsonb) l
+      WHERE s.metadata ->> l.key IS DISTINCT FROM l.value
+  )
+  AND s.status != 'cancelled'
+ORDER BY
+    total_latency ASC;
+
+-- name: GetQueueLatencySummaryByGroup :many
+SELECT group_key, latency
+FROM (
+    SELECT
+        CAST(CASE sqlc.arg(group_by)::text
+            WHEN 'executor' THEN s.executor
+            WHEN 'commit_hash' THEN s.commit_hash
+            WHEN 'run' THEN s.run_id::text
+            WHEN 'hour' THEN to_char(r.start_time, 'YYYY-MM-DD"T"HH24":00:00Z"')
+            WHEN 'day' THEN to_char(r.start_time, 'YYYY-MM-DD')
+            ELSE s.metadata ->> sqlc.narg(group_label)::text
+        END AS TEXT) AS group_key,
+        CAST(EXTRACT(EPOCH FROM date_trunc('second', r.start_time) - date_trunc('second', s.creation_time)) AS BIGINT) AS latency
+    FROM
+        scheduled_job s
+            INNER JOIN job_results r ON s.id = r.id
+    WHERE
+        r.start_time IS NOT NULL
+      AND (date_trunc('second', r.start_time) >= date_trunc('second', sqlc.narg(from)::timestamp) OR sqlc.narg(from)::timestamp IS NULL)
+      AND (date_trunc('second', r.start_time) <= date_trunc('second', sqlc.narg(to)::timestamp) OR sqlc.narg(to)::timestamp IS NULL)
+      AND (s.commit_hash = sqlc.narg(commit_hash)::text OR sqlc.narg(commit_hash)::text IS NULL)
+      AND (s.executor = sqlc.narg(executor)::text OR sqlc.narg(executor)::text IS NULL)
+      AND (s.payload_hash = sqlc.narg(payload_hash)::text OR sqlc.narg(payload_hash)::text IS NULL)
+      AND NOT EXISTS (
+          SELECT 1 FROM jsonb_each_text(sqlc.narg(labels)::jsonb) l
+          WHERE s.metadata ->> l.key IS DISTINCT FROM l.value
+      )
+      AND s.status != 'cancelled'
+) grouped
+WHERE group_key IS NOT NULL
+ORDER BY
+    group_key, latency ASC;
+
+-- name: GetBuildTimeSummaryByGroup :many
+SELECT group_key, build_time
+FROM (
+    SELECT
+        CAST(CASE sqlc.arg(group_by)::text
+            WHEN 'executor' THEN s.executor
+            WHEN 'commit_hash' THEN s.commit_hash
+            WHEN 'run' THEN s.run_id::text
+            WHEN 'hour' THEN to_char(r.start_time, 'YYYY-MM-DD"T"HH24":00:00Z"')
+            WHEN 'day' THEN to_char(r.start_time, 'YYYY-MM-DD')
+            ELSE s.metadata ->> sqlc.narg(group_label)::text
+        END AS TEXT) AS group_key,
+        CAST(EXTRACT(EPOCH FROM date_trunc('second', r.end_time) - date_trunc('second', r.start_time)) AS BIGINT) AS build_time
+    FROM
+        job_results r
+            INNER JOIN scheduled_job s ON r.id = s.id
+    WHERE
+        r.start_time IS NOT NULL
+      AND r.end_time IS NOT NULL
+      AND r.invalid = false
+      AND (date_trunc('second', r.start_time) >= date_trunc('second', sqlc.narg(from)::timestamp) OR sqlc.narg(from)::timestamp IS NULL)
+      AND (date_trunc('second', r.end_time) <= date_trunc('second', sqlc.narg(to)::timestamp) OR sqlc.narg(to)::timestamp IS NULL)
+      AND (s.commit_hash = sqlc.narg(commit_hash)::text OR sqlc.narg(commit_hash)::text IS NULL)
+      AND (s.executor = sqlc.narg(executor)::text OR sqlc.narg(executor)::text IS NULL)
+      AND (s.payload_hash = sqlc.narg(payload_hash)::text OR sqlc.narg(payload_hash)::text IS NULL)
+      AND NOT EXISTS (
+          SELECT 1 FROM jsonb_each_text(sqlc.narg(labels)::jsonb) l
+          WHERE s.metadata ->> l.key IS DISTINCT FROM l.value
+      )
+      AND s.status != 'cancelled'
+) grouped
+WHERE group_key IS NOT NULL
+ORDER BY
+    group_key, build_time ASC;
+
+-- name: GetTotalLatenciesSummaryByGroup :many
+SELECT group_key, total_latency
+FROM (
+    SELECT
+        CAST(CASE sqlc.arg(group_by)::text
+            WHEN 'executor' THEN s.executor
+            WHEN 'commit_hash' THEN s.commit_hash
+            WHEN 'run' THEN s.run_id::text
+            WHEN 'hour' THEN to_char(r.start_time, 'YYYY-MM-DD"T"HH24":00:00Z"')
+            WHEN 'day' THEN to_char(r.start_time, 'YYYY-MM-DD')
+            ELSE s.metadata ->> sqlc.narg(group_label)::text
+        END AS TEXT) AS group_key,
+        CAST(EXTRACT(EPOCH FROM date_trunc('second', r.end_time) - date_trunc('second', s.creation_time)) AS BIGINT) AS total_latency
+    FROM
+        scheduled_job s
+            INNER JOIN job_results r ON s.id = r.id
+    WHERE
+        r.start_time IS NOT NULL
+      AND r.end_time IS NOT NULL
+      AND (date_trunc('second', r.start_time) >= date_trunc('second', sqlc.narg(from)::timestamp) OR sqlc.narg(from)::timestamp IS NULL)
+      AND (date_trunc('second', r.end_time) <= date_trunc('second', sqlc.narg(to)::timestamp) OR sqlc.narg(to)::timestamp IS NULL)
+      AND (s.commit_hash = sqlc.narg(commit_hash)::text OR sqlc.narg(commit_hash)::text IS NULL)
+      AND (s.executor = sqlc.narg(executor)::text OR sqlc.narg(executor)::text IS NULL)
+      AND (s.payload_hash = sqlc.narg(payload_hash)::text OR sqlc.narg(payload_hash)::text IS NULL)
+      AND NOT EXISTS (
+          SELECT 1 FROM jsonb_each_text(sqlc.narg(labels)::jsonb) l
+          WHERE s.metadata ->> l.key IS DISTINCT FROM l.value
+      )
+      AND s.status != 'cancelled'
+) grouped
+WHERE group_key IS NOT NULL
+ORDER BY
+    group_key, total_latency ASC;
+
+-- name: CreateExperiment :exec
+INSERT INTO experiment (
+  id, creation_time, scenario, run_order, seed, run_count
+) VALUES (
+  $1, $2, $3, $4, $5, $6
+);
+
+-- name: UpdateExperimentStatus :exec
+UPDATE experiment
+SET status = $1, error = $2, finish_time = $3
+WHERE id = $4;
+
+-- name: GetExperiment :one
+SELECT * FROM experiment
+WHERE id = $1;
+
+-- name: GetRunsByExperiment :many
+SELECT * FROM benchmark_run
+WHERE experiment_id = $1
+ORDER BY creation_time;
+
+-- name: GetJobTimesByExperiment :many
+SELECT
+    s.run_id,
+    s.creation_time,
+    r.start_time,
+    r.end_time
+FROM
+    scheduled_job s
+        INNER JOIN benchmark_run b ON s.run_id = b.id
+        LEFT JOIN job_results r ON s.id = r.id
+WHERE
+    b.experiment_id = $1
+  AND s.status != 'cancelled';
+
+-- name: CreatePayloadVersion :exec
+INSERT INTO payload (
+  id, version, name, content, content_hash, creation_time
+) VALUES (
+  $1, $2, $3, $4, $5, $6
+);
+
+-- name: GetLatestPayloadVersion :one
+SELECT * FROM payload
+WHERE id = $1 AND deleted = false
+ORDER BY version DESC
+LIMIT 1;
+
+-- name: GetPayloadVersion :one
+SELECT * FROM payload
+WHERE id = $1 AND version = $2 AND deleted = false;
+
+-- name: ListPayloads :many
+SELECT p.* FROM payload p
+WHERE p.deleted = false
+  AND p.version = (SELECT MAX(version) FROM payload WHERE id = p.id)
+ORDER BY p.name, p.id;
+
+-- name: ListPayloadVersions :many
+SELECT * FROM payload
+WHERE id = $1 AND deleted = false
+ORDER BY version;
+
+-- name: DeletePayload :execrows
+UPDATE payload
+SET deleted = true
+WHERE id = $1 AND deleted = false;
+
+-- name: GetSchemaVersion :one
+SELECT CAST(COALESCE(MAX(version), 0) AS BIGINT) AS version
+FROM schema_migrations;
+
+-- name: RecordMigration :exec
+INSERT INTO schema_migrations (
+  version, name, applied_at
+) VALUES (
+  $1, $2, $3
+);
+
+-- name: CountScheduledJobs :one
+SELECT COUNT(*) FROM scheduled_job
+WHERE id = $1;
+
+-- name: StoreOrphanCallback :exec
+INSERT INTO orphan_callbacks (
+  job_id, callback, reported_time, received_at
+) VALUES (
+  $1, $2, $3, $4
+);
+
+-- name: ClaimOrphanCallbacks :many
+DELETE FROM orphan_callbacks
+WHERE job_id = $1
+RETURNING *;
+
+-- name: ListOrphanCallbacks :many
+SELECT * FROM orphan_callbacks
+ORDER BY received_at DESC, id DESC
+LIMIT $1;
+
+-- name: ListJobsToPrune :many
+SELECT
+    s.*,
+    r.start_time,
+    r.end_time,
+    r.start_time_callbacks,
+    r.end_time_callbacks,
+    r.invalid
+FROM
+    scheduled_job s
+        LEFT JOIN job_results r ON s.id = r.id
+WHERE
+    (s.creation_time < sqlc.narg(before)::timestamp OR sqlc.narg(before)::timestamp IS NULL)
+  AND (s.executor = sqlc.narg(executor)::text OR sqlc.narg(executor)::text IS NULL)
+  AND (s.run_id = sqlc.narg(run_id)::uuid OR sqlc.narg(run_id)::uuid IS NULL)
+ORDER BY
+    s.creation_time
+LIMIT sqlc.arg(batch_size);
+
+-- name: DeleteJobResult :exec
+DELETE FROM job_results
+WHERE id = $1;
+
+-- name: DeleteScheduledJob :exec
+DELETE FROM scheduled_job
+WHERE id = $1;
+
+-- name: ListRunsToPrune :many
+SELECT * FROM benchmark_run b
+WHERE b.status != 'running'
+  AND NOT EXISTS (SELECT 1 FROM scheduled_job s WHERE s.run_id = b.id)
+  AND (b.creation_time < sqlc.narg(before)::timestamp OR sqlc.narg(before)::timestamp IS NULL)
+  AND (b.executor = sqlc.narg(executor)::text OR sqlc.narg(executor)::text IS NULL)
+  AND (b.id = sqlc.narg(run_id)::uuid OR sqlc.narg(run_id)::uuid IS NULL)
+ORDER BY b.creation_time;
+
+-- name: ListSubmissionAttemptsByRun :many
+SELECT * FROM submission_attempt
+WHERE run_id = $1
+ORDER BY job_index, attempt;
+
+-- name: DeleteSubmissionAttemptsByRun :exec
+DELETE FROM submission_attempt
+WHERE run_id = $1;
+
+-- name: DeleteRun :exec
+DELETE FROM benchmark_run
+WHERE id = $1;
+
+-- name: GetJobCreationTimeAtOffset :one
+SELECT creation_time FROM scheduled_job
+ORDER BY creation_time DESC
+LIMIT 1 OFFSET $1;
+
+-- name: ListRunIDsAfterOffset :many
+SELECT id FROM benchmark_run
+ORDER BY creation_time DESC
+OFFSET $1;
+
+-- name: ListJobsForExport :many
+SELECT
+    s.*,
+    r.start_time,
+    r.end_time,
+    r.start_time_callbacks,
+    r.end_time_callbacks,
+    r.invalid
+FROM
+    scheduled_job s
+        LEFT JOIN job_results r ON s.id = r.id
+ORDER BY
+    s.creation_time, s.id
+LIMIT $1 OFFSET $2;
+
+-- name: ListRunsForExport :many
+SELECT * FROM benchmark_run
+ORDER BY creation_time, id
+LIMIT $1 OFFSET $2;
+
+-- name: CountRuns :one
+SELECT COUNT(*) FROM benchmark_run
+WHERE id = $1;
+
+-- name: ImportScheduledJob :exec
+INSERT INTO scheduled_job (
+  id, creation_time, executor, metadata, commit_hash, status, run_id, payload_id, payload_version, payload_hash
+) VALUES (
+  $1, $2, $3, $4, $5, $6, $7, $8, $9, $10
+);
+
+-- name: ImportRun :exec
+INSERT INTO benchmark_run (
+  id, creation_time, executor, job_count, concurrency, status, error, finish_time, scenario, labels, experiment_id
+) VALUES (
+  $1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11
+);
@@ -1,32 +1,121 @@
 -- name: StoreScheduledJobWithMetadata :one
 INSERT INTO scheduled_job (
-  id, creation_time, executor, metadata, commit_hash
+  id, creation_time, executor, metadata, commit_hash, run_id, payload_id, payload_version, payload_hash
 ) VALUES (
-  ?, ?, ?, ?, ?
+  ?, ?, ?, ?, ?, ?, ?, ?, ?
 )
 RETURNING *;
 
 -- name: StoreScheduledJob :one
 INSERT INTO scheduled_job (
-  id, creation_time, executor, commit_hash
+  id, creation_time, executor, commit_hash, run_id, payload_id, payload_version, payload_hash
 ) VALUES (
-  ?, ?, ?, ?
+  ?, ?, ?, ?, ?, ?, ?, ?
 )
 RETURNING *;
 
--- name: UpsertJobStartTime :one
-INSERT INTO job_results (id, start_time)
-VALUES (?, ?)
-ON CONFLICT (id) DO UPDATE
-  SET start_time = EXCLUDED.start_time
-RETURNING *;
+-- name: GetJobResult :one
+SELECT * FROM job_results
+WHERE id = ?;
 
--- name: UpsertJobEndTime :one
-INSERT INTO job_results (id, end_time)
-VALUES (?, ?)
+-- name: SaveJobResult :exec
+INSERT INTO job_results (
+  id, start_time, end_time, start_time_callbacks, end_time_callbacks, invalid
+) VALUES (
+  ?, ?, ?, ?, ?, ?
+)
 ON CONFLICT (id) DO UPDATE
-  SET end_time = EXCLUDED.end_time
-RETURNING *;
+  SET start_time = EXCLUDED.start_time,
+  end_time = EXCLUDED.end_time,
+  start_time_callbacks = EXCLUDED.start_time_callbacks,
+  end_time_callbacks = EXCLUDED.end_time_callbacks,
+  invalid = EXCLUDED.invalid;
+
+-- name: UpdateJobStatus :exec
+UPDATE scheduled_job
+SET status = ?
+WHERE id = ?;
+
+-- name: CreateRun :exec
+INSERT INTO benchmark_run (
+  id, creation_time, executor, job_count, concurrency, scenario, labels, experiment_id
+) VALUES (
+  ?, ?, ?, ?, ?, ?, ?, ?
+);
+
+-- name: UpdateRunStatus :exec
+UPDATE benchmark_run
+SET status = ?, error = ?, finish_time = ?
+WHERE id = ?;
+
+-- name: GetRun :one
+SELECT * FROM benchmark_run
+WHERE id = ?;
+
+-- name: GetRunJobStats :one
+SELECT
+    COUNT(*) AS scheduled_jobs,
+    CAST(COALESCE(SUM(CASE WHEN s.status != 'cancelled' AND (r.end_time IS NOT NULL OR s.status IN ('succeeded', 'failed')) THEN 1 ELSE 0 END), 0) AS INTEGER) AS finished_jobs,
+    CAST(COALESCE(SUM(CASE WHEN s.status = 'cancelled' THEN 1 ELSE 0 END), 0) AS INTEGER) AS cancelled_jobs,
+    CAST(COALESCE(SUM(
+        CASE WHEN r.start_time_callbacks > 1 THEN r.start_time_callbacks - 1 ELSE 0 END +
+        CASE WHEN r.end_time_callbacks > 1 THEN r.end_time_callbacks - 1 ELSE 0 END
+    ), 0) AS INTEGER) AS duplicate_callbacks,
+    CAST(COALESCE(SUM(CASE WHEN r.invalid THEN 1 ELSE 0 END), 0) AS INTEGER) AS invalid_jobs
+FROM
+    scheduled_job s
+        LEFT JOIN job_results r ON s.id = r.id
+WHERE
+    s.run_id = ?;
+
+-- name: GetPendingJobIDsByRun :many
+SELECT
+    s.id
+FROM
+    scheduled_job s
+        LEFT JOIN job_results r ON s.id = r.id
+WHERE
+    s.run_id = ?
+  AND s.status NOT IN ('succeeded', 'failed', 'cancelled')
+  AND r.end_time IS NULL;
+
+-- name: CancelPendingJobsByRun :execrows
+UPDATE scheduled_job
+SET status = 'cancelled'
+WHERE run_id = ?
+  AND status NOT IN ('succeeded', 'failed', 'cancelled')
+  AND id NOT IN (SELECT id FROM job_results WHERE end_time IS NOT NULL);
+
+-- name: StoreSubmissionAttempt :exec
+INSERT INTO submission_attempt (
+  run_id, job_index, attempt, submit_delay_ms, start_time, duration_ms, status_code, error, job_id
+) VALUES (
+  ?, ?, ?, ?, ?, ?, ?, ?, ?
+);
+
+-- name: GetRunSubmissionStats :one
+SELECT
+    CAST(COALESCE(SUM(j.attempts), 0) AS INTEGER) AS attempts,
+    CAST(COALESCE(SUM(CASE WHEN j.attempts > 1 THEN 1 ELSE 0 END), 0) AS INTEGER) AS retried_jobs,
+    CAST(COALESCE(SUM(CASE WHEN j.submitted = 0 THEN 1 ELSE 0 END), 0) AS INTEGER) AS failed_jobs,
+    CAST(COALESCE(AVG(j.submit_latency_ms), 0) AS INTEGER) AS avg_submit_latency_ms,
+    CAST(COALESCE(MAX(j.submit_latency_ms), 0) AS INTEGER) AS max_submit_latency_ms,
+    CAST(COALESCE(AVG(j.submit_delay_ms), 0) AS INTEGER) AS avg_submit_delay_ms,
+    CAST(COALESCE(MAX(j.submit_delay_ms), 0) AS INTEGER) AS max_submit_delay_ms
+FROM (
+    SELECT
+        job_index,
+        COUNT(*) AS attempts,
+        MAX(CASE WHEN attempt = 1 THEN submit_delay_ms ELSE 0 END) AS submit_delay_ms,
+        SUM(duration_ms) AS submit_latency_ms,
+        MAX(CASE WHEN job_id IS NOT NULL THEN 1 ELSE 0 END) AS submitted
+    FROM
+        submission_attempt
+    WHERE
+        run_id = ?
+    GROUP BY
+        job_index
+) j;
 
 -- name: UpsertJobTimes :one
 INSERT INTO job_results (id, start_time, end_time)
@@ -48,6 +137,12 @@ WHERE
   AND (datetime(r.start_time) <= datetime(:to) OR :to IS NULL)
   AND (s.commit_hash = :commit_hash OR :commit_hash IS NULL)
   AND (s.executor = :executor OR :executor IS NULL)
+  AND (s.payload_hash = :payload_hash OR :payload_hash IS NULL)
+  AND NOT EXISTS (
+      SELECT 1 FROM json_each(:labels) l
+      WHERE (SELECT m.value FROM json_each(s.metadata) m WHERE m.key = l.key) IS NOT l.value
+  )
+  AND s.status != 'cancelled'
 ORDER BY
     queue_latency DESC;
 
@@ -60,10 +155,17 @@ FROM
 WHERE
     r.start_time IS NOT NULL
   AND r.end_time IS NOT NULL
+  AND r.invalid = false
   AND (datetime(r.start_time) >= datetime(:from) OR :from IS NULL)
   AND (datetime(r.end_time) <= datetime(:to) OR :to IS NULL)
   AND (s.commit_hash = :commit_hash OR :commit_hash IS NULL)
   AND (s.executor = :executor OR :executor IS NULL)
+  AND (s.payload_hash = :payload_hash OR :payload_hash IS NULL)
+  AND NOT EXISTS (
+      SELECT 1 FROM json_each(:labels) l
+      WHERE (SELECT m.value FROM json_each(s.metadata) m WHERE m.key = l.key) IS NOT l.value
+  )
+  AND s.status != 'cancelled'
 ORDER BY
     build_time DESC;
 
@@ -80,6 +182,12 @@ WHERE
   AND (datetime(r.end_time) <= datetime(:to) OR :to IS NULL)
   AND (s.commit_hash = :commit_hash OR :commit_hash IS NULL)
   AND (s.executor = :executor OR :executor IS NULL)
+  AND (s.payload_hash = :payload_hash OR :payload_hash IS NULL)
+  AND NOT EXISTS (
+      SELECT 1 FROM json_each(:labels) l
+      WHERE (SELECT m.value FROM json_each(s.metadata) m WHERE m.key = l.key) IS NOT l.value
+  )
+  AND s.status != 'cancelled'
 ORDER BY
     total_latency DESC;
 
@@ -95,6 +203,12 @@ WHERE
   AND (datetime(r.start_time) <= datetime(:to) OR :to IS NULL)
   AND (s.commit_hash = :commit_hash OR :commit_hash IS NULL)
   AND (s.executor = :executor OR :executor IS NULL)
+  AND (s.payload_hash = :payload_hash OR :payload_hash IS NULL)
+  AND NOT EXISTS (
+      SELECT 1 FROM json_each(:labels) l
+      WHERE (SELECT m.value FROM json_each(s.metadata) m WHERE m.key = l.key) IS NOT l.value
+  )
+  AND s.status != 'cancelled'
 ORDER BY
     latency ASC;
 
@@ -107,10 +221,17 @@ FROM
 WHERE
     r.start_time IS NOT NULL
   AND r.end_time IS NOT NULL
+  AND r.invalid = false
   AND (datetime(r.start_time) >= datetime(:from) OR :from IS NULL)
   AND (datetime(r.end_time) <= datetime(:to) OR :to IS NULL)
   AND (s.commit_hash = :commit_hash OR :commit_hash IS NULL)
   AND (s.executor = :executor OR :executor IS NULL)
+  AND (s.payload_hash = :payload_hash OR :payload_hash IS NULL)
+  AND NOT EXISTS (
+      SELECT 1 FROM json_each(:labels) l
+      WHERE (SELECT m.value FROM json_each(s.metadata) m WHERE m.key = l.key) IS NOT l.value
+  )
+  AND s.status != 'cancelled'
 ORDER BY
     build_time ASC;
 
@@ -127,5 +248,310 @@ WHERE
   AND (datetime(r.end_time) <= datetime(:to) OR :to IS NULL)
   AND (s.commit_hash = :commit_hash OR :commit_hash IS NULL)
   AND (s.executor = :executor OR :executor IS NULL)
+  AND (s.payload_hash = :payload_hash OR :payload_hash IS NULL)
+  AND NOT EXISTS (
+      SELECT 1 FROM json_each(:labels) l
+      WHERE (SELECT m.value FROM json_each(s.metadata) m WHERE m.key = l.key) IS NOT l.value
+  )
+  AND s.status != 'cancelled'
+ORDER BY
+    total_latency ASC;
+
+-- name: GetQueueLatencySummaryByGroup :many
+SELECT group_key, latency
+FROM (
+    SELECT
+        CAST(CASE :group_by
+            WHEN 'executor' THEN s.executor
+            WHEN 'commit_hash' THEN s.commit_hash
+            WHEN 'run' THEN s.run_id
+            WHEN 'hour' THEN strftime('%Y-%m-%dT%H:00:00Z', r.start_time)
+            WHEN 'day' THEN strftime('%Y-%m-%d', r.start_time)
+            ELSE (SELECT m.value FROM json_each(s.metadata) m WHERE m.key = :group_label)
+        END AS TEXT) AS group_key,
+        CAST((strftime('%s', r.start_time) - strftime('%s', s.creation_time)) AS INTEGER) AS latency
+    FROM
+        scheduled_job s
+            INNER JOIN job_results r ON s.id = r.id
+    WHERE
+        r.start_time IS NOT NULL
+      AND (datetime(r.start_time) >= datetime(:from) OR :from IS NULL)
+      AND (datetime(r.start_time) <= datetime(:to) OR :to IS NULL)
+      AND (s.commit_hash = :commit_hash OR :commit_hash IS NULL)
+      AND (s.executor = sqlc.narg(executor) OR sqlc.narg(executor) IS NULL)
+      AND (s.payload_hash = :payload_hash OR :payload_hash IS NULL)
+      AND NOT EXISTS (
+          SELECT 1 FROM json_each(:labels) l
+          WHERE (SELECT m.value FROM json_each(s.metadata) m WHERE m.key = l.key) IS NOT l.value
+      )
+      AND s.status != 'cancelled'
+) grouped
+WHERE group_key IS NOT NULL
+ORDER BY
+    group_key, latency ASC;
+
+-- name: GetBuildTimeSummaryByGroup :many
+SELECT group_key, build_time
+FROM (
+    SELECT
+        CAST(CASE :group_by
+            WHEN 'executor' THEN s.executor
+            WHEN 'commit_hash' THEN s.commit_hash
+            WHEN 'run' THEN s.run_id
+            WHEN 'hour' THEN strftime('%Y-%m-%dT%H:00:00Z', r.start_time)
+            WHEN 'day' THEN strftime('%Y-%m-%d', r.start_time)
+            ELSE (SELECT m.value FROM json_each(s.metadata) m WHERE m.key = :group_label)
+        END AS TEXT) AS group_key,
+        CAST((strftime('%s', r.end_time) - strftime('%s', r.start_time)) AS INTEGER) AS build_time
+    FROM
+        job_results r
+            INNER JOIN scheduled_job s ON r.id = s.id
+    WHERE
+        r.start_time IS NOT NULL
+      AND r.end_time IS NOT NULL
+      AND r.invalid = false
+      AND (datetime(r.start_time) >= datetime(:from) OR :from IS NULL)
+      AND (datetime(r.end_time) <= datetime(:to) OR :to IS NULL)
+      AND (s.commit_hash = :commit_hash OR :commit_hash IS NULL)
+      AND (s.executor = sqlc.narg(executor) OR sqlc.narg(executor) IS NULL)
+      AND (s.payload_hash = :payload_hash OR :payload_hash IS NULL)
+      AND NOT EXISTS (
+          SELECT 1 FROM json_each(:labels) l
+          WHERE (SELECT m.value FROM json_each(s.metadata) m WHERE m.key = l.key) IS NOT l.value
+      )
+      AND s.status != 'cancelled'
+) grouped
+WHERE group_key IS NOT NULL
+ORDER BY
+    group_key, build_time ASC;
+
+-- name: GetTotalLatenciesSummaryByGroup :many
+SELECT group_key, total_latency
+FROM (
+    SELECT
+        CAST(CASE :group_by
+            WHEN 'executor' THEN s.executor
+            WHEN 'commit_hash' THEN s.commit_hash
+            WHEN 'run' THEN s.run_id
+            WHEN 'hour' THEN strftime('%Y-%m-%dT%H:00:00Z', r.start_time)
+            WHEN 'day' THEN strftime('%Y-%m-%d', r.start_time)
+            ELSE (SELECT m.value FROM json_each(s.metadata) m WHERE m.key = :group_label)
+        END AS TEXT) AS group_key,
+        CAST((strftime('%s', r.end_time) - strftime('%s', s.creation_time)) AS INTEGER) AS total_latency
+    FROM
+        scheduled_job s
+            INNER JOIN job_results r ON s.id = r.id
+    WHERE
+        r.start_time IS NOT NULL
+      AND r.end_time IS NOT NULL
+      AND (datetime(r.start_time) >= datetime(:from) OR :from IS NULL)
+      AND (datetime(r.end_time) <= datetime(:to) OR :to IS NULL)
+      AND (s.commit_hash = :commit_hash OR :commit_hash IS NULL)
+      AND (s.executor = sqlc.narg(executor) OR sqlc.narg(executor) IS NULL)
+      AND (s.payload_hash = :payload_hash OR :payload_hash IS NULL)
+      AND NOT EXISTS (
+          SELECT 1 FROM json_each(:labels) l
+          WHERE (SELECT m.value FROM json_each(s.metadata) m WHERE m.key = l.key) IS NOT l.value
+      )
+      AND s.status != 'cancelled'
+) grouped
+WHERE group_key IS NOT NULL
+ORDER BY
+    group_key, total_latency ASC;
+
+-- name: CreateExperiment :exec
+INSERT INTO experiment (
+  id, creation_time, scenario, run_order, seed, run_count
+) VALUES (
+  ?, ?, ?, ?, ?, ?
+);
+
+-- name: UpdateExperimentStatus :exec
+UPDATE experiment
+SET status = ?, error = ?, finish_time = ?
+WHERE id = ?;
+
+-- name: GetExperiment :one
+SELECT * FROM experiment
+WHERE id = ?;
+
+-- name: GetRunsByExperiment :many
+SELECT * FROM benchmark_run
+WHERE experiment_id = ?
+ORDER BY creation_time;
+
+-- name: GetJobTimesByExperiment :many
+SELECT
+    s.run_id,
+    s.creation_time,
+    r.start_time,
+    r.end_time
+FROM
+    scheduled_job s
+        INNER JOIN benchmark_run b ON s.run_id = b.id
+        LEFT JOIN job_results r ON s.id = r.id
+WHERE
+    b.experiment_id = ?
+  AND s.status != 'cancelled';
+
+-- name: CreatePayloadVersion :exec
+INSERT INTO payload (
+  id, version, name, content, content_hash, creation_time
+) VALUES (
+  ?, ?, ?, ?, ?, ?
+);
+
+-- name: GetLatestPayloadVersion :one
+SELECT * FROM payload
+WHERE id = ? AND deleted = false
+ORDER BY version DESC
+LIMIT 1;
+
+-- name: GetPayloadVersion :one
+SELECT * FROM payload
+WHERE id = ? AND version = ? AND deleted = false;
+
+-- name: ListPayloads :many
+SELECT p.* FROM payload p
+WHERE p.deleted = false
+  AND p.version = (SELECT MAX(version) FROM payload WHERE id = p.id)
+ORDER BY p.name, p.id;
+
+-- name: ListPayloadVersions :many
+SELECT * FROM payload
+WHERE id = ? AND deleted = false
+ORDER BY version;
+
+-- name: DeletePayload :execrows
+UPDATE payload
+SET deleted = true
+WHERE id = ? AND deleted = false;
+
+-- name: GetSchemaVersion :one
+SELECT CAST(COALESCE(MAX(version), 0) AS INTEGER) AS version
+FROM schema_migrations;
+
+-- name: RecordMigration :exec
+INSERT INTO schema_migrations (
+  version, name, applied_at
+) VALUES (
+  ?, ?, ?
+);
+
+-- name: CountScheduledJobs :one
+SELECT COUNT(*) FROM scheduled_job
+WHERE id = ?;
+
+-- name: StoreOrphanCallback :exec
+INSERT INTO orphan_callbacks (
+  job_id, callback, reported_time, received_at
+) VALUES (
+  ?, ?, ?, ?
+);
+
+-- name: ClaimOrphanCallbacks :many
+DELETE FROM orphan_callbacks
+WHERE job_id = ?
+RETURNING *;
+
+-- name: ListOrphanCallbacks :many
+SELECT * FROM orphan_callbacks
+ORDER BY received_at DESC, id DESC
+LIMIT ?;
+
+-- name: ListJobsToPrune :many
+SELECT
+    s.*,
+    r.start_time,
+    r.end_time,
+    r.start_time_callbacks,
+    r.end_time_callbacks,
+    r.invalid
+FROM
+    scheduled_job s
+        LEFT JOIN job_results r ON s.id = r.id
+WHERE
+    (julianday(s.creation_time) < julianday(:before) OR :before IS NULL)
+  AND (s.executor = sqlc.narg(executor) OR sqlc.narg(executor) IS NULL)
+  AND (s.run_id = sqlc.narg(run_id) OR sqlc.narg(run_id) IS NULL)
 ORDER BY
-    total_latency ASC;
\ No newline at end of file
+    s.creation_time
+LIMIT :batch_size;
+
+-- name: DeleteJobResult :exec
+DELETE FROM job_results
+WHERE id = ?;
+
+-- name: DeleteScheduledJob :exec
+DELETE FROM scheduled_job
+WHERE id = ?;
+
+-- name: ListRunsToPrune :many
+SELECT * FROM benchmark_run b
+WHERE b.status != 'running'
+  AND NOT EXISTS (SELECT 1 FROM scheduled_job s WHERE s.run_id = b.id)
+  AND (julianday(b.creation_time) < julianday(:before) OR :before IS NULL)
+  AND (b.executor = sqlc.narg(executor) OR sqlc.narg(executor) IS NULL)
+  AND (b.id = sqlc.narg(run_id) OR sqlc.narg(run_id) IS NULL)
+ORDER BY b.creation_time;
+
+-- name: ListSubmissionAttemptsByRun :many
+SELECT * FROM submission_attempt
+WHERE run_id = ?
+ORDER BY job_index, attempt;
+
+-- name: DeleteSubmissionAttemptsByRun :exec
+DELETE FROM submission_attempt
+WHERE run_id = ?;
+
+-- name: DeleteRun :exec
+DELETE FROM benchmark_run
+WHERE id = ?;
+
+-- name: GetJobCreationTimeAtOffset :one
+SELECT creation_time FROM scheduled_job
+ORDER BY creation_time DESC
+LIMIT 1 OFFSET ?;
+
+-- name: ListRunIDsAfterOffset :many
+SELECT id FROM benchmark_run
+ORDER BY creation_time DESC
+LIMIT -1 OFFSET ?;
+
+-- name: ListJobsForExport :many
+SELECT
+    s.*,
+    r.start_time,
+    r.end_time,
+    r.start_time_callbacks,
+    r.end_time_callbacks,
+    r.invalid
+FROM
+    scheduled_job s
+        LEFT JOIN job_results r ON s.id = r.id
+ORDER BY
+    s.creation_time, s.id
+LIMIT ? OFFSET ?;
+
+-- name: ListRunsForExport :many
+SELECT * FROM benchmark_run
+ORDER BY creation_time, id
+LIMIT ? OFFSET ?;
+
+-- name: CountRuns :one
+SELECT COUNT(*) FROM benchmark_run
+WHERE id = ?;
+
+-- name: ImportScheduledJob :exec
+INSERT INTO scheduled_job (
+  id, creation_time, executor, metadata, commit_hash, status, run_id, payload_id, payload_version, payload_hash
+) VALUES (
+  ?, ?, ?, ?, ?, ?, ?, ?, ?, ?
+);
+
+-- name: ImportRun :exec
+INSERT INTO benchmark_run (
+  id, creation_time, executor, job_count, concurrency, status, error, finish_time, scenario, labels, experiment_id
+) VALUES (
+  ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?
+);
@@ -0,0 +1,337 @@
+package persister
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/Mtze/CI-Benchmarker/persister/model"
+	"github.com/google/uuid"
+)
+
+// transferBatchSize is the number of records exported or imported at once
+const transferBatchSize = 500
+
+// OriginLabel is the label imported jobs and runs are marked with, it names the benchmarker instance they come from
+const OriginLabel = "origin"
+
+// ErrInvalidImport is returned by Import for records which are not valid JSON or miss required fields
+var ErrInvalidImport = errors.New("invalid import")
+
+// ConflictPolicy decides what happens to an imported job or run whose ID is already stored
+type ConflictPolicy string
+
+const (
+	// ConflictSkip keeps the stored job or run, which makes importing the same records twice harmless
+	ConflictSkip ConflictPolicy = "skip"
+	// ConflictReplace replaces the stored job with its times, or the stored run with its submission attempts
+	ConflictReplace ConflictPolicy = "replace"
+)
+
+// ParseConflictPolicy parses "skip" or "replace"
+func ParseConflictPolicy(policy string) (ConflictPolicy, error) {
+	switch p := ConflictPolicy(policy); p {
+	case ConflictSkip, ConflictReplace:
+		return p, nil
+	}
+	return "", fmt.Errorf("unknown conflict policy %q, expected skip or replace", policy)
+}
+
+// ImportOptions configure an import. Origin is added as OriginLabel to the jobs and runs which are not
+// labelled with an origin yet, an empty Origin leaves their labels as they are.
+type ImportOptions struct {
+	Origin     string
+	OnConflict ConflictPolicy
+}
+
+// ImportResult counts the imported jobs and runs, the replaced ones are counted as imported as well
+type ImportResult struct {
+	Jobs         int
+	ReplacedJobs int
+	SkippedJobs  int
+	Runs         int
+	ReplacedRuns int
+	SkippedRuns  int
+}
+
+// Export writes all jobs with their times and all runs with their submission attempts to archive,
+// in the format of the archives of pruned jobs
+func (d DBPersister) Export(ctx context.Context, archive Archive) error {
+	for offset := int64(0); ; offset += transferBatchSize {
+		jobs, err := d.queries.ListJobsForExport(ctx, model.ListJobsForExportParams{Limit: transferBatchSize, Offset: offset})
+		if err != nil {
+			return err
+		}
+		for _, job := range jobs {
+			record, err := archivedJob(model.ListJobsToPruneRow(job))
+			if err != nil {
+				return err
+			}
+			if err := archive.Write(ArchiveRecord{Job: &record}); err != nil {
+				return fmt.Errorf("failed to export job %s: %w", job.ID, err)
+			}
+		}
+		if err := archive.Flush(); err != nil {
+			return err
+		}
+		if len(jobs) < transferBatchSize {
+			break
+		}
+	}
+
+	for offset := int64(0); ; offset += transferBatchSize {
+		runs, err := d.queries.ListRunsForExport(ctx, model.ListRunsForExportParams{Limit: transferBatchSize, Offset: offset})
+		if err != nil {
+			return err
+		}
+		for _, run := range runs {
+			record, err := d.archivedRun(ctx, run)
+			if err != nil {
+				return err
+			}
+			if err := archive.Write(ArchiveRecord{Run: &record}); err != nil {
+				return fmt.Errorf("failed to export run %s: %w", run.ID, err)
+			}
+		}
+		if err := archive.Flush(); err != nil {
+			return err
+		}
+		if len(runs) < transferBatchSize {
+			return nil
+		}
+	}
+}
+
+// Import reads JSONL records in the format of Export and stores their jobs and runs. The records are
+// imported in batches, the batches before an invalid record are kept.
+func (d DBPersister) Import(ctx context.Context, r io.Reader, options ImportOptions) (ImportResult, error) {
+	var result ImportResult
+	decoder := json.NewDecoder(r)
+	batch := make([]ArchiveRecord, 0, transferBatchSize)
+
+	for n := 1; ; n++ {
+		var record ArchiveRecord
+		err := decoder.Decode(&record)
+		if err == nil {
+			err = validateRecord(record)
+		}
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			if flushErr := d.importBatch(batch, options, &result); flushErr != nil {
+				return result, flushErr
+			}
+			return result, fmt.Errorf("%w: record %d: %v", ErrInvalidImport, n, err)
+		}
+
+		batch = append(batch, record)
+		if len(batch) == transferBatchSize {
+			if err := d.importBatch(batch, options, &result); err != nil {
+				return result, err
+			}
+			batch = batch[:0]
+		}
+	}
+	return result, d.importBatch(batch, options, &result)
+}
+
+func validateRecord(record ArchiveRecord) error {
+	switch {
+	case (record.Job == nil) == (record.Run == nil):
+		return errors.New("expected either a job or a run")
+	case record.Job != nil && (record.Job.ID == uuid.Nil || record.Job.Executor == "" || record.Job.CreationTime.IsZero()):
+		return errors.New("job without id, executor or creation_time")
+	case record.Run != nil && (record.Run.ID == uuid.Nil || record.Run.Executor == "" || record.Run.CreationTime.IsZero()):
+		return errors.New("run without id, executor or creation_time")
+	}
+	return nil
+}
+
+// importBatch stores the jobs and runs of a batch of records in one transaction, result is only updated
+// once the transaction is committed
+func (d DBPersister) importBatch(batch []ArchiveRecord, options ImportOptions, result *ImportResult) error {
+	if len(batch) == 0 {
+		return nil
+	}
+
+	var batchResult ImportResult
+	if err := d.inTx(func(ctx context.Context, queries model.Querier) error {
+		batchResult = ImportResult{}
+		for _, record := range batch {
+			var err error
+			if record.Job != nil {
+				err = importJob(ctx, queries, *record.Job, options, &batchResult)
+			} else {
+				err = importRun(ctx, queries, *record.Run, options, &batchResult)
+			}
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	}); err != nil {
+		return fmt.Errorf("failed to import records: %w", err)
+	}
+
+	result.Jobs += batchResult.Jobs
+	result.ReplacedJobs += batchResult.ReplacedJobs
+	result.SkippedJobs += batchResult.SkippedJobs
+	result.Runs += batchResult.Runs
+	result.ReplacedRuns += batchResult.ReplacedRuns
+	result.SkippedRuns += batchResult.SkippedRuns
+	return nil
+}
+
+func importJob(ctx context.Context, queries model.Querier, job ArchivedJob, options ImportOptions, result *ImportResult) error {
+	count, err := queries.CountScheduledJobs(ctx, job.ID)
+	if err != nil {
+		return err
+	}
+	if count > 0 {
+		if options.OnConflict != ConflictReplace {
+			result.SkippedJobs++
+			return nil
+		}
+		if err := queries.DeleteJobResult(ctx, job.ID); err != nil {
+			return err
+		}
+		if err := queries.DeleteScheduledJob(ctx, job.ID); err != nil {
+			return err
+		}
+		result.ReplacedJobs++
+	}
+
+	labels, err := originLabels(job.Labels, options.Origin)
+	if err != nil {
+		return err
+	}
+	if err := queries.ImportScheduledJob(ctx, model.ImportScheduledJobParams{
+		ID:             job.ID,
+		CreationTime:   job.CreationTime.UTC(),
+		Executor:       job.Executor,
+		Metadata:       labels,
+		CommitHash:     nullableString(job.CommitHash),
+		Status:         job.Status,
+		RunID:          nullableUUID(job.RunID),
+		PayloadID:      nullableUUID(job.PayloadID),
+		PayloadVersion: nullableInt64(job.PayloadVersion),
+		PayloadHash:    nullableString(job.PayloadHash),
+	}); err != nil {
+		return fmt.Errorf("failed to import job %s: %w", job.ID, err)
+	}
+
+	if job.StartTime != nil || job.EndTime != nil {
+		if err := queries.SaveJobResult(ctx, model.SaveJobResultParams{
+			ID:                 job.ID,
+			StartTime:          nullableTime(job.StartTime),
+			EndTime:            nullableTime(job.EndTime),
+			StartTimeCallbacks: job.StartTimeCallbacks,
+			EndTimeCallbacks:   job.EndTimeCallbacks,
+			Invalid:            job.Invalid,
+		}); err != nil {
+			return fmt.Errorf("failed to import times of job %s: %w", job.ID, err)
+		}
+	}
+	result.Jobs++
+	return nil
+}
+
+func importRun(ctx context.Context, queries model.Querier, run ArchivedRun, options ImportOptions, result *ImportResult) error {
+	count, err := queries.CountRuns(ctx, run.ID)
+	if err != nil {
+		return err
+	}
+	if count > 0 {
+		if options.OnConflict != ConflictReplace {
+			result.SkippedRuns++
+			return nil
+		}
+		if err := queries.DeleteSubmissionAttemptsByRun(ctx, run.ID); err != nil {
+			return err
+		}
+		if err := queries.DeleteRun(ctx, run.ID); err != nil {
+			return err
+		}
+		result.ReplacedRuns++
+	}
+
+	labels, err := originLabels(run.Labels, options.Origin)
+	if err != nil {
+		return err
+	}
+	if err := queries.ImportRun(ctx, model.ImportRunParams{
+		ID:           run.ID,
+		CreationTime: run.CreationTime.UTC(),
+		Executor:     run.Executor,
+		JobCount:     run.JobCount,
+		Concurrency:  run.Concurrency,
+		Status:       run.Status,
+		Error:        nullableString(run.Error),
+		FinishTime:   nullableTime(run.FinishTime),
+		Scenario:     nullableString(run.Scenario),
+		Labels:       labels,
+		ExperimentID: nullableUUID(run.ExperimentID),
+	}); err != nil {
+		return fmt.Errorf("failed to import run %s: %w", run.ID, err)
+	}
+
+	for _, attempt := range run.Attempts {
+		if err := queries.StoreSubmissionAttempt(ctx, model.StoreSubmissionAttemptParams{
+			RunID:         run.ID,
+			JobIndex:      attempt.JobIndex,
+			Attempt:       attempt.Attempt,
+			SubmitDelayMs: attempt.SubmitDelayMs,
+			StartTime:     attempt.StartTime.UTC(),
+			DurationMs:    attempt.DurationMs,
+			StatusCode:    nullableInt64(attempt.StatusCode),
+			Error:         nullableString(attempt.Error),
+			JobID:         nullableUUID(attempt.JobID),
+		}); err != nil {
+			return fmt.Errorf("failed to import submission attempt of run %s: %w", run.ID, err)
+		}
+	}
+	result.Runs++
+	return nil
+}
+
+// originLabels encodes labels for storing them, with origin added unless they name an origin already
+func originLabels(labels map[string]string, origin string) (sql.NullString, error) {
+	if origin != "" {
+		if _, ok := labels[OriginLabel]; !ok {
+			withOrigin := make(map[string]string, len(labels)+1)
+			for key, value := range labels {
+				withOrigin[key] = value
+			}
+			withOrigin[OriginLabel] = origin
+			labels = withOrigin
+		}
+	}
+	if len(labels) == 0 {
+		return sql.NullString{Valid: false}, nil
+	}
+
+	data, err := json.Marshal(labels)
+	if err != nil {
+		return sql.NullString{}, err
+	}
+	return sql.NullString{String: string(data), Valid: true}, nil
+}
+
+func nullableInt64(i *int64) sql.NullInt64 {
+	if i == nil {
+		return sql.NullInt64{Valid: false}
+	}
+	return sql.NullInt64{Int64: *i, Valid: true}
+}
+
+func nullableTime(t *time.Time) sql.NullTime {
+	if t == nil {
+		return sql.NullTime{Valid: false}
+	}
+	return sql.NullTime{Time: t.UTC(), Valid: true}
+}
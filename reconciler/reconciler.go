@@ -0,0 +1,175 @@
+package reconciler
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/Mtze/CI-Benchmarker/executor"
+	"github.com/Mtze/CI-Benchmarker/persister"
+	"github.com/google/uuid"
+)
+
+// Reconciler polls the CI system for the state of in-flight jobs until they reach a terminal state.
+// The observed start and end times are stored with the persister, so metrics are available even for
+// pipelines which do not contain the start time and result reporter steps.
+type Reconciler struct {
+	persister  persister.Persister
+	interval   time.Duration
+	jobTimeout time.Duration
+	// concurrency bounds the jobs polled at the same time, so a large run does not flood the CI system
+	concurrency int
+
+	mu   sync.Mutex
+	jobs map[uuid.UUID]*trackedJob
+}
+
+type trackedJob struct {
+	reporter  executor.StatusReporter
+	state     executor.JobState
+	trackedAt time.Time
+}
+
+// defaultConcurrency is the number of jobs polled at the same time
+const defaultConcurrency = 10
+
+// NewReconciler creates a reconciler which polls all tracked jobs every interval.
+// Jobs which do not reach a terminal state within jobTimeout are given up on.
+func NewReconciler(p persister.Persister, interval time.Duration, jobTimeout time.Duration) *Reconciler {
+	slog.Info("Creating new Reconciler", slog.Duration("interval", interval), slog.Duration("jobTimeout", jobTimeout))
+	return &Reconciler{
+		persister:   p,
+		interval:    interval,
+		jobTimeout:  jobTimeout,
+		concurrency: defaultConcurrency,
+		jobs:        make(map[uuid.UUID]*trackedJob),
+	}
+}
+
+// Track registers a job to be polled via the given reporter until it is terminal
+func (r *Reconciler) Track(jobID uuid.UUID, reporter executor.StatusReporter) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.jobs[jobID] = &trackedJob{
+		reporter:  reporter,
+		state:     executor.JobStateQueued,
+		trackedAt: time.Now(),
+	}
+}
+
+// InFlight returns the number of jobs which are currently tracked
+func (r *Reconciler) InFlight() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return len(r.jobs)
+}
+
+// Run polls the tracked jobs until the context is cancelled
+func (r *Reconciler) Run(ctx context.Context) {
+	slog.Info("Starting Reconciler")
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			slog.Info("Stopping Reconciler", slog.Int("inFlight", r.InFlight()))
+			return
+		case <-ticker.C:
+			r.poll(ctx)
+		}
+	}
+}
+
+func (r *Reconciler) poll(ctx context.Context) {
+	r.mu.Lock()
+	snapshot := make(map[uuid.UUID]*trackedJob, len(r.jobs))
+	for id, job := range r.jobs {
+		snapshot[id] = job
+	}
+	r.mu.Unlock()
+
+	if len(snapshot) == 0 {
+		return
+	}
+	slog.Debug("Polling in-flight jobs", slog.Int("count", len(snapshot)))
+
+	// The jobs are polled in parallel, the next tick waits until all of them are done
+	var wg sync.WaitGroup
+	slots := make(chan struct{}, max(r.concurrency, 1))
+	for id, job := range snapshot {
+		if ctx.Err() != nil {
+			break
+		}
+		slots <- struct{}{}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer func() { <-slots }()
+			r.pollJob(ctx, id, job)
+		}()
+	}
+	wg.Wait()
+}
+
+// pollJob queries the state of a single job and stores it
+func (r *Reconciler) pollJob(ctx context.Context, id uuid.UUID, job *trackedJob) {
+	state, timestamps, err := job.reporter.Status(ctx, id)
+	if !r.isTracked(id) {
+		// The job was untracked while it was polled, e.g. because it was cancelled
+		return
+	}
+	if errors.Is(err, executor.ErrJobNotFound) {
+		// Polling again does not help, the job would only be given up on after the job timeout
+		slog.Warn("CI system does not know the job, stop polling it", slog.Any("uuid", id), slog.Any("error", err))
+		if err := r.persister.StoreJobStatus(id, string(executor.JobStateUnknown)); err != nil {
+			slog.Error("Failed to store job status", slog.Any("uuid", id), slog.Any("error", err))
+		}
+		r.Untrack(id)
+		return
+	}
+	if err != nil {
+		slog.Debug("Failed to poll job status", slog.Any("uuid", id), slog.Any("error", err))
+	} else {
+		if err := r.persister.StoreObservedTimes(id, timestamps.StartTime, timestamps.EndTime); err != nil {
+			slog.Error("Failed to store observed job times", slog.Any("uuid", id), slog.Any("error", err))
+		}
+		if state != job.state {
+			slog.Debug("Job changed state", slog.Any("uuid", id), slog.String("from", string(job.state)), slog.String("to", string(state)))
+			if err := r.persister.StoreJobStatus(id, string(state)); err != nil {
+				// The state is stored with the next poll
+				slog.Error("Failed to store job status", slog.Any("uuid", id), slog.Any("error", err))
+			} else {
+				job.state = state
+			}
+		}
+	}
+
+	if job.state.IsTerminal() {
+		r.Untrack(id)
+		return
+	}
+	if time.Since(job.trackedAt) > r.jobTimeout {
+		slog.Warn("Job did not reach a terminal state in time, giving up", slog.Any("uuid", id), slog.String("state", string(job.state)))
+		if err := r.persister.StoreJobStatus(id, string(executor.JobStateUnknown)); err != nil {
+			slog.Error("Failed to store job status", slog.Any("uuid", id), slog.Any("error", err))
+		}
+		r.Untrack(id)
+	}
+}
+
+func (r *Reconciler) isTracked(jobID uuid.UUID) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	_, ok := r.jobs[jobID]
+	return ok
+}
+
+// Untrack stops polling a job, e.g. because it was cancelled
+func (r *Reconciler) Untrack(jobID uuid.UUID) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.jobs, jobID)
+}
@@ -0,0 +1,156 @@
+package reconciler
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Mtze/CI-Benchmarker/executor"
+	"github.com/Mtze/CI-Benchmarker/persister"
+	"github.com/google/uuid"
+)
+
+// fakePersister records the statuses and times stored by the reconciler
+type fakePersister struct {
+	persister.Persister
+
+	mu       sync.Mutex
+	statuses map[uuid.UUID]string
+	ends     map[uuid.UUID]time.Time
+}
+
+func newFakePersister() *fakePersister {
+	return &fakePersister{statuses: make(map[uuid.UUID]string), ends: make(map[uuid.UUID]time.Time)}
+}
+
+func (p *fakePersister) StoreJobStatus(id uuid.UUID, status string) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.statuses[id] = status
+	return nil
+}
+
+func (p *fakePersister) StoreObservedTimes(id uuid.UUID, startTime *time.Time, endTime *time.Time) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if endTime != nil {
+		p.ends[id] = *endTime
+	}
+	return nil
+}
+
+func (p *fakePersister) status(id uuid.UUID) string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.statuses[id]
+}
+
+// fakeReporter answers every status request with state and err, optionally after delay
+type fakeReporter struct {
+	state executor.JobState
+	end   *time.Time
+	err   error
+	delay time.Duration
+
+	mu        sync.Mutex
+	polls     int
+	active    int
+	maxActive int
+}
+
+func (f *fakeReporter) Status(ctx context.Context, jobID uuid.UUID) (executor.JobState, executor.JobTimestamps, error) {
+	f.mu.Lock()
+	f.polls++
+	f.active++
+	f.maxActive = max(f.maxActive, f.active)
+	f.mu.Unlock()
+
+	time.Sleep(f.delay)
+
+	f.mu.Lock()
+	f.active--
+	f.mu.Unlock()
+	return f.state, executor.JobTimestamps{EndTime: f.end}, f.err
+}
+
+func TestPoll(t *testing.T) {
+	end := time.Date(2025, 2, 4, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name     string
+		reporter *fakeReporter
+		timeout  time.Duration
+		status   string
+		tracked  bool
+	}{
+		{"running", &fakeReporter{state: executor.JobStateRunning}, time.Hour, "running", true},
+		{"finished", &fakeReporter{state: executor.JobStateSucceeded, end: &end}, time.Hour, "succeeded", false},
+		{"unknown to the CI system", &fakeReporter{err: executor.ErrJobNotFound}, time.Hour, "unknown", false},
+		// Other errors are retried with the next poll
+		{"failed request", &fakeReporter{err: errors.New("connection refused")}, time.Hour, "", true},
+		{"timed out", &fakeReporter{state: executor.JobStateRunning}, -time.Second, "unknown", false},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			p := newFakePersister()
+			r := NewReconciler(p, time.Hour, test.timeout)
+			id := uuid.New()
+			r.Track(id, test.reporter)
+
+			r.poll(context.Background())
+
+			if status := p.status(id); status != test.status {
+				t.Errorf("expected status %q, got %q", test.status, status)
+			}
+			if tracked := r.isTracked(id); tracked != test.tracked {
+				t.Errorf("expected tracked to be %t, got %t", test.tracked, tracked)
+			}
+			if test.reporter.end != nil && !p.ends[id].Equal(end) {
+				t.Errorf("expected end time %s, got %s", end, p.ends[id])
+			}
+		})
+	}
+}
+
+func TestPollConcurrency(t *testing.T) {
+	p := newFakePersister()
+	r := NewReconciler(p, time.Hour, time.Hour)
+	r.concurrency = 3
+	reporter := &fakeReporter{state: executor.JobStateRunning, delay: 10 * time.Millisecond}
+	for range 20 {
+		r.Track(uuid.New(), reporter)
+	}
+
+	r.poll(context.Background())
+
+	if reporter.polls != 20 {
+		t.Fatalf("expected 20 polls, got %d", reporter.polls)
+	}
+	if reporter.maxActive > 3 {
+		t.Fatalf("expected at most 3 concurrent polls, got %d", reporter.maxActive)
+	}
+	if reporter.maxActive < 2 {
+		t.Fatalf("expected the jobs to be polled in parallel, got %d concurrent polls", reporter.maxActive)
+	}
+}
+
+func TestPollCancelled(t *testing.T) {
+	p := newFakePersister()
+	r := NewReconciler(p, time.Hour, time.Hour)
+	reporter := &fakeReporter{state: executor.JobStateRunning}
+	for range 5 {
+		r.Track(uuid.New(), reporter)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	r.poll(ctx)
+
+	if reporter.polls != 0 {
+		t.Fatalf("expected no polls after the context is cancelled, got %d", reporter.polls)
+	}
+	if r.InFlight() != 5 {
+		t.Fatalf("expected the jobs to stay tracked, got %d", r.InFlight())
+	}
+}
@@ -0,0 +1,257 @@
+package report
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	htmltemplate "html/template"
+	"io"
+	"strconv"
+	"text/template"
+	"time"
+
+	"github.com/Mtze/CI-Benchmarker/MetricsController"
+	"github.com/Mtze/CI-Benchmarker/benchmarkController"
+)
+
+// Metrics lists the metrics in the order they are reported
+var Metrics = []string{QueueLatency, BuildTime, TotalLatency}
+
+// Report bundles the results of a run or an experiment and the outcome of their SLOs
+type Report struct {
+	Title       string                                      `json:"title"`
+	GeneratedAt time.Time                                   `json:"generated_at"`
+	Run         *benchmarkController.RunSummary             `json:"run,omitempty"`
+	Experiment  *benchmarkController.ExperimentSummary      `json:"experiment,omitempty"`
+	Metrics     map[string]*MetricsController.MetricSummary `json:"metrics,omitempty"`
+	SLOs        []SLOResult                                 `json:"slos,omitempty"`
+}
+
+// Passed reports whether all SLOs were met
+func (r Report) Passed() bool {
+	return len(Violations(r.SLOs)) == 0
+}
+
+// CheckSLOs evaluates the SLOs against the metrics of the report, for experiments against every cell
+func (r *Report) CheckSLOs(slos []SLO) {
+	if r.Metrics != nil {
+		r.SLOs = append(r.SLOs, CheckAll(slos, r.Metrics, "")...)
+	}
+	if r.Experiment != nil {
+		for _, cell := range r.Experiment.Comparison {
+			r.SLOs = append(r.SLOs, CheckAll(slos, CellMetrics(cell), CellName(cell))...)
+		}
+	}
+}
+
+// CellMetrics returns the summaries of a cell keyed by metric name
+func CellMetrics(cell benchmarkController.CellComparison) map[string]*MetricsController.MetricSummary {
+	return map[string]*MetricsController.MetricSummary{
+		QueueLatency: cell.QueueLatency,
+		BuildTime:    cell.BuildTime,
+		TotalLatency: cell.TotalLatency,
+	}
+}
+
+// CellName identifies a cell of an experiment
+func CellName(cell benchmarkController.CellComparison) string {
+	return fmt.Sprintf("%s/%s/c=%s", cell.Executor, cell.Payload, cell.Concurrency)
+}
+
+// WriteJSON writes the report as indented JSON
+func (r Report) WriteJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(r)
+}
+
+// WriteCSV writes one row per metric, for experiments one row per cell and metric
+func (r Report) WriteCSV(w io.Writer) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write([]string{"scope", "metric", "total_jobs", "average", "median", "q25", "q75", "min", "max"}); err != nil {
+		return err
+	}
+
+	writeRows := func(scope string, metrics map[string]*MetricsController.MetricSummary) error {
+		for _, metric := range Metrics {
+			s := metrics[metric]
+			if s == nil {
+				continue
+			}
+			row := []string{scope, metric, strconv.Itoa(s.TotalJobs)}
+			for _, v := range []int64{s.Average, s.Median, s.Q25, s.Q75, s.Min, s.Max} {
+				row = append(row, strconv.FormatInt(v, 10))
+			}
+			if err := cw.Write(row); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	if r.Metrics != nil {
+		scope := "all"
+		if r.Run != nil {
+			scope = r.Run.ID.String()
+		}
+		if err := writeRows(scope, r.Metrics); err != nil {
+			return err
+		}
+	}
+	if r.Experiment != nil {
+		for _, cell := range r.Experiment.Comparison {
+			if err := writeRows(CellName(cell), CellMetrics(cell)); err != nil {
+				return err
+			}
+		}
+	}
+
+	cw.Flush()
+	return cw.Error()
+}
+
+var templateFuncs = map[string]any{
+	"metrics":     func() []string { return Metrics },
+	"cellMetrics": CellMetrics,
+	"cellName":    CellName,
+	"time": func(t time.Time) string {
+		return t.UTC().Format(time.RFC3339)
+	},
+	"result": FormatResult,
+}
+
+const markdownTemplate = `# {{ .Title }}
+
+Generated at {{ time .GeneratedAt }}
+{{- with .Run }}
+
+## Run {{ .ID }}
+
+| Executor | Scenario | Status | Jobs | Scheduled | Finished | Cancelled | Concurrency | Attempts | Failed submissions |
+|----------|----------|--------|------|-----------|----------|-----------|-------------|----------|--------------------|
+| {{ .Executor }} | {{ .Scenario }} | {{ .Status }} | {{ .JobCount }} | {{ .ScheduledJobs }} | {{ .FinishedJobs }} | {{ .CancelledJobs }} | {{ .Concurrency }} | {{ .Submission.Attempts }} | {{ .Submission.FailedJobs }} |
+{{- if .Error }}
+
+Error: {{ .Error }}
+{{- end }}
+{{- end }}
+{{- with .Metrics }}
+
+## Metrics (seconds)
+
+| Metric | Jobs | Average | Median | Q25 | Q75 | Min | Max |
+|--------|------|---------|--------|-----|-----|-----|-----|
+{{- range $m := metrics }}{{ with index $.Metrics $m }}
+| {{ $m }} | {{ .TotalJobs }} | {{ .Average }} | {{ .Median }} | {{ .Q25 }} | {{ .Q75 }} | {{ .Min }} | {{ .Max }} |
+{{- end }}{{ end }}
+{{- end }}
+{{- with .Experiment }}
+
+## Experiment {{ .ID }}
+
+Scenario {{ .Scenario }}, {{ .RunCount }} runs in {{ .Order }} order, status {{ .Status }}
+
+| Executor | Payload | Concurrency | Runs | Metric | Jobs | Average | Median | Q25 | Q75 | Min | Max |
+|----------|---------|-------------|------|--------|------|---------|--------|-----|-----|-----|-----|
+{{- range $cell := .Comparison }}{{ $cm := cellMetrics $cell }}{{ range $m := metrics }}{{ with index $cm $m }}
+| {{ $cell.Executor }} | {{ $cell.Payload }} | {{ $cell.Concurrency }} | {{ $cell.Runs }} | {{ $m }} | {{ .TotalJobs }} | {{ .Average }} | {{ .Median }} | {{ .Q25 }} | {{ .Q75 }} | {{ .Min }} | {{ .Max }} |
+{{- end }}{{ end }}{{ end }}
+{{- end }}
+{{- with .SLOs }}
+
+## SLOs
+
+{{ range . }}- {{ result . }}
+{{ end }}
+{{- end }}
+`
+
+const htmlTemplate = `<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>{{ .Title }}</title>
+<style>
+body { font-family: sans-serif; margin: 2em; }
+table { border-collapse: collapse; margin-bottom: 1.5em; }
+th, td { border: 1px solid #ccc; padding: 4px 8px; text-align: right; }
+th { background: #f4f4f4; }
+td.text { text-align: left; }
+.ok { color: #1a7f37; }
+.violated { color: #cf222e; font-weight: bold; }
+</style>
+</head>
+<body>
+<h1>{{ .Title }}</h1>
+<p>Generated at {{ time .GeneratedAt }}</p>
+{{- with .Run }}
+<h2>Run {{ .ID }}</h2>
+<table>
+<tr><th>Executor</th><th>Scenario</th><th>Status</th><th>Jobs</th><th>Scheduled</th><th>Finished</th><th>Cancelled</th><th>Concurrency</th><th>Attempts</th><th>Failed submissions</th></tr>
+<tr><td class="text">{{ .Executor }}</td><td class="text">{{ .Scenario }}</td><td class="text">{{ .Status }}</td><td>{{ .JobCount }}</td><td>{{ .ScheduledJobs }}</td><td>{{ .FinishedJobs }}</td><td>{{ .CancelledJobs }}</td><td>{{ .Concurrency }}</td><td>{{ .Submission.Attempts }}</td><td>{{ .Submission.FailedJobs }}</td></tr>
+</table>
+{{- if .Error }}
+<p class="violated">Error: {{ .Error }}</p>
+{{- end }}
+{{- end }}
+{{- with .Metrics }}
+<h2>Metrics (seconds)</h2>
+<table>
+<tr><th>Metric</th><th>Jobs</th><th>Average</th><th>Median</th><th>Q25</th><th>Q75</th><th>Min</th><th>Max</th></tr>
+{{- range $m := metrics }}{{ with index $.Metrics $m }}
+<tr><td class="text">{{ $m }}</td><td>{{ .TotalJobs }}</td><td>{{ .Average }}</td><td>{{ .Median }}</td><td>{{ .Q25 }}</td><td>{{ .Q75 }}</td><td>{{ .Min }}</td><td>{{ .Max }}</td></tr>
+{{- end }}{{ end }}
+</table>
+{{- end }}
+{{- with .Experiment }}
+<h2>Experiment {{ .ID }}</h2>
+<p>Scenario {{ .Scenario }}, {{ .RunCount }} runs in {{ .Order }} order, status {{ .Status }}</p>
+<table>
+<tr><th>Executor</th><th>Payload</th><th>Concurrency</th><th>Runs</th><th>Metric</th><th>Jobs</th><th>Average</th><th>Median</th><th>Q25</th><th>Q75</th><th>Min</th><th>Max</th></tr>
+{{- range $cell := .Comparison }}{{ $cm := cellMetrics $cell }}{{ range $m := metrics }}{{ with index $cm $m }}
+<tr><td class="text">{{ $cell.Executor }}</td><td class="text">{{ $cell.Payload }}</td><td>{{ $cell.Concurrency }}</td><td>{{ $cell.Runs }}</td><td class="text">{{ $m }}</td><td>{{ .TotalJobs }}</td><td>{{ .Average }}</td><td>{{ .Median }}</td><td>{{ .Q25 }}</td><td>{{ .Q75 }}</td><td>{{ .Min }}</td><td>{{ .Max }}</td></tr>
+{{- end }}{{ end }}{{ end }}
+</table>
+{{- end }}
+{{- with .SLOs }}
+<h2>SLOs</h2>
+<ul>
+{{- range . }}
+<li class="{{ if .OK }}ok{{ else }}violated{{ end }}">{{ result . }}</li>
+{{- end }}
+</ul>
+{{- end }}
+</body>
+</html>
+`
+
+var (
+	markdown = template.Must(template.New("markdown").Funcs(templateFuncs).Parse(markdownTemplate))
+	html     = htmltemplate.Must(htmltemplate.New("html").Funcs(templateFuncs).Parse(htmlTemplate))
+)
+
+// WriteMarkdown writes the report as a Markdown document
+func (r Report) WriteMarkdown(w io.Writer) error {
+	return markdown.Execute(w, r)
+}
+
+// WriteHTML writes the report as a standalone HTML page
+func (r Report) WriteHTML(w io.Writer) error {
+	return html.Execute(w, r)
+}
+
+// Write writes the report in the given format, one of json, csv, markdown or html
+func (r Report) Write(w io.Writer, format string) error {
+	switch format {
+	case "json":
+		return r.WriteJSON(w)
+	case "csv":
+		return r.WriteCSV(w)
+	case "markdown", "md":
+		return r.WriteMarkdown(w)
+	case "html":
+		return r.WriteHTML(w)
+	default:
+		return fmt.Errorf("unknown format %q, expected json, csv, markdown or html", format)
+	}
+}
@@ -0,0 +1,162 @@
+package report
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Mtze/CI-Benchmarker/MetricsController"
+)
+
+// Metric names used in SLOs and reports
+const (
+	QueueLatency = "queue_latency"
+	BuildTime    = "build_time"
+	TotalLatency = "total_latency"
+)
+
+// SLO is an objective on a statistic of a metric, e.g. total_latency.median<=120.
+// Latencies and build times are in seconds.
+type SLO struct {
+	Metric    string
+	Statistic string
+	Operator  string
+	Threshold int64
+}
+
+// SLOResult is the outcome of checking an SLO, Missing is set if no job reported the metric
+type SLOResult struct {
+	SLO     SLO    `json:"-"`
+	Spec    string `json:"slo"`
+	Scope   string `json:"scope,omitempty"`
+	Actual  int64  `json:"actual"`
+	Missing bool   `json:"missing,omitempty"`
+	OK      bool   `json:"ok"`
+}
+
+var sloPattern = regexp.MustCompile(`^\s*([a-z_]+)\.([a-z0-9_]+)\s*(<=|>=|<|>|==)\s*(\S+)\s*$`)
+
+var statistics = map[string]func(MetricsController.MetricSummary) int64{
+	"average":    func(s MetricsController.MetricSummary) int64 { return s.Average },
+	"avg":        func(s MetricsController.MetricSummary) int64 { return s.Average },
+	"median":     func(s MetricsController.MetricSummary) int64 { return s.Median },
+	"p50":        func(s MetricsController.MetricSummary) int64 { return s.Median },
+	"q25":        func(s MetricsController.MetricSummary) int64 { return s.Q25 },
+	"p25":        func(s MetricsController.MetricSummary) int64 { return s.Q25 },
+	"q75":        func(s MetricsController.MetricSummary) int64 { return s.Q75 },
+	"p75":        func(s MetricsController.MetricSummary) int64 { return s.Q75 },
+	"max":        func(s MetricsController.MetricSummary) int64 { return s.Max },
+	"min":        func(s MetricsController.MetricSummary) int64 { return s.Min },
+	"total_jobs": func(s MetricsController.MetricSummary) int64 { return int64(s.TotalJobs) },
+}
+
+// ParseSLO parses an SLO like "queue_latency.p75<30" or "build_time.max<=10m". The threshold is
+// either a number of seconds (or jobs for total_jobs) or a duration.
+func ParseSLO(spec string) (SLO, error) {
+	m := sloPattern.FindStringSubmatch(spec)
+	if m == nil {
+		return SLO{}, fmt.Errorf("invalid SLO %q, expected <metric>.<statistic><op><threshold>", spec)
+	}
+
+	slo := SLO{Metric: m[1], Statistic: m[2], Operator: m[3]}
+	switch slo.Metric {
+	case QueueLatency, BuildTime, TotalLatency:
+	default:
+		return SLO{}, fmt.Errorf("invalid SLO %q, unknown metric %q", spec, slo.Metric)
+	}
+	if _, ok := statistics[slo.Statistic]; !ok {
+		return SLO{}, fmt.Errorf("invalid SLO %q, unknown statistic %q", spec, slo.Statistic)
+	}
+
+	if n, err := strconv.ParseInt(m[4], 10, 64); err == nil {
+		slo.Threshold = n
+	} else if d, err := time.ParseDuration(m[4]); err == nil {
+		slo.Threshold = int64(d / time.Second)
+	} else {
+		return SLO{}, fmt.Errorf("invalid SLO %q, threshold must be a number or a duration", spec)
+	}
+	return slo, nil
+}
+
+// ParseSLOs parses all specs
+func ParseSLOs(specs []string) ([]SLO, error) {
+	slos := make([]SLO, 0, len(specs))
+	for _, spec := range specs {
+		slo, err := ParseSLO(spec)
+		if err != nil {
+			return nil, err
+		}
+		slos = append(slos, slo)
+	}
+	return slos, nil
+}
+
+func (s SLO) String() string {
+	return fmt.Sprintf("%s.%s%s%d", s.Metric, s.Statistic, s.Operator, s.Threshold)
+}
+
+// Check evaluates the SLO against the summary of its metric, a nil summary violates the SLO
+func (s SLO) Check(summary *MetricsController.MetricSummary) SLOResult {
+	result := SLOResult{SLO: s, Spec: s.String()}
+	if summary == nil {
+		result.Missing = true
+		return result
+	}
+
+	result.Actual = statistics[s.Statistic](*summary)
+	switch s.Operator {
+	case "<=":
+		result.OK = result.Actual <= s.Threshold
+	case "<":
+		result.OK = result.Actual < s.Threshold
+	case ">=":
+		result.OK = result.Actual >= s.Threshold
+	case ">":
+		result.OK = result.Actual > s.Threshold
+	case "==":
+		result.OK = result.Actual == s.Threshold
+	}
+	return result
+}
+
+// CheckAll evaluates all SLOs against the summaries keyed by metric name
+func CheckAll(slos []SLO, summaries map[string]*MetricsController.MetricSummary, scope string) []SLOResult {
+	results := make([]SLOResult, 0, len(slos))
+	for _, slo := range slos {
+		result := slo.Check(summaries[slo.Metric])
+		result.Scope = scope
+		results = append(results, result)
+	}
+	return results
+}
+
+// Violations returns the results which did not meet their SLO
+func Violations(results []SLOResult) []SLOResult {
+	var violations []SLOResult
+	for _, r := range results {
+		if !r.OK {
+			violations = append(violations, r)
+		}
+	}
+	return violations
+}
+
+// FormatResult describes the outcome of an SLO check in a single line
+func FormatResult(r SLOResult) string {
+	var b strings.Builder
+	if r.Scope != "" {
+		fmt.Fprintf(&b, "[%s] ", r.Scope)
+	}
+	b.WriteString(r.Spec)
+	switch {
+	case r.Missing:
+		b.WriteString(": VIOLATED (no data)")
+	case r.OK:
+		fmt.Fprintf(&b, ": ok (%d)", r.Actual)
+	default:
+		fmt.Fprintf(&b, ": VIOLATED (%d)", r.Actual)
+	}
+	return b.String()
+}
@@ -0,0 +1,199 @@
+package retention
+
+import (
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/Mtze/CI-Benchmarker/persister"
+)
+
+// Policy limits the jobs kept in the database, a zero value disables a limit
+type Policy struct {
+	// MaxAge prunes the jobs created longer ago
+	MaxAge time.Duration
+	// MaxJobs keeps only the latest jobs
+	MaxJobs int
+	// KeepRuns keeps only the jobs of the latest runs, jobs which are not part of a run are kept
+	KeepRuns int
+}
+
+// Enabled reports whether any limit is set
+func (p Policy) Enabled() bool {
+	return p.MaxAge > 0 || p.MaxJobs > 0 || p.KeepRuns > 0
+}
+
+// Result describes a pruning, Archive is the path of the archive the pruned jobs and runs were written to
+type Result struct {
+	persister.PruneResult
+	Archive string
+}
+
+// Pruner deletes jobs and runs from the database after writing them to gzip compressed JSONL archives.
+// It enforces its policy periodically and prunes on demand.
+type Pruner struct {
+	persister  persister.Database
+	policy     Policy
+	interval   time.Duration
+	archiveDir string
+
+	// mu serializes prunings, so jobs are not archived twice
+	mu sync.Mutex
+}
+
+// NewPruner creates a pruner which enforces policy every interval and writes its archives to archiveDir
+func NewPruner(p persister.Database, policy Policy, interval time.Duration, archiveDir string) *Pruner {
+	return &Pruner{
+		persister:  p,
+		policy:     policy,
+		interval:   interval,
+		archiveDir: archiveDir,
+	}
+}
+
+// Run enforces the policy until the context is cancelled, it returns immediately if no limit is set
+func (p *Pruner) Run(ctx context.Context) {
+	if !p.policy.Enabled() {
+		slog.Debug("No retention policy set, keeping all jobs")
+		return
+	}
+	slog.Info("Starting Pruner", slog.Duration("interval", p.interval), slog.Duration("maxAge", p.policy.MaxAge),
+		slog.Int("maxJobs", p.policy.MaxJobs), slog.Int("keepRuns", p.policy.KeepRuns))
+	ticker := time.NewTicker(p.interval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := p.Enforce(ctx); err != nil && ctx.Err() == nil {
+			slog.Error("Failed to enforce retention policy", slog.Any("error", err))
+		}
+
+		select {
+		case <-ctx.Done():
+			slog.Info("Stopping Pruner")
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Enforce prunes the jobs and runs exceeding the limits of the policy
+func (p *Pruner) Enforce(ctx context.Context) ([]Result, error) {
+	var results []Result
+	var before *time.Time
+	if p.policy.MaxAge > 0 {
+		cutoff := time.Now().Add(-p.policy.MaxAge)
+		before = &cutoff
+	}
+	if p.policy.MaxJobs > 0 {
+		// Prune the jobs created before the oldest job which is kept
+		cutoff, err := p.persister.JobCreationTimeAt(p.policy.MaxJobs - 1)
+		if err != nil {
+			return nil, err
+		}
+		if cutoff != nil && (before == nil || cutoff.After(*before)) {
+			before = cutoff
+		}
+	}
+	if before != nil {
+		result, err := p.Prune(ctx, persister.PruneFilter{Before: before})
+		if err != nil {
+			return results, err
+		}
+		results = append(results, result)
+	}
+
+	if p.policy.KeepRuns > 0 {
+		runIDs, err := p.persister.RunIDsAfter(p.policy.KeepRuns)
+		if err != nil {
+			return results, err
+		}
+		for _, runID := range runIDs {
+			result, err := p.Prune(ctx, persister.PruneFilter{RunID: &runID})
+			if err != nil {
+				return results, err
+			}
+			results = append(results, result)
+		}
+	}
+	return results, nil
+}
+
+// Prune archives and deletes the jobs matching filter and the runs left without jobs. Nothing is
+// archived if nothing matches.
+func (p *Pruner) Prune(ctx context.Context, filter persister.PruneFilter) (Result, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	archive, err := createArchive(p.archiveDir)
+	if err != nil {
+		return Result{}, err
+	}
+	pruned, err := p.persister.Prune(ctx, filter, archive)
+	if closeErr := archive.Close(); closeErr != nil && err == nil {
+		err = fmt.Errorf("failed to close archive: %w", closeErr)
+	}
+	if pruned.Jobs == 0 && pruned.Runs == 0 && err == nil {
+		return Result{}, os.Remove(archive.path)
+	}
+
+	result := Result{PruneResult: pruned, Archive: archive.path}
+	if pruned.Jobs > 0 || pruned.Runs > 0 {
+		slog.Info("Pruned jobs", slog.Int("jobs", pruned.Jobs), slog.Int("runs", pruned.Runs), slog.String("archive", archive.path))
+	}
+	return result, err
+}
+
+// jsonlArchive writes the records of pruned jobs and runs as gzip compressed JSONL
+type jsonlArchive struct {
+	path    string
+	file    *os.File
+	gzip    *gzip.Writer
+	encoder *json.Encoder
+}
+
+func createArchive(dir string) (*jsonlArchive, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create archive directory: %w", err)
+	}
+	file, err := os.CreateTemp(dir, "pruned-"+time.Now().UTC().Format("20060102T150405Z")+"-*.jsonl.gz")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create archive: %w", err)
+	}
+
+	writer := gzip.NewWriter(file)
+	return &jsonlArchive{
+		path:    filepath.Clean(file.Name()),
+		file:    file,
+		gzip:    writer,
+		encoder: json.NewEncoder(writer),
+	}, nil
+}
+
+func (a *jsonlArchive) Write(record persister.ArchiveRecord) error {
+	return a.encoder.Encode(record)
+}
+
+func (a *jsonlArchive) Flush() error {
+	if err := a.gzip.Flush(); err != nil {
+		return err
+	}
+	return a.file.Sync()
+}
+
+func (a *jsonlArchive) Close() error {
+	if err := a.gzip.Close(); err != nil {
+		a.file.Close()
+		return err
+	}
+	if err := a.file.Sync(); err != nil {
+		a.file.Close()
+		return err
+	}
+	return a.file.Close()
+}
@@ -5,11 +5,16 @@ import (
 	swaggerFiles "github.com/swaggo/files"
 	ginSwagger "github.com/swaggo/gin-swagger"
 
+	"crypto/subtle"
+	"errors"
 	"log/slog"
+	"net/http"
+	"strings"
 	"time"
 
 	"github.com/Mtze/CI-Benchmarker/MetricsController"
 	"github.com/Mtze/CI-Benchmarker/benchmarkController"
+	"github.com/Mtze/CI-Benchmarker/persister"
 	_ "github.com/Mtze/CI-Benchmarker/shared/response"
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
@@ -30,7 +35,7 @@ type JobStartTime struct {
 	BuildStartTime string `json:"buildStartTime" env:"BUILD_START_TIME"`
 }
 
-func startRouter() *gin.Engine {
+func startRouter(adminToken string) *gin.Engine {
 	slog.Debug("Setting up router")
 	gin.SetMode(gin.ReleaseMode)
 	r := gin.Default()
@@ -46,20 +51,62 @@ func startRouter() *gin.Engine {
 
 	version.POST("/start_time", handleStartTime)
 
+	// Register the route for callbacks of jobs which are not scheduled
+	version.GET("/orphan_callbacks", benchmarkController.ListOrphanCallbacks(services.Persister))
+
+	// Register the route to prune jobs, they are archived before
+	version.DELETE("/jobs", requireAdminToken(adminToken), benchmarkController.DeleteJobs(services))
+
+	// Register the routes for the administration of the database
+	adminGroup := version.Group("/admin", requireAdminToken(adminToken))
+	{
+		adminGroup.GET("/backup", benchmarkController.GetBackup(services.Persister))
+		adminGroup.GET("/export", benchmarkController.ExportData(services.Persister))
+		adminGroup.POST("/import", benchmarkController.ImportData(services.Persister))
+	}
+
 	// Register the route for the benchmark executors
 	benchmarkGroup := version.Group("/benchmark")
 	{
 		// Create benchmarks
-		benchmarkGroup.POST("/hades-docker", benchmarkController.NewHadesDockerBenchmark())
-		benchmarkGroup.POST("/hades-k8s", benchmarkController.NewHadesKubernetesBenchmark())
-		benchmarkGroup.POST("/jenkins", benchmarkController.NewJenkinsBenchmark())
+		benchmarkGroup.POST("/hades-docker", benchmarkController.NewHadesDockerBenchmark(services))
+		benchmarkGroup.POST("/hades-k8s", benchmarkController.NewHadesKubernetesBenchmark(services))
+		benchmarkGroup.POST("/jenkins", benchmarkController.NewJenkinsBenchmark(services))
 		// Get benchmark results
-		benchmarkGroup.GET("/latency/histogram", MetricsController.GetTotalLatencyHistogram)
-		benchmarkGroup.GET("/latency/metrics", MetricsController.GetTotalLatencyMetrics)
-		benchmarkGroup.GET("/queue_latency/histogram", MetricsController.GetQueueLatencyHistogram)
-		benchmarkGroup.GET("/queue_latency/metrics", MetricsController.GetQueueLatencyMetrics)
-		benchmarkGroup.GET("/build_time/histogram", MetricsController.GetBuildTimeHistogram)
-		benchmarkGroup.GET("/build_time/metrics", MetricsController.GetBuildTimeMetrics)
+		benchmarkGroup.GET("/latency/histogram", MetricsController.GetTotalLatencyHistogram(services.Persister))
+		benchmarkGroup.GET("/latency/metrics", MetricsController.GetTotalLatencyMetrics(services.Persister))
+		benchmarkGroup.GET("/queue_latency/histogram", MetricsController.GetQueueLatencyHistogram(services.Persister))
+		benchmarkGroup.GET("/queue_latency/metrics", MetricsController.GetQueueLatencyMetrics(services.Persister))
+		benchmarkGroup.GET("/build_time/histogram", MetricsController.GetBuildTimeHistogram(services.Persister))
+		benchmarkGroup.GET("/build_time/metrics", MetricsController.GetBuildTimeMetrics(services.Persister))
+	}
+
+	// Register the route for declarative benchmark scenarios
+	version.POST("/scenarios/run", benchmarkController.RunScenario(services))
+
+	// Register the routes for experiments started from scenarios with a matrix
+	experimentGroup := version.Group("/experiments")
+	{
+		experimentGroup.GET("/:id", benchmarkController.GetExperiment(services.Persister))
+		experimentGroup.POST("/:id/cancel", benchmarkController.CancelExperiment(services))
+	}
+
+	// Register the routes for the payload library
+	payloadGroup := version.Group("/payloads")
+	{
+		payloadGroup.POST("", benchmarkController.CreatePayload(services.Persister))
+		payloadGroup.GET("", benchmarkController.ListPayloads(services.Persister))
+		payloadGroup.GET("/:id", benchmarkController.GetPayload(services.Persister))
+		payloadGroup.PUT("/:id", benchmarkController.UpdatePayload(services.Persister))
+		payloadGroup.DELETE("/:id", benchmarkController.DeletePayload(services.Persister))
+		payloadGroup.GET("/:id/versions", benchmarkController.ListPayloadVersions(services.Persister))
+	}
+
+	// Register the routes for benchmark runs
+	runGroup := version.Group("/runs")
+	{
+		runGroup.GET("/:id", benchmarkController.GetRun(services.Persister))
+		runGroup.POST("/:id/cancel", benchmarkController.CancelRun(services))
 	}
 
 	return r
@@ -72,7 +119,10 @@ func startRouter() *gin.Engine {
 // @Produce      json
 // @Param        resultMetadata  body  ResultMetadata  true  "Job Result Metadata"
 // @Success      200  {object}  response.SimpleMessage
+// @Success      202  {object}  response.SimpleMessage  "Unknown job, kept as orphan callback"
 // @Failure 	 400  {object} 	response.ErrorMessage
+// @Failure      500  {object}  response.ServerErrorMessage
+// @Failure      503  {object}  response.ServerErrorMessage
 // @Router       /result [post]
 func handleResult(c *gin.Context) {
 	slog.Debug("Received result", slog.Any("result", c.Request.Body))
@@ -99,7 +149,24 @@ func handleResult(c *gin.Context) {
 		return
 	}
 
-	p.StoreResult(uuid, buildCompletionTime)
+	err = p.StoreResult(uuid, buildCompletionTime)
+	if errors.Is(err, persister.ErrUnknownJob) {
+		slog.Warn("Received result of unknown job", slog.Any("uuid", uuid))
+		c.JSON(202, gin.H{"message": "Unknown job, the result is kept as orphan callback"})
+		return
+	}
+	if errors.Is(err, persister.ErrQueueFull) {
+		slog.Warn("Callback queue is full, rejecting result", slog.Any("uuid", uuid))
+		c.Header("Retry-After", "1")
+		c.JSON(503, gin.H{"error": "Too many callbacks, retry later"})
+		return
+	}
+	// Answer with a server error, so the reporter retries the callback
+	if err != nil {
+		slog.Error("Failed to store result", slog.Any("uuid", uuid), slog.Any("error", err))
+		c.JSON(500, gin.H{"error": "Failed to store result"})
+		return
+	}
 
 	c.JSON(200, gin.H{"message": "Result received"})
 }
@@ -111,7 +178,10 @@ func handleResult(c *gin.Context) {
 // @Produce      json
 // @Param        jobStartTime  body  JobStartTime  true  "Build Start Time"
 // @Success      200  {object}  response.SimpleMessage
+// @Success      202  {object}  response.SimpleMessage  "Unknown job, kept as orphan callback"
 // @Failure      400  {object}  response.ErrorMessage
+// @Failure      500  {object}  response.ServerErrorMessage
+// @Failure      503  {object}  response.ServerErrorMessage
 // @Router       /start_time [post]
 func handleStartTime(c *gin.Context) {
 	slog.Debug("Received job start time information", slog.Any("time", c.Request.Body))
@@ -138,7 +208,42 @@ func handleStartTime(c *gin.Context) {
 		return
 	}
 
-	p.StoreStartTime(uuid, buildStartTime)
+	err = p.StoreStartTime(uuid, buildStartTime)
+	if errors.Is(err, persister.ErrUnknownJob) {
+		slog.Warn("Received build start time of unknown job", slog.Any("uuid", uuid))
+		c.JSON(202, gin.H{"message": "Unknown job, the build start time is kept as orphan callback"})
+		return
+	}
+	if errors.Is(err, persister.ErrQueueFull) {
+		slog.Warn("Callback queue is full, rejecting build start time", slog.Any("uuid", uuid))
+		c.Header("Retry-After", "1")
+		c.JSON(503, gin.H{"error": "Too many callbacks, retry later"})
+		return
+	}
+	// Answer with a server error, so the reporter retries the callback
+	if err != nil {
+		slog.Error("Failed to store build start time", slog.Any("uuid", uuid), slog.Any("error", err))
+		c.JSON(500, gin.H{"error": "Failed to store build start time"})
+		return
+	}
 
 	c.JSON(200, gin.H{"message": "Build start time received"})
 }
+
+// requireAdminToken rejects requests without the bearer token token. The endpoints it guards are disabled
+// if token is empty, as they delete or expose the stored data.
+func requireAdminToken(token string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if token == "" {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "Admin endpoints are disabled, set ADMIN_TOKEN to enable them"})
+			return
+		}
+		provided, ok := strings.CutPrefix(c.GetHeader("Authorization"), "Bearer ")
+		if !ok || subtle.ConstantTimeCompare([]byte(provided), []byte(token)) != 1 {
+			c.Header("WWW-Authenticate", "Bearer")
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Invalid or missing admin token"})
+			return
+		}
+		c.Next()
+	}
+}
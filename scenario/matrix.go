@@ -0,0 +1,150 @@
+package scenario
+
+import (
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"slices"
+	"time"
+
+	"github.com/ls1intum/hades/shared/payload"
+)
+
+// Orders in which the cells of a matrix are executed
+const (
+	// OrderInterleaved runs every combination once per repetition, so each repetition is spread over time
+	OrderInterleaved = "interleaved"
+	// OrderRandomized shuffles the combinations within every repetition
+	OrderRandomized = "randomized"
+)
+
+// DefaultCellTimeout bounds how long an experiment waits for the jobs of a run before starting the next one
+const DefaultCellTimeout = 30 * time.Minute
+
+// Matrix declares the dimensions of an experiment. Every combination of executor, payload and
+// concurrency level is run Repetitions times with the load profile of the scenario. Runs are executed
+// one after another and the next run starts once the jobs of the previous one finished, CellTimeout
+// elapsed or the run was cancelled. Dimensions which are left empty fall back to the executor, payload
+// and concurrency of the scenario.
+type Matrix struct {
+	Executors   []ExecutorSpec `json:"executors,omitempty"`
+	Payloads    []NamedPayload `json:"payloads,omitempty"`
+	Concurrency []int          `json:"concurrency,omitempty"`
+	Repetitions int            `json:"repetitions,omitempty"`
+	Order       string         `json:"order,omitempty"`
+	// Seed makes a randomized order reproducible, 0 picks a random seed which is stored with the experiment
+	Seed        uint64   `json:"seed,omitempty"`
+	CellTimeout Duration `json:"cell_timeout,omitempty"`
+	// Pause is waited between two runs
+	Pause Duration `json:"pause,omitempty"`
+}
+
+// NamedPayload is a payload of a matrix, the name identifies it in the comparison
+type NamedPayload struct {
+	Name        string               `json:"name"`
+	Payload     *payload.RESTPayload `json:"payload,omitempty"`
+	PayloadFile string               `json:"payload_file,omitempty"`
+}
+
+// Cell is a single run of an experiment
+type Cell struct {
+	Executor    ExecutorSpec
+	PayloadName string
+	Payload     payload.RESTPayload
+	// Concurrency is nil if neither the matrix nor the load profile set it
+	Concurrency *int
+	Repetition  int
+}
+
+func (m *Matrix) validate() error {
+	for i, e := range m.Executors {
+		if err := e.validate(); err != nil {
+			return fmt.Errorf("matrix.executors[%d]: %w", i, err)
+		}
+	}
+	for i, p := range m.Payloads {
+		if p.Name == "" {
+			return fmt.Errorf("matrix.payloads[%d]: name is required", i)
+		}
+		if err := validatePayload(p.Payload); err != nil {
+			return fmt.Errorf("matrix.payloads[%d]: %w", i, err)
+		}
+	}
+	for _, c := range m.Concurrency {
+		if c < 0 {
+			return errors.New("matrix.concurrency must not be negative")
+		}
+	}
+	if m.Repetitions < 0 {
+		return errors.New("matrix.repetitions must not be negative")
+	}
+	switch m.Order {
+	case "":
+		m.Order = OrderInterleaved
+	case OrderInterleaved, OrderRandomized:
+	default:
+		return fmt.Errorf("unknown matrix.order %q", m.Order)
+	}
+	if m.CellTimeout < 0 || m.Pause < 0 {
+		return errors.New("matrix.cell_timeout and matrix.pause must not be negative")
+	}
+	if m.CellTimeout == 0 {
+		m.CellTimeout = Duration(DefaultCellTimeout)
+	}
+	if m.Order == OrderRandomized && m.Seed == 0 {
+		m.Seed = rand.Uint64()
+	}
+	return nil
+}
+
+// Cells expands the matrix of the scenario into the runs of the experiment in execution order
+func (s *Scenario) Cells() []Cell {
+	m := s.Matrix
+	if m == nil {
+		m = &Matrix{}
+	}
+
+	executors := m.Executors
+	if len(executors) == 0 {
+		executors = []ExecutorSpec{s.Executor}
+	}
+	payloads := m.Payloads
+	if len(payloads) == 0 {
+		payloads = []NamedPayload{{Name: s.Payload.Name, Payload: s.Payload}}
+	}
+	concurrency := make([]*int, 0, len(m.Concurrency))
+	for _, c := range m.Concurrency {
+		concurrency = append(concurrency, &c)
+	}
+	if len(concurrency) == 0 {
+		concurrency = []*int{s.Load.Concurrency}
+	}
+	repetitions := max(m.Repetitions, 1)
+
+	rng := rand.New(rand.NewPCG(m.Seed, m.Seed))
+	cells := make([]Cell, 0, repetitions*len(executors)*len(payloads)*len(concurrency))
+	for r := 1; r <= repetitions; r++ {
+		var round []Cell
+		for _, e := range executors {
+			if e.Name == "" {
+				e.Name = e.Type
+			}
+			for _, p := range payloads {
+				for _, c := range concurrency {
+					round = append(round, Cell{
+						Executor:    e,
+						PayloadName: p.Name,
+						Payload:     *p.Payload,
+						Concurrency: c,
+						Repetition:  r,
+					})
+				}
+			}
+		}
+		if m.Order == OrderRandomized {
+			rng.Shuffle(len(round), func(i, j int) { round[i], round[j] = round[j], round[i] })
+		}
+		cells = slices.Concat(cells, round)
+	}
+	return cells
+}
@@ -0,0 +1,102 @@
+package scenario
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+const testMatrix = testScenario + `payload: {name: default}
+load: {count: 1}
+matrix:
+  executors:
+    - {type: hades-docker, host: http://docker}
+    - {name: k8s, type: hades-k8s, host: http://k8s}
+  payloads:
+    - {name: a, payload: {name: a}}
+    - {name: b, payload: {name: b}}
+  concurrency: [1, 5]
+  repetitions: 2
+`
+
+// cellNames identifies the cells by executor, payload, concurrency and repetition
+func cellNames(cells []Cell) []string {
+	names := make([]string, 0, len(cells))
+	for _, cell := range cells {
+		names = append(names, fmt.Sprintf("%s/%s/%d/%d", cell.Executor.Name, cell.PayloadName, *cell.Concurrency, cell.Repetition))
+	}
+	return names
+}
+
+func TestCellsInterleaved(t *testing.T) {
+	s, err := Parse([]byte(testMatrix), t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.Matrix.Order != OrderInterleaved {
+		t.Fatalf("expected order %q by default, got %q", OrderInterleaved, s.Matrix.Order)
+	}
+
+	var round []string
+	for _, e := range []string{"hades-docker", "k8s"} {
+		for _, p := range []string{"a", "b"} {
+			for _, c := range []int{1, 5} {
+				round = append(round, fmt.Sprintf("%s/%s/%d/%%d", e, p, c))
+			}
+		}
+	}
+	var expected []string
+	for r := 1; r <= 2; r++ {
+		for _, name := range round {
+			expected = append(expected, fmt.Sprintf(name, r))
+		}
+	}
+	if names := cellNames(s.Cells()); !reflect.DeepEqual(names, expected) {
+		t.Fatalf("expected cells %v, got %v", expected, names)
+	}
+}
+
+func TestCellsRandomized(t *testing.T) {
+	randomized := testMatrix + "  order: randomized\n  seed: 42\n"
+	first, err := Parse([]byte(randomized), t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := Parse([]byte(randomized), t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cells := cellNames(first.Cells())
+	if again := cellNames(second.Cells()); !reflect.DeepEqual(cells, again) {
+		t.Fatalf("expected the same order for the same seed, got %v and %v", cells, again)
+	}
+	if len(cells) != 16 {
+		t.Fatalf("expected 16 cells, got %d", len(cells))
+	}
+	// Every repetition runs all combinations before the next one starts
+	for i, cell := range first.Cells() {
+		if cell.Repetition != i/8+1 {
+			t.Fatalf("cell %d: expected repetition %d, got %d", i, i/8+1, cell.Repetition)
+		}
+	}
+
+	unseeded, err := Parse([]byte(testMatrix+"  order: randomized\n"), t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if unseeded.Matrix.Seed == 0 {
+		t.Fatal("expected a random seed to be picked")
+	}
+}
+
+func TestCellsFallBackToScenario(t *testing.T) {
+	s, err := Parse([]byte(testScenario+"payload: {name: default}\nload: {count: 1, concurrency: 3}\nmatrix: {repetitions: 2}\n"), t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []string{"hades-docker/default/3/1", "hades-docker/default/3/2"}
+	if names := cellNames(s.Cells()); !reflect.DeepEqual(names, expected) {
+		t.Fatalf("expected cells %v, got %v", expected, names)
+	}
+}
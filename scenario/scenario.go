@@ -0,0 +1,462 @@
+package scenario
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/Mtze/CI-Benchmarker/executor"
+	"github.com/Mtze/CI-Benchmarker/payloadtemplate"
+	"github.com/ls1intum/hades/shared/payload"
+	"gopkg.in/yaml.v3"
+)
+
+// Executor types which can be targeted by a scenario
+const (
+	ExecutorHadesDocker     = "hades-docker"
+	ExecutorHadesKubernetes = "hades-k8s"
+	ExecutorJenkins         = "jenkins"
+)
+
+// Scenario describes a reproducible benchmark setup, i.e. which CI system is benchmarked with which
+// payload and load. Scenarios are written as YAML or JSON files, so they can be versioned in git.
+type Scenario struct {
+	Name     string       `json:"name"`
+	Executor ExecutorSpec `json:"executor"`
+	// Payload is the job which is submitted, alternatively PayloadFile points to a JSON or YAML file containing it
+	Payload     *payload.RESTPayload `json:"payload,omitempty"`
+	PayloadFile string               `json:"payload_file,omitempty"`
+	Load        LoadProfile          `json:"load"`
+	// MaxAttempts overrides the number of submission attempts per job, 0 keeps the server default
+	MaxAttempts int               `json:"max_attempts,omitempty"`
+	CommitHash  string            `json:"commit_hash,omitempty"`
+	Labels      map[string]string `json:"labels,omitempty"`
+	Warmup      Warmup            `json:"warmup,omitempty"`
+	// Trace replays recorded submissions instead of the load profile, only load.concurrency applies to it
+	Trace *Trace `json:"trace,omitempty"`
+	// Template renders the payloads per job, see package payloadtemplate
+	Template *Template `json:"template,omitempty"`
+	// Reporters adds the steps reporting the start time and result of every job to the payloads
+	Reporters *Reporters `json:"reporters,omitempty"`
+	// Matrix turns the scenario into an experiment with one run per combination, see Matrix
+	Matrix *Matrix `json:"matrix,omitempty"`
+}
+
+// Template enables rendering the payloads per job. Choices are lists the templates pick from at random,
+// e.g. assignment repositories or commits.
+type Template struct {
+	Choices map[string][]string `json:"choices,omitempty"`
+}
+
+// Reporters enables adding the reporter steps to the payloads. ResultMetadata is added to the metadata
+// of the result step, e.g. INGEST_DIR.
+type Reporters struct {
+	Inject         bool              `json:"inject"`
+	ResultMetadata map[string]string `json:"result_metadata,omitempty"`
+}
+
+// ExecutorSpec selects the CI system and how to connect to it. User, APIToken, JobPath and
+// UseParameters are only used by Jenkins.
+type ExecutorSpec struct {
+	// Name identifies the executor within a matrix, it defaults to the type
+	Name          string `json:"name,omitempty"`
+	Type          string `json:"type"`
+	Host          string `json:"host"`
+	User          string `json:"user,omitempty"`
+	APIToken      string `json:"api_token,omitempty"`
+	JobPath       string `json:"job_path,omitempty"`
+	UseParameters bool   `json:"use_parameters,omitempty"`
+}
+
+// LoadProfile defines how many jobs are submitted and how fast. Without a duration all jobs are
+// submitted as fast as the concurrency allows, with a duration they are spread evenly over it.
+// Instead of a count a rate in jobs per second can be given together with a duration.
+type LoadProfile struct {
+	Count       int      `json:"count,omitempty"`
+	Rate        float64  `json:"rate,omitempty"`
+	Duration    Duration `json:"duration,omitempty"`
+	Concurrency *int     `json:"concurrency,omitempty"`
+}
+
+// Warmup jobs are submitted before the measured jobs and are not recorded, e.g. to pull images
+// and fill caches of the CI system. Pause is waited between the warm-up and the measured jobs.
+type Warmup struct {
+	Count int      `json:"count,omitempty"`
+	Pause Duration `json:"pause,omitempty"`
+}
+
+// Duration is a time.Duration written as a string like "90s" or "10m", or as a number of seconds
+type Duration time.Duration
+
+func (d *Duration) UnmarshalJSON(data []byte) error {
+	var seconds float64
+	if err := json.Unmarshal(data, &seconds); err == nil {
+		*d = Duration(seconds * float64(time.Second))
+		return nil
+	}
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return fmt.Errorf("duration must be a string like \"10m\" or a number of seconds: %w", err)
+	}
+	parsed, err := time.ParseDuration(s)
+	if err != nil {
+		return err
+	}
+	*d = Duration(parsed)
+	return nil
+}
+
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(time.Duration(d).String())
+}
+
+// Std returns the duration as time.Duration
+func (d Duration) Std() time.Duration {
+	return time.Duration(d)
+}
+
+// Parse reads a scenario from YAML or JSON, e.g. submitted over the API. A relative PayloadFile or trace
+// file is resolved against baseDir, files outside of baseDir are rejected.
+func Parse(data []byte, baseDir string) (*Scenario, error) {
+	if baseDir == "" {
+		baseDir = "."
+	}
+	return parse(data, files{baseDir: baseDir, root: baseDir})
+}
+
+func parse(data []byte, f files) (*Scenario, error) {
+	// JSON is valid YAML, so both formats are decoded as YAML and mapped onto the JSON field names
+	var raw any
+	if err := yaml.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("failed to parse scenario: %w", err)
+	}
+	if raw == nil {
+		return nil, errors.New("scenario is empty")
+	}
+	var s Scenario
+	if err := remarshal(raw, &s); err != nil {
+		return nil, fmt.Errorf("failed to parse scenario: %w", err)
+	}
+
+	if err := resolvePayload(&s.Payload, s.PayloadFile, f); err != nil {
+		return nil, err
+	}
+	if s.Matrix != nil {
+		for i := range s.Matrix.Payloads {
+			p := &s.Matrix.Payloads[i]
+			if err := resolvePayload(&p.Payload, p.PayloadFile, f); err != nil {
+				return nil, fmt.Errorf("matrix.payloads[%d]: %w", i, err)
+			}
+		}
+	}
+	if s.Trace != nil {
+		if err := s.Trace.resolve(f); err != nil {
+			return nil, err
+		}
+		// If every entry brings its own payload, the warm-up jobs use the payload of the first one
+		if s.Payload == nil && (s.Matrix == nil || len(s.Matrix.Payloads) == 0) && len(s.Trace.Entries) > 0 {
+			s.Payload = s.Trace.Entries[0].Payload
+		}
+	}
+
+	if err := s.Validate(); err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+// Load reads a scenario file from the command line, a relative payload file is resolved against the directory
+// of the scenario. Unlike with Parse, the referenced files may be anywhere.
+func Load(path string) (*Scenario, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read scenario: %w", err)
+	}
+	return parse(data, files{baseDir: filepath.Dir(path)})
+}
+
+// files resolves the files referenced by a scenario. Scenarios submitted over the API may only reference
+// files inside root, otherwise any client could make the server read and submit any file it can access.
+type files struct {
+	// baseDir is the directory relative paths are resolved against
+	baseDir string
+	// root confines the referenced files, they are not confined if it is empty
+	root string
+}
+
+// path resolves name, for confined files absolute paths and paths leaving root are rejected. Symbolic links
+// are followed before the check, so a link inside root cannot point outside of it.
+func (f files) path(name string) (string, error) {
+	if f.root == "" {
+		if filepath.IsAbs(name) {
+			return name, nil
+		}
+		return filepath.Join(f.baseDir, name), nil
+	}
+
+	if filepath.IsAbs(name) {
+		return "", fmt.Errorf("%s: absolute paths are not allowed, paths are relative to the scenario directory", name)
+	}
+	path := filepath.Join(f.baseDir, name)
+	if !inside(f.root, path) {
+		return "", fmt.Errorf("%s is outside the scenario directory", name)
+	}
+
+	root, err := filepath.EvalSymlinks(f.root)
+	if err != nil {
+		return "", fmt.Errorf("failed to read %s", name)
+	}
+	target, err := filepath.EvalSymlinks(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to read %s", name)
+	}
+	if !inside(root, target) {
+		return "", fmt.Errorf("%s is outside the scenario directory", name)
+	}
+	return path, nil
+}
+
+// inside reports whether path is root or below it
+func inside(root string, path string) bool {
+	rel, err := filepath.Rel(root, path)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+// open opens the file name refers to. The errors of confined files do not tell why the file could not be
+// opened, so clients cannot probe the file system.
+func (f files) open(name string) (*os.File, string, error) {
+	path, err := f.path(name)
+	if err != nil {
+		return nil, "", err
+	}
+	file, err := os.Open(path)
+	if err != nil && f.root != "" {
+		return nil, "", fmt.Errorf("failed to read %s", name)
+	}
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to read %s: %w", name, err)
+	}
+	return file, path, nil
+}
+
+// resolvePayload loads the payload file into p if one is given
+func resolvePayload(p **payload.RESTPayload, payloadFile string, f files) error {
+	if payloadFile == "" {
+		return nil
+	}
+	if *p != nil {
+		return errors.New("payload and payload_file are mutually exclusive")
+	}
+	file, path, err := f.open(payloadFile)
+	if err != nil {
+		return fmt.Errorf("payload file: %w", err)
+	}
+	defer file.Close()
+	loaded, err := loadPayload(file, path)
+	if err != nil {
+		return err
+	}
+	*p = loaded
+	return nil
+}
+
+func loadPayload(r io.Reader, path string) (*payload.RESTPayload, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read payload file %s: %w", path, err)
+	}
+	var raw any
+	if err := yaml.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("failed to parse payload file %s: %w", path, err)
+	}
+	var p payload.RESTPayload
+	if err := remarshal(raw, &p); err != nil {
+		return nil, fmt.Errorf("failed to parse payload file %s: %w", path, err)
+	}
+	return &p, nil
+}
+
+// remarshal maps a decoded YAML document onto a struct with JSON tags
+func remarshal(raw any, v any) error {
+	data, err := json.Marshal(raw)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
+
+// Validate checks the scenario.
+// The executor and payload may be omitted if the matrix defines them. A trace has to be resolved by Parse before.
+func (s *Scenario) Validate() error {
+	if s.Matrix == nil || len(s.Matrix.Executors) == 0 {
+		if err := s.Executor.validate(); err != nil {
+			return err
+		}
+	}
+	if s.Matrix == nil || len(s.Matrix.Payloads) == 0 {
+		if err := validatePayload(s.Payload); err != nil {
+			return err
+		}
+	}
+	if s.Matrix != nil {
+		if err := s.Matrix.validate(); err != nil {
+			return err
+		}
+	}
+
+	if s.Trace != nil {
+		if s.Load.Count != 0 || s.Load.Rate != 0 || s.Load.Duration != 0 {
+			return errors.New("load.count, load.rate and load.duration cannot be combined with a trace")
+		}
+		if err := s.Trace.validate(s.Payload != nil || (s.Matrix != nil && len(s.Matrix.Payloads) > 0)); err != nil {
+			return err
+		}
+	} else if err := s.Load.validate(); err != nil {
+		return err
+	}
+	if s.Load.Concurrency != nil && *s.Load.Concurrency < 0 {
+		return errors.New("load.concurrency must not be negative")
+	}
+
+	if s.Template != nil {
+		if err := s.Template.validate(s.payloads()); err != nil {
+			return err
+		}
+	}
+
+	if s.MaxAttempts < 0 {
+		return errors.New("max_attempts must not be negative")
+	}
+	if s.Warmup.Count < 0 || s.Warmup.Pause < 0 {
+		return errors.New("warmup.count and warmup.pause must not be negative")
+	}
+	return nil
+}
+
+func (e ExecutorSpec) validate() error {
+	switch e.Type {
+	case ExecutorHadesDocker, ExecutorHadesKubernetes, ExecutorJenkins:
+	case "":
+		return errors.New("executor.type is required")
+	default:
+		return fmt.Errorf("unknown executor type %q", e.Type)
+	}
+	if e.Host == "" {
+		return errors.New("executor.host is required")
+	}
+	return nil
+}
+
+// payloads returns all payloads of the scenario, i.e. of the scenario itself, the matrix and the trace
+func (s *Scenario) payloads() []*payload.RESTPayload {
+	var payloads []*payload.RESTPayload
+	if s.Payload != nil {
+		payloads = append(payloads, s.Payload)
+	}
+	if s.Matrix != nil {
+		for _, p := range s.Matrix.Payloads {
+			payloads = append(payloads, p.Payload)
+		}
+	}
+	if s.Trace != nil {
+		for _, entry := range s.Trace.Entries {
+			if entry.Payload != nil {
+				payloads = append(payloads, entry.Payload)
+			}
+		}
+	}
+	return payloads
+}
+
+func (t *Template) validate(payloads []*payload.RESTPayload) error {
+	for name, choices := range t.Choices {
+		if len(choices) == 0 {
+			return fmt.Errorf("template.choices.%s must not be empty", name)
+		}
+	}
+	// Payloads of trace entries are often shared, they are checked once
+	checked := make(map[*payload.RESTPayload]bool)
+	for _, p := range payloads {
+		if p == nil || checked[p] {
+			continue
+		}
+		checked[p] = true
+		if err := payloadtemplate.Check(*p); err != nil {
+			return fmt.Errorf("payload %s: %w", p.Name, err)
+		}
+	}
+	return nil
+}
+
+func (l LoadProfile) validate() error {
+	if l.Count < 0 || l.Rate < 0 || l.Duration < 0 {
+		return errors.New("load.count, load.rate and load.duration must not be negative")
+	}
+	if l.Rate > 0 {
+		if l.Count > 0 {
+			return errors.New("load.count and load.rate are mutually exclusive")
+		}
+		if l.Duration == 0 {
+			return errors.New("load.rate requires load.duration")
+		}
+	}
+	if l.JobCount() < 1 {
+		return errors.New("load.count must be at least 1")
+	}
+	return nil
+}
+
+func validatePayload(p *payload.RESTPayload) error {
+	if p == nil {
+		return errors.New("either payload or payload_file is required")
+	}
+	if p.Name == "" {
+		return errors.New("payload.name is required")
+	}
+	return nil
+}
+
+// JobCount returns the number of measured jobs of a run of the scenario
+func (s *Scenario) JobCount() int {
+	if s.Trace != nil {
+		return len(s.Trace.Entries)
+	}
+	return s.Load.JobCount()
+}
+
+// JobCount returns the number of jobs, derived from rate and duration if no count is given
+func (l LoadProfile) JobCount() int {
+	if l.Rate > 0 {
+		return int(l.Rate * l.Duration.Std().Seconds())
+	}
+	return l.Count
+}
+
+// Interval returns the time between two job submissions, 0 if the jobs are submitted as fast as possible
+func (l LoadProfile) Interval() time.Duration {
+	count := l.JobCount()
+	if l.Duration == 0 || count == 0 {
+		return 0
+	}
+	return l.Duration.Std() / time.Duration(count)
+}
+
+// NewExecutor creates the executor targeted by the spec
+func (e ExecutorSpec) NewExecutor(client *http.Client) (executor.Executor, error) {
+	switch e.Type {
+	case ExecutorHadesDocker:
+		return executor.NewHadesExecutor(e.Host, executor.Docker, client), nil
+	case ExecutorHadesKubernetes:
+		return executor.NewHadesExecutor(e.Host, executor.Kubernetes, client), nil
+	case ExecutorJenkins:
+		return executor.NewJenkinsExecutor(e.Host, e.User, e.APIToken, e.JobPath, e.UseParameters, client), nil
+	default:
+		return nil, fmt.Errorf("unknown executor type %q", e.Type)
+	}
+}
@@ -0,0 +1,196 @@
+package scenario
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+const testScenario = "name: test\nexecutor: {type: hades-docker, host: http://hades}\n"
+
+// writeFiles creates the files below dir, the names may contain directories
+func writeFiles(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestParse(t *testing.T) {
+	yaml := testScenario + `payload: {name: job}
+load: {rate: 2, duration: 90s, concurrency: 5}
+warmup: {count: 3, pause: 30s}
+labels: {env: test}
+`
+	json := `{"name": "test", "executor": {"type": "hades-docker", "host": "http://hades"}, "payload": {"name": "job"},
+  "load": {"rate": 2, "duration": "90s", "concurrency": 5}, "warmup": {"count": 3, "pause": "30s"}, "labels": {"env": "test"}}`
+
+	for format, data := range map[string]string{"yaml": yaml, "json": json} {
+		t.Run(format, func(t *testing.T) {
+			s, err := Parse([]byte(data), t.TempDir())
+			if err != nil {
+				t.Fatal(err)
+			}
+			if s.Name != "test" || s.Executor.Type != ExecutorHadesDocker || s.Payload == nil || s.Payload.Name != "job" {
+				t.Fatalf("unexpected scenario %+v", s)
+			}
+			// The job count is derived from the rate
+			if s.JobCount() != 180 || s.Load.Interval() != 500*time.Millisecond {
+				t.Fatalf("expected 180 jobs every 500ms, got %d every %s", s.JobCount(), s.Load.Interval())
+			}
+			if s.Load.Concurrency == nil || *s.Load.Concurrency != 5 {
+				t.Fatalf("expected concurrency 5, got %v", s.Load.Concurrency)
+			}
+			if s.Warmup.Count != 3 || s.Warmup.Pause.Std() != 30*time.Second || s.Labels["env"] != "test" {
+				t.Fatalf("unexpected warm-up or labels %+v %v", s.Warmup, s.Labels)
+			}
+		})
+	}
+}
+
+func TestParsePayloadFile(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"payloads/job.json": `{"name": "from json"}`,
+		"payloads/job.yaml": "name: from yaml\n",
+	})
+
+	for file, name := range map[string]string{"payloads/job.json": "from json", "payloads/job.yaml": "from yaml"} {
+		s, err := Parse([]byte(testScenario+"load: {count: 1}\npayload_file: "+file+"\n"), dir)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if s.Payload == nil || s.Payload.Name != name {
+			t.Fatalf("%s: expected payload %q, got %+v", file, name, s.Payload)
+		}
+	}
+}
+
+func TestParseInvalid(t *testing.T) {
+	tests := []struct {
+		scenario string
+		err      string
+	}{
+		{"", "scenario is empty"},
+		{"name: test\nexecutor: {host: http://hades}\npayload: {name: job}\nload: {count: 1}\n", "executor.type is required"},
+		{"name: test\nexecutor: {type: gitlab, host: http://hades}\npayload: {name: job}\nload: {count: 1}\n", `unknown executor type "gitlab"`},
+		{"name: test\nexecutor: {type: hades-docker}\npayload: {name: job}\nload: {count: 1}\n", "executor.host is required"},
+		{testScenario + "load: {count: 1}\n", "either payload or payload_file is required"},
+		{testScenario + "payload: {name: job}\npayload_file: job.json\nload: {count: 1}\n", "payload and payload_file are mutually exclusive"},
+		{testScenario + "payload: {steps: []}\nload: {count: 1}\n", "payload.name is required"},
+		{testScenario + "payload: {name: job}\nload: {count: 0}\n", "load.count must be at least 1"},
+		{testScenario + "payload: {name: job}\nload: {count: 1, rate: 1, duration: 1m}\n", "load.count and load.rate are mutually exclusive"},
+		{testScenario + "payload: {name: job}\nload: {rate: 1}\n", "load.rate requires load.duration"},
+		{testScenario + "payload: {name: job}\nload: {count: 1, duration: 1h30}\n", "duration"},
+	}
+	for _, test := range tests {
+		_, err := Parse([]byte(test.scenario), t.TempDir())
+		if err == nil || !strings.Contains(err.Error(), test.err) {
+			t.Errorf("%q: expected error %q, got %v", test.scenario, test.err, err)
+		}
+	}
+}
+
+func TestLoad(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"payloads/ok.json": `{"name": "ok"}`,
+		"scenarios/s.yaml": testScenario + "load: {count: 1}\npayload_file: ../payloads/ok.json\n",
+	})
+
+	// The payload file is relative to the scenario file, unlike with Parse it may be outside of its directory
+	s, err := Load(filepath.Join(dir, "scenarios/s.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.Payload == nil || s.Payload.Name != "ok" {
+		t.Fatalf("expected the payload to be loaded, got %+v", s.Payload)
+	}
+
+	if _, err := Load(filepath.Join(dir, "missing.yaml")); err == nil || !errors.Is(err, fs.ErrNotExist) {
+		t.Fatalf("expected a not exist error, got %v", err)
+	}
+}
+
+func TestParseConfinesFiles(t *testing.T) {
+	dir := t.TempDir()
+	root := filepath.Join(dir, "scenarios")
+	writeFiles(t, dir, map[string]string{
+		"secret.json":                 `{"name": "secret"}`,
+		"scenarios/payloads/ok.json":  `{"name": "ok"}`,
+		"scenarios/traces/ok.jsonl":   `{"offset": 1, "payload_ref": "../payloads/ok.json"}` + "\n",
+		"scenarios/traces/bad.jsonl":  `{"offset": 1, "payload_ref": "../../secret.json"}` + "\n",
+		"scenarios/traces/abs.jsonl":  `{"offset": 1, "payload_ref": "` + filepath.Join(dir, "secret.json") + `"}` + "\n",
+		"scenarios/nested/deep.jsonl": `{"offset": 1, "payload_ref": "../payloads/ok.json"}` + "\n",
+		"scenarios/traces/link.jsonl": `{"offset": 1, "payload_ref": "../payloads/secret.json"}` + "\n",
+	})
+	// Links are followed, the ones leaving the scenario directory are rejected
+	links := map[string]string{
+		"scenarios/payloads/secret.json": filepath.Join(dir, "secret.json"),
+		"scenarios/payloads/alias.json":  filepath.Join(root, "payloads/ok.json"),
+		"scenarios/outside":              dir,
+		"link":                           root,
+	}
+	for name, target := range links {
+		if err := os.Symlink(target, filepath.Join(dir, name)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	accepted := []string{
+		"load: {count: 1}\npayload_file: payloads/ok.json\n",
+		"load: {count: 1}\npayload_file: payloads/../payloads/ok.json\n",
+		"load: {count: 1}\nmatrix: {payloads: [{name: ok, payload_file: payloads/ok.json}]}\n",
+		"trace: {file: traces/ok.jsonl}\n",
+		"trace: {file: nested/deep.jsonl}\n",
+		"load: {count: 1}\npayload_file: payloads/alias.json\n",
+	}
+	for _, scenario := range accepted {
+		if _, err := Parse([]byte(testScenario+scenario), root); err != nil {
+			t.Errorf("%q: %v", scenario, err)
+		}
+		// The scenario directory itself may be a link
+		if _, err := Parse([]byte(testScenario+scenario), filepath.Join(dir, "link")); err != nil {
+			t.Errorf("%q in linked directory: %v", scenario, err)
+		}
+	}
+
+	rejected := []struct {
+		scenario string
+		err      string
+	}{
+		{"payload_file: " + filepath.Join(dir, "secret.json") + "\n", "absolute paths are not allowed"},
+		{"payload_file: ../secret.json\n", "outside the scenario directory"},
+		{"payload_file: payloads/../../secret.json\n", "outside the scenario directory"},
+		{"payload_file: ..\n", "outside the scenario directory"},
+		{"payload: {name: x}\nmatrix: {payloads: [{name: secret, payload_file: ../secret.json}]}\n", "outside the scenario directory"},
+		{"payload: {name: x}\ntrace: {file: ../secret.json}\n", "outside the scenario directory"},
+		{"payload: {name: x}\ntrace: {file: " + filepath.Join(root, "traces/ok.jsonl") + "}\n", "absolute paths are not allowed"},
+		{"payload: {name: x}\ntrace: {file: traces/bad.jsonl}\n", "outside the scenario directory"},
+		{"payload: {name: x}\ntrace: {file: traces/abs.jsonl}\n", "absolute paths are not allowed"},
+		{"payload: {name: x}\ntrace: {entries: [{offset: 1s, payload_ref: ../secret.json}]}\n", "outside the scenario directory"},
+		{"payload_file: payloads/secret.json\n", "outside the scenario directory"},
+		{"payload_file: outside/secret.json\n", "outside the scenario directory"},
+		{"payload: {name: x}\ntrace: {file: traces/link.jsonl}\n", "outside the scenario directory"},
+		// Whether a file exists is not revealed
+		{"payload_file: payloads/missing.json\n", "failed to read payloads/missing.json"},
+	}
+	for _, test := range rejected {
+		_, err := Parse([]byte(testScenario+test.scenario), root)
+		if err == nil || !strings.Contains(err.Error(), test.err) {
+			t.Errorf("%q: expected error %q, got %v", test.scenario, test.err, err)
+		} else if strings.Contains(err.Error(), "no such file") {
+			t.Errorf("%q: error reveals the file system: %v", test.scenario, err)
+		}
+	}
+}
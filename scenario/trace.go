@@ -0,0 +1,179 @@
+package scenario
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/ls1intum/hades/shared/payload"
+)
+
+// maxTraceLine bounds the length of a line of a trace file, i.e. of a single recorded submission
+const maxTraceLine = 16 * 1024 * 1024
+
+// Trace replays recorded submissions, e.g. from the logs of a CI system before an exam deadline, instead
+// of the synthetic load profile. The jobs are submitted at their recorded offsets, so the inter-arrival
+// times of the recording are preserved.
+type Trace struct {
+	// File is a JSONL file with one TraceEntry per line, alternatively the entries are given inline
+	File    string       `json:"file,omitempty"`
+	Entries []TraceEntry `json:"entries,omitempty"`
+	// TimeScale multiplies the offsets, e.g. 0.5 replays the trace twice as fast. It defaults to 1.
+	TimeScale float64 `json:"time_scale,omitempty"`
+	// Executor only replays the entries recorded for this executor, all entries are replayed if it is empty
+	Executor string `json:"executor,omitempty"`
+}
+
+// TraceEntry is a recorded submission. It is due either at Offset after the start of the trace or, if
+// recorded with absolute times, at Timestamp relative to the earliest entry. Entries without a payload
+// or payload_ref use the payload of the scenario.
+type TraceEntry struct {
+	Offset    Duration   `json:"offset,omitempty"`
+	Timestamp *time.Time `json:"timestamp,omitempty"`
+	// PayloadRef is a JSON or YAML file containing the payload, relative to the trace file
+	Payload    *payload.RESTPayload `json:"payload,omitempty"`
+	PayloadRef string               `json:"payload_ref,omitempty"`
+	// Executor is the executor the submission was recorded for, it is only used to filter the trace
+	Executor string `json:"executor,omitempty"`
+}
+
+// ParseTrace reads a JSONL trace with one TraceEntry per line, blank lines are skipped
+func ParseTrace(r io.Reader) ([]TraceEntry, error) {
+	var entries []TraceEntry
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxTraceLine)
+	for line := 1; scanner.Scan(); line++ {
+		data := bytes.TrimSpace(scanner.Bytes())
+		if len(data) == 0 {
+			continue
+		}
+		var entry TraceEntry
+		if err := json.Unmarshal(data, &entry); err != nil {
+			return nil, fmt.Errorf("trace line %d: %w", line, err)
+		}
+		entries = append(entries, entry)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read trace: %w", err)
+	}
+	return entries, nil
+}
+
+// resolve loads the trace file and the referenced payloads, filters the entries by executor, converts
+// timestamps to offsets and sorts the entries by offset. Relative paths are resolved against the base
+// directory of f, payload references of a trace file against the directory of the file. Both stay confined
+// to the root of f.
+func (t *Trace) resolve(f files) error {
+	refs := f
+	if t.File != "" {
+		if len(t.Entries) > 0 {
+			return errors.New("trace.file and trace.entries are mutually exclusive")
+		}
+		file, path, err := f.open(t.File)
+		if err != nil {
+			return fmt.Errorf("trace file: %w", err)
+		}
+		defer file.Close()
+		if t.Entries, err = ParseTrace(file); err != nil {
+			return fmt.Errorf("%s: %w", t.File, err)
+		}
+		t.File = ""
+		refs.baseDir = filepath.Dir(path)
+	}
+
+	if t.Executor != "" {
+		filtered := t.Entries[:0]
+		for _, entry := range t.Entries {
+			if entry.Executor == t.Executor {
+				filtered = append(filtered, entry)
+			}
+		}
+		t.Entries = filtered
+	}
+
+	// Every referenced payload file is loaded once and shared by its entries
+	payloads := make(map[string]*payload.RESTPayload)
+	var first *time.Time
+	for i := range t.Entries {
+		entry := &t.Entries[i]
+		if entry.PayloadRef != "" {
+			if entry.Payload != nil {
+				return fmt.Errorf("trace entry %d: payload and payload_ref are mutually exclusive", i)
+			}
+			if _, ok := payloads[entry.PayloadRef]; !ok {
+				if err := resolvePayload(&entry.Payload, entry.PayloadRef, refs); err != nil {
+					return fmt.Errorf("trace entry %d: %w", i, err)
+				}
+				payloads[entry.PayloadRef] = entry.Payload
+			}
+			entry.Payload = payloads[entry.PayloadRef]
+			entry.PayloadRef = ""
+		}
+
+		if entry.Timestamp != nil {
+			if entry.Offset != 0 {
+				return fmt.Errorf("trace entry %d: offset and timestamp are mutually exclusive", i)
+			}
+			if first == nil || entry.Timestamp.Before(*first) {
+				first = entry.Timestamp
+			}
+		}
+	}
+
+	if first != nil {
+		for i := range t.Entries {
+			entry := &t.Entries[i]
+			if entry.Timestamp == nil {
+				return fmt.Errorf("trace entry %d: either all or no entries must have a timestamp", i)
+			}
+			entry.Offset = Duration(entry.Timestamp.Sub(*first))
+			entry.Timestamp = nil
+		}
+	}
+
+	sort.SliceStable(t.Entries, func(i, j int) bool {
+		return t.Entries[i].Offset < t.Entries[j].Offset
+	})
+	return nil
+}
+
+// validate checks the resolved trace, entries without payload require a payload of the scenario or matrix
+func (t *Trace) validate(hasDefaultPayload bool) error {
+	if len(t.Entries) == 0 {
+		if t.Executor != "" {
+			return fmt.Errorf("trace has no entries for executor %q", t.Executor)
+		}
+		return errors.New("trace has no entries")
+	}
+	if t.TimeScale < 0 {
+		return errors.New("trace.time_scale must not be negative")
+	}
+	for i, entry := range t.Entries {
+		if entry.Offset < 0 {
+			return fmt.Errorf("trace entry %d: offset must not be negative", i)
+		}
+		if entry.Payload == nil && !hasDefaultPayload {
+			return fmt.Errorf("trace entry %d: no payload and the scenario has no payload", i)
+		}
+		if entry.Payload != nil {
+			if err := validatePayload(entry.Payload); err != nil {
+				return fmt.Errorf("trace entry %d: %w", i, err)
+			}
+		}
+	}
+	return nil
+}
+
+// ScaledOffset returns the offset of the entry scaled by the time scale of the trace
+func (t *Trace) ScaledOffset(entry TraceEntry) time.Duration {
+	if t.TimeScale == 0 {
+		return entry.Offset.Std()
+	}
+	return time.Duration(float64(entry.Offset) * t.TimeScale)
+}
@@ -0,0 +1,98 @@
+package scenario
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseTrace(t *testing.T) {
+	trace := `{"offset": "1.5s", "executor": "hades-docker"}
+
+{"offset": 3, "payload": {"name": "inline"}}
+`
+	entries, err := ParseTrace(strings.NewReader(trace))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 2 {
+		t.Fatalf("expected 2 entries, got %d", len(entries))
+	}
+	if entries[0].Offset.Std() != 1500*time.Millisecond || entries[0].Executor != "hades-docker" {
+		t.Fatalf("unexpected first entry %+v", entries[0])
+	}
+	if entries[1].Offset.Std() != 3*time.Second || entries[1].Payload == nil || entries[1].Payload.Name != "inline" {
+		t.Fatalf("unexpected second entry %+v", entries[1])
+	}
+
+	if _, err := ParseTrace(strings.NewReader("{\"offset\": \"1s\"}\nnot json\n")); err == nil || !strings.Contains(err.Error(), "line 2") {
+		t.Fatalf("expected an error in line 2, got %v", err)
+	}
+}
+
+func TestTraceResolve(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"payloads/java.json":   `{"name": "java"}`,
+		"traces/exam.jsonl":    `{"timestamp": "2025-02-04T12:00:05Z", "executor": "hades-docker", "payload_ref": "../payloads/java.json"}` + "\n" + `{"timestamp": "2025-02-04T12:00:00Z", "executor": "hades-docker"}` + "\n" + `{"timestamp": "2025-02-04T12:00:01Z", "executor": "jenkins"}` + "\n" + `{"timestamp": "2025-02-04T12:00:02Z", "executor": "hades-docker", "payload_ref": "../payloads/java.json"}` + "\n",
+		"traces/mixed.jsonl":   `{"timestamp": "2025-02-04T12:00:00Z"}` + "\n" + `{"offset": "1s"}` + "\n",
+		"traces/both.jsonl":    `{"offset": "1s", "timestamp": "2025-02-04T12:00:00Z"}` + "\n",
+		"traces/payload.jsonl": `{"offset": "1s", "payload": {"name": "inline"}, "payload_ref": "../payloads/java.json"}` + "\n",
+	})
+
+	t.Run("timestamps", func(t *testing.T) {
+		s, err := Parse([]byte(testScenario+"payload: {name: default}\ntrace: {file: traces/exam.jsonl, executor: hades-docker}\n"), dir)
+		if err != nil {
+			t.Fatal(err)
+		}
+		entries := s.Trace.Entries
+		// The jenkins entry is filtered, the others are sorted by their offset from the earliest timestamp
+		expected := []time.Duration{0, 2 * time.Second, 5 * time.Second}
+		if len(entries) != len(expected) {
+			t.Fatalf("expected %d entries, got %d", len(expected), len(entries))
+		}
+		for i, entry := range entries {
+			if entry.Offset.Std() != expected[i] || entry.Timestamp != nil {
+				t.Fatalf("entry %d: expected offset %s, got %s", i, expected[i], entry.Offset.Std())
+			}
+		}
+		if entries[0].Payload != nil {
+			t.Fatalf("expected the first entry to use the payload of the scenario, got %+v", entries[0].Payload)
+		}
+		if entries[1].Payload == nil || entries[1].Payload.Name != "java" || entries[1].Payload != entries[2].Payload {
+			t.Fatal("expected the referenced payload to be loaded once and shared")
+		}
+	})
+
+	t.Run("inline entries are sorted", func(t *testing.T) {
+		s, err := Parse([]byte(testScenario+"payload: {name: default}\ntrace: {entries: [{offset: 3s}, {offset: 1s}, {offset: 2s}]}\n"), dir)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for i, entry := range s.Trace.Entries {
+			if entry.Offset.Std() != time.Duration(i+1)*time.Second {
+				t.Fatalf("entry %d: expected offset %ds, got %s", i, i+1, entry.Offset.Std())
+			}
+		}
+	})
+
+	invalid := []struct {
+		name  string
+		trace string
+		err   string
+	}{
+		{"file and entries", "{file: traces/exam.jsonl, entries: [{offset: 1s}]}", "trace.file and trace.entries are mutually exclusive"},
+		{"offset and timestamp", "{file: traces/both.jsonl}", "offset and timestamp are mutually exclusive"},
+		{"payload and payload_ref", "{file: traces/payload.jsonl}", "payload and payload_ref are mutually exclusive"},
+		{"some timestamps", "{file: traces/mixed.jsonl}", "either all or no entries must have a timestamp"},
+		{"unknown executor", "{file: traces/exam.jsonl, executor: hades-k8s}", `no entries for executor "hades-k8s"`},
+	}
+	for _, test := range invalid {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := Parse([]byte(testScenario+"payload: {name: default}\ntrace: "+test.trace+"\n"), dir)
+			if err == nil || !strings.Contains(err.Error(), test.err) {
+				t.Fatalf("expected error %q, got %v", test.err, err)
+			}
+		})
+	}
+}
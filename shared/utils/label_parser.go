@@ -0,0 +1,26 @@
+package utils
+
+import (
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// LabelPrefix marks query parameters which carry a label, e.g. label.cluster=aks
+const LabelPrefix = "label."
+
+// ParseLabelParams returns the labels given as label.<key>=<value> query parameters, nil if there are none
+func ParseLabelParams(c *gin.Context) map[string]string {
+	var labels map[string]string
+	for key, values := range c.Request.URL.Query() {
+		name, ok := strings.CutPrefix(key, LabelPrefix)
+		if !ok || name == "" || len(values) == 0 {
+			continue
+		}
+		if labels == nil {
+			labels = make(map[string]string)
+		}
+		labels[name] = values[len(values)-1]
+	}
+	return labels
+}
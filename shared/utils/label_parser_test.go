@@ -0,0 +1,37 @@
+package utils
+
+import (
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestParseLabelParams(t *testing.T) {
+	tests := []struct {
+		name     string
+		query    string
+		expected map[string]string
+	}{
+		{"no labels", "executor=Hades&group_by=label.env", nil},
+		{"single label", "label.env=prod", map[string]string{"env": "prod"}},
+		{"several labels", "label.env=prod&executor=Hades&label.cluster=aks", map[string]string{"env": "prod", "cluster": "aks"}},
+		{"last value wins", "label.env=dev&label.env=prod", map[string]string{"env": "prod"}},
+		{"empty value", "label.env=", map[string]string{"env": ""}},
+		{"escaped key", "label.team.name=a%20b&label.a%22b=q", map[string]string{"team.name": "a b", `a"b`: "q"}},
+		{"empty key", "label.=prod", nil},
+		{"other prefix", "labels.env=prod", nil},
+	}
+	gin.SetMode(gin.TestMode)
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			c, _ := gin.CreateTestContext(httptest.NewRecorder())
+			c.Request = httptest.NewRequest("GET", "/?"+test.query, nil)
+
+			if labels := ParseLabelParams(c); !reflect.DeepEqual(labels, test.expected) {
+				t.Fatalf("expected labels %v, got %v", test.expected, labels)
+			}
+		})
+	}
+}